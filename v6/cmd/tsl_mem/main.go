@@ -20,6 +20,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -64,17 +65,12 @@ func main() {
 	check(err)
 
 	// Filter the books collection using our transformed TSL tree.
-	for _, book := range Books {
-		eval := evalFactory(book)
-
-		matchingFilter, err := semantics.Walk(newTree, eval)
-		check(err)
-
-		// Convert interface{} to bool
-		if match, ok := matchingFilter.(bool); ok && match {
-			books = append(books, book)
-		}
+	get := func(book Book, k string) (interface{}, bool) {
+		return evalFactory(book)(k)
 	}
+	matches, err := semantics.Filter(context.Background(), newTree, Books, get)
+	check(err)
+	books = append(books, matches...)
 
 	// Printout the filtered list.
 	switch *outputPtr {
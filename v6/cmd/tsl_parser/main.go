@@ -26,6 +26,7 @@ import (
 
 	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
 	"github.com/yaacov/tree-search-language/v6/pkg/walkers/graphviz"
+	"github.com/yaacov/tree-search-language/v6/pkg/walkers/ident"
 	"github.com/yaacov/tree-search-language/v6/pkg/walkers/sql"
 )
 
@@ -67,8 +68,16 @@ func main() {
 		var args []interface{}
 		var filter sq.Sqlizer
 
+		// Allow the columns of the input, it is written by the user running the command.
+		var columns []string
+		_, err = ident.Walk(tree, func(name string) (string, error) {
+			columns = append(columns, name)
+			return name, nil
+		})
+		check(err)
+
 		// Use Squirrel to walk the tree, and create SQL filter.
-		filter, err = sql.Walk(tree)
+		filter, err = sql.WalkWithOptions(tree, sql.WithAllowedColumns(columns...))
 		check(err)
 
 		// Add SQL template to bytes slice.
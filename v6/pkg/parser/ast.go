@@ -2,7 +2,8 @@ package parser
 
 import (
 	"fmt"
-	"strconv"
+	"math/big"
+	"net/netip"
 	"time"
 )
 
@@ -22,6 +23,9 @@ const (
 	NodeArrayLiteral
 	NodeBooleanLiteral
 	NodeNullLiteral
+	NodeIPLiteral
+	NodeCIDRLiteral
+	NodeVersionLiteral
 )
 
 // String returns the string representation of NodeKind
@@ -47,6 +51,12 @@ func (nk NodeKind) String() string {
 		return "BOOLEAN"
 	case NodeNullLiteral:
 		return "NULL"
+	case NodeIPLiteral:
+		return "IP"
+	case NodeCIDRLiteral:
+		return "CIDR"
+	case NodeVersionLiteral:
+		return "VERSION"
 	default:
 		return "UNKNOWN"
 	}
@@ -82,6 +92,12 @@ const (
 	OpREQ
 	OpRNE
 	OpUMinus
+	OpWithin
+	OpCount
+	OpAvg
+	OpMin
+	OpMax
+	OpAggSum
 )
 
 // String returns the string representation of OpType
@@ -139,6 +155,18 @@ func (op OpType) String() string {
 		return "~!"
 	case OpUMinus:
 		return "NEG"
+	case OpWithin:
+		return "WITHIN"
+	case OpCount:
+		return "COUNT"
+	case OpAvg:
+		return "AVG"
+	case OpMin:
+		return "MIN"
+	case OpMax:
+		return "MAX"
+	case OpAggSum:
+		return "AGG_SUM"
 	default:
 		return "UNKNOWN"
 	}
@@ -155,15 +183,15 @@ type Node struct {
 	Position int // Position in the input string for error reporting
 }
 
-// parseSizeValue converts size strings like "5k", "2M", "1G" to numeric values
-func parseSizeValue(value string) (float64, error) {
+// parseSizeValue converts size strings like "5k", "2M", "1G" to exact numeric values
+func parseSizeValue(value string) (*big.Rat, error) {
 	if len(value) == 0 {
-		return 0, fmt.Errorf("empty value")
+		return nil, fmt.Errorf("empty value")
 	}
 
 	// Check for size suffixes
 	lastChar := value[len(value)-1]
-	var multiplier float64
+	var multiplier int64
 	var numStr string
 
 	switch lastChar {
@@ -185,7 +213,7 @@ func parseSizeValue(value string) (float64, error) {
 	case 'i', 'I':
 		// Handle binary prefixes like "Ki", "Mi", "Gi", "Ti", "Pi"
 		if len(value) < 2 {
-			return strconv.ParseFloat(value, 64)
+			return parseRat(value)
 		}
 		secondLastChar := value[len(value)-2]
 		switch secondLastChar {
@@ -206,28 +234,43 @@ func parseSizeValue(value string) (float64, error) {
 			numStr = value[:len(value)-2]
 		default:
 			// Not a valid binary prefix, parse as regular number
-			return strconv.ParseFloat(value, 64)
+			return parseRat(value)
 		}
 	default:
 		// No suffix, parse as regular number
-		return strconv.ParseFloat(value, 64)
+		return parseRat(value)
 	}
 
 	// Parse the numeric part
-	num, err := strconv.ParseFloat(numStr, 64)
+	num, err := parseRat(numStr)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return num * multiplier, nil
+	return num.Mul(num, new(big.Rat).SetInt64(multiplier)), nil
+}
+
+// parseRat parses an integer, decimal or scientific notation number exactly
+func parseRat(value string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("invalid number: %s", value)
+	}
+	return r, nil
 }
 
 // NewNumberNode creates a numeric literal node
+//
+// Numbers keep their exact form, integers that fit in an int64 are stored as
+// int64, all other numbers are stored as Decimal.
 func NewNumberNode(value string, pos int) *Node {
-	// Parse as float64 to handle all numeric types consistently, including size suffixes
-	val, err := parseSizeValue(value)
-	if err != nil {
-		val = 0.0
+	var val interface{} = int64(0)
+	if r, err := parseSizeValue(value); err == nil {
+		if r.IsInt() && r.Num().IsInt64() {
+			val = r.Num().Int64()
+		} else {
+			val = Decimal{rat: r}
+		}
 	}
 	return &Node{
 		Kind:     NodeNumericLiteral,
@@ -301,6 +344,60 @@ func NewTimestampNode(value string, pos int) *Node {
 	}
 }
 
+// NewIPNode creates an IP address literal node (IPv4 or IPv6)
+func NewIPNode(value string, pos int) *Node {
+	// Try to parse as netip.Addr
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return &Node{
+			Kind:     NodeIPLiteral,
+			Value:    addr,
+			Position: pos,
+		}
+	}
+	// Fallback to string
+	return &Node{
+		Kind:     NodeIPLiteral,
+		Value:    value,
+		Position: pos,
+	}
+}
+
+// NewCIDRNode creates a CIDR prefix literal node (IPv4 or IPv6)
+func NewCIDRNode(value string, pos int) *Node {
+	// Try to parse as netip.Prefix
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return &Node{
+			Kind:     NodeCIDRLiteral,
+			Value:    prefix,
+			Position: pos,
+		}
+	}
+	// Fallback to string
+	return &Node{
+		Kind:     NodeCIDRLiteral,
+		Value:    value,
+		Position: pos,
+	}
+}
+
+// NewVersionNode creates a semantic version literal node
+func NewVersionNode(value string, pos int) *Node {
+	// Try to parse as Version
+	if v, err := ParseVersion(value); err == nil {
+		return &Node{
+			Kind:     NodeVersionLiteral,
+			Value:    v,
+			Position: pos,
+		}
+	}
+	// Fallback to string
+	return &Node{
+		Kind:     NodeVersionLiteral,
+		Value:    value,
+		Position: pos,
+	}
+}
+
 // NewBinaryOpNode creates a binary operation node
 func NewBinaryOpNode(op OpType, left, right *Node, pos int) *Node {
 	return &Node{
@@ -368,7 +465,8 @@ func (n *Node) String() string {
 
 	switch n.Kind {
 	case NodeNumericLiteral, NodeStringLiteral, NodeIdentifier,
-		NodeDateLiteral, NodeTimestampLiteral, NodeBooleanLiteral:
+		NodeDateLiteral, NodeTimestampLiteral, NodeBooleanLiteral,
+		NodeIPLiteral, NodeCIDRLiteral, NodeVersionLiteral:
 		return fmt.Sprintf("%s(%v)", n.Kind, n.Value)
 	case NodeNullLiteral:
 		return "NULL"
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// decimalDigits is the number of fraction digits used to print decimals
+// that have no finite decimal representation (e.g. 1/3)
+const decimalDigits = 34
+
+// Decimal is an exact, arbitrary-precision decimal number
+type Decimal struct {
+	rat *big.Rat
+}
+
+// ParseDecimal parses a decimal string, e.g. "0.1", "-12.5" or "1.23e-4"
+func ParseDecimal(s string) (Decimal, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal: %s", s)
+	}
+	return Decimal{rat: r}, nil
+}
+
+// NewDecimal creates a decimal from a big.Rat value
+func NewDecimal(r *big.Rat) Decimal {
+	return Decimal{rat: new(big.Rat).Set(r)}
+}
+
+// Rat returns a copy of the decimal value as a big.Rat
+func (d Decimal) Rat() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Set(d.rat)
+}
+
+// Float64 returns the nearest float64 value of the decimal
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// String returns the decimal in plain decimal notation
+func (d Decimal) String() string {
+	r := d.Rat()
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	// A fraction has a finite decimal representation only if its denominator
+	// has no prime factors other than 2 and 5
+	digits := 0
+	den := new(big.Int).Set(r.Denom())
+	for _, p := range []int64{2, 5} {
+		n := 0
+		prime := big.NewInt(p)
+		mod := new(big.Int)
+		for {
+			q, m := new(big.Int).QuoRem(den, prime, mod)
+			if m.Sign() != 0 {
+				break
+			}
+			den = q
+			n++
+		}
+		if n > digits {
+			digits = n
+		}
+	}
+
+	if den.Cmp(big.NewInt(1)) != 0 {
+		s := r.FloatString(decimalDigits)
+		return strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return r.FloatString(digits)
+}
+
+// MarshalJSON encodes the decimal as a JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Value implements the database/sql driver.Valuer interface, the decimal is
+// passed to the database as exact decimal text
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"net/netip"
 	"regexp"
 	"strings"
 	"unicode"
@@ -25,7 +26,8 @@ type Lexer struct {
 	pos     int // current position
 	start   int // start of current token
 	tokens  []Token
-	current int // current token index
+	current int  // current token index
+	query   bool // recognize query clause keywords
 }
 
 // Keywords map (case-insensitive) - values will be set after parser generation
@@ -47,11 +49,47 @@ var keywords = map[string]int{
 	"sum":     1,
 }
 
+// Query clause keywords (case-insensitive), only reserved when parsing queries
+// so they can still be used as identifiers in filter expressions
+var queryKeywords = map[string]int{
+	"order":  1, // Will be updated to match generated constants
+	"by":     1,
+	"asc":    1,
+	"desc":   1,
+	"limit":  1,
+	"offset": 1,
+	"select": 1,
+	"as":     1,
+	"where":  1,
+	"group":  1,
+	"having": 1,
+}
+
+// Network operator keywords (case-insensitive), only recognized where the
+// grammar expects them so they can still be used as identifiers, WITHIN after
+// an operand and CIDR between IN and an operand
+var networkKeywords = map[string]int{
+	"within": 1, // Will be updated to match generated constants
+	"cidr":   1,
+}
+
+// Aggregate function names (case-insensitive), only recognized in queries when
+// followed by an opening parenthesis, e.g. COUNT(*) or MAX(pages)
+var aggregateFunctions = map[string]int{
+	"count": 1, // Will be updated to match generated constants
+	"avg":   1,
+	"min":   1,
+	"max":   1,
+}
+
 // Regular expressions for token patterns
 var (
 	// Date and time patterns
 	datePattern    = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
 	rfc3339Pattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`)
+
+	// IP address and CIDR patterns, candidates are validated using net/netip
+	ipPattern = regexp.MustCompile(`^[0-9a-fA-F:.]*[0-9a-fA-F:](/\d{1,3})?`)
 )
 
 // NewLexer creates a new lexer instance
@@ -202,6 +240,28 @@ func (l *Lexer) scanToken() error {
 	case '`':
 		return l.scanString('`')
 	default:
+		if c == 'v' && unicode.IsDigit(l.peek()) {
+			// Put back the character and check if it's a semantic version
+			l.pos--
+			if n := l.versionLiteralLength(); n > 0 {
+				l.pos += n
+				l.addToken(VERSION_LITERAL, l.input[l.start:l.pos])
+				return nil
+			}
+			l.pos++
+		}
+
+		if unicode.IsDigit(c) || c == ':' || isHexLetter(c) {
+			// Put back the character and check if it's an IP address or CIDR
+			l.pos--
+			if n, tokenType := l.ipLiteralLength(); n > 0 {
+				l.pos += n
+				l.addToken(tokenType, l.input[l.start:l.pos])
+				return nil
+			}
+			l.pos++
+		}
+
 		if unicode.IsDigit(c) || (c == '-' && unicode.IsDigit(l.peek())) || (c == '+' && unicode.IsDigit(l.peek())) {
 			// Put back the character and check if it's a date/time pattern first
 			l.pos--
@@ -254,6 +314,64 @@ func (l *Lexer) isDateTimePattern() bool {
 	return false
 }
 
+// isHexLetter checks if a character is a hexadecimal letter
+func isHexLetter(c rune) bool {
+	return (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// ipLiteralLength checks if the current position starts an IP address or
+// a CIDR literal, and returns its length and token type
+func (l *Lexer) ipLiteralLength() (int, int) {
+	candidate := ipPattern.FindString(l.input[l.pos:])
+	if candidate == "" {
+		return 0, 0
+	}
+
+	// The literal must end at a delimiter
+	end := l.pos + len(candidate)
+	if end < len(l.input) {
+		c := rune(l.input[end])
+		if unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.' || c == '/' || c == ':' {
+			return 0, 0
+		}
+	}
+
+	// IPv4 needs four dotted parts, IPv6 needs at least two colons
+	addr := strings.SplitN(candidate, "/", 2)[0]
+	if strings.Count(addr, ":") < 2 && (strings.Count(addr, ".") != 3 || strings.Contains(addr, ":")) {
+		return 0, 0
+	}
+
+	if strings.Contains(candidate, "/") {
+		if _, err := netip.ParsePrefix(candidate); err == nil {
+			return len(candidate), CIDR_LITERAL
+		}
+		return 0, 0
+	}
+	if _, err := netip.ParseAddr(candidate); err == nil {
+		return len(candidate), IP_LITERAL
+	}
+	return 0, 0
+}
+
+// versionLiteralLength checks if the current position starts a semantic
+// version literal, e.g. v1.28.0 or v1.0.0-rc.1, and returns its length
+func (l *Lexer) versionLiteralLength() int {
+	end := l.pos
+	for end < len(l.input) {
+		c := rune(l.input[end])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '.' && c != '-' && c != '+' {
+			break
+		}
+		end++
+	}
+
+	if _, err := ParseVersion(l.input[l.pos:end]); err != nil {
+		return 0
+	}
+	return end - l.pos
+}
+
 // scanDateTime scans a date or RFC3339 timestamp
 func (l *Lexer) scanDateTime() error {
 	start := l.pos
@@ -313,10 +431,11 @@ func (l *Lexer) scanString(quote rune) error {
 			case '`':
 				value.WriteRune('`')
 			default:
-				value.WriteRune(escaped)
+				value.WriteByte(byte(escaped))
 			}
 		} else {
-			value.WriteRune(c)
+			// The lexer reads bytes, copy them so UTF-8 text is kept as is
+			value.WriteByte(byte(c))
 		}
 	}
 
@@ -436,6 +555,12 @@ func (l *Lexer) scanIdentifier() error {
 	lowerValue := strings.ToLower(value)
 	if tokenType, isKeyword := keywords[lowerValue]; isKeyword {
 		l.addToken(tokenType, value)
+	} else if tokenType, isKeyword := queryKeywords[lowerValue]; isKeyword && l.query {
+		l.addToken(tokenType, value)
+	} else if tokenType, isKeyword := networkKeywords[lowerValue]; isKeyword && l.networkKeyword(tokenType) {
+		l.addToken(tokenType, value)
+	} else if tokenType, isFunction := aggregateFunctions[lowerValue]; isFunction && l.query && l.nextNonSpace() == '(' {
+		l.addToken(tokenType, value)
 	} else {
 		l.addToken(IDENTIFIER, value)
 	}
@@ -443,6 +568,68 @@ func (l *Lexer) scanIdentifier() error {
 	return nil
 }
 
+// networkKeyword reports if WITHIN or CIDR is expected at the current token,
+// WITHIN follows an operand or NOT after an operand, CIDR follows IN and is
+// followed by an operand
+func (l *Lexer) networkKeyword(tokenType int) bool {
+	n := len(l.tokens)
+	if tokenType == K_CIDR {
+		return n > 0 && l.tokens[n-1].Type == K_IN && l.operandFollows()
+	}
+
+	if n > 0 && l.tokens[n-1].Type == K_NOT {
+		n--
+	}
+	if n == 0 {
+		return false
+	}
+	switch l.tokens[n-1].Type {
+	case IDENTIFIER, NUMERIC_LITERAL, STRING_LITERAL, DATE, RFC3339, IP_LITERAL, CIDR_LITERAL,
+		VERSION_LITERAL, K_TRUE, K_FALSE, K_NULL, RPAREN, RBRACKET:
+		return true
+	}
+	return false
+}
+
+// operandFollows reports if the input after the current token starts an
+// operand, a literal, an array, a parenthesis or an identifier that is not a
+// keyword
+func (l *Lexer) operandFollows() bool {
+	i := l.pos
+	for i < len(l.input) && unicode.IsSpace(rune(l.input[i])) {
+		i++
+	}
+	if i == len(l.input) {
+		return false
+	}
+
+	c := rune(l.input[i])
+	switch {
+	case unicode.IsDigit(c) || strings.ContainsRune("'\"`[(:", c):
+		return true
+	case unicode.IsLetter(c) || c == '_':
+		end := i
+		for end < len(l.input) && (unicode.IsLetter(rune(l.input[end])) || unicode.IsDigit(rune(l.input[end])) || l.input[end] == '_') {
+			end++
+		}
+		word := strings.ToLower(l.input[i:end])
+		_, keyword := keywords[word]
+		_, queryKeyword := queryKeywords[word]
+		return !keyword && !(queryKeyword && l.query)
+	}
+	return false
+}
+
+// nextNonSpace returns the next character that is not a white space, without advancing
+func (l *Lexer) nextNonSpace() rune {
+	for i := l.pos; i < len(l.input); i++ {
+		if c := rune(l.input[i]); !unicode.IsSpace(c) {
+			return c
+		}
+	}
+	return 0
+}
+
 // NextToken returns the next token for the parser
 func (l *Lexer) NextToken() Token {
 	if l.current >= len(l.tokens) {
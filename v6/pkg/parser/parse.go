@@ -4,10 +4,18 @@ package parser
 type tslLexer struct {
 	lexer *Lexer
 	pos   int
+	start int // START_EXPR or START_QUERY, selects what the parser accepts
 }
 
 // Lex implements the goyacc lexer interface
 func (l *tslLexer) Lex(lval *yySymType) int {
+	// The first token selects the start rule
+	if l.start != 0 {
+		start := l.start
+		l.start = 0
+		return start
+	}
+
 	token := l.lexer.NextToken()
 	l.pos = token.Position
 
@@ -32,31 +40,58 @@ func (l *tslLexer) Error(s string) {
 
 // Parse parses a TSL expression and returns the AST
 func Parse(input string) (*Node, error) {
+	if err := parse(NewLexer(input), START_EXPR); err != nil {
+		return nil, err
+	}
+
+	return parseResult, nil
+}
+
+// ParseQuery parses a TSL query, an optional SELECT projection, a filter
+// expression and optional ORDER BY, LIMIT and OFFSET clauses
+func ParseQuery(input string) (*Query, error) {
+	lexer := NewLexer(input)
+	lexer.query = true
+	if err := parse(lexer, START_QUERY); err != nil {
+		return nil, err
+	}
+
+	query := queryResult
+	if err := query.parseClauses(lexer.tokens); err != nil {
+		return nil, err
+	}
+
+	return query, nil
+}
+
+// parse tokenizes and parses the input of a lexer
+func parse(lexer *Lexer, start int) error {
 	// Reset global state
 	parseResult = nil
+	queryResult = nil
 	parseError = nil
 
 	// Create and tokenize
-	currentLexer = NewLexer(input)
+	currentLexer = lexer
 	if err := currentLexer.Tokenize(); err != nil {
-		return nil, err
+		return err
 	}
 
 	// Create goyacc lexer adapter
-	yylex := &tslLexer{lexer: currentLexer}
+	yylex := &tslLexer{lexer: currentLexer, start: start}
 
 	// Parse
 	if yyParse(yylex) != 0 {
 		if parseError != nil {
-			return nil, parseError
+			return parseError
 		}
-		return nil, &ParseError{
+		return &ParseError{
 			Message:  "Parse error",
 			Position: 0,
 		}
 	}
 
-	return parseResult, nil
+	return nil
 }
 
 // Initialize keyword map with correct token constants
@@ -76,4 +111,24 @@ func init() {
 	keywords["any"] = K_ANY
 	keywords["all"] = K_ALL
 	keywords["sum"] = K_SUM
+
+	queryKeywords["order"] = K_ORDER
+	queryKeywords["by"] = K_BY
+	queryKeywords["asc"] = K_ASC
+	queryKeywords["desc"] = K_DESC
+	queryKeywords["limit"] = K_LIMIT
+	queryKeywords["offset"] = K_OFFSET
+	queryKeywords["select"] = K_SELECT
+	queryKeywords["as"] = K_AS
+	queryKeywords["where"] = K_WHERE
+	queryKeywords["group"] = K_GROUP
+	queryKeywords["having"] = K_HAVING
+
+	networkKeywords["within"] = K_WITHIN
+	networkKeywords["cidr"] = K_CIDR
+
+	aggregateFunctions["count"] = K_COUNT
+	aggregateFunctions["avg"] = K_AVG
+	aggregateFunctions["min"] = K_MIN
+	aggregateFunctions["max"] = K_MAX
 }
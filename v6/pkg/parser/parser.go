@@ -9,15 +9,22 @@ import __yyfmt__ "fmt"
 
 // Global variables for the parser
 var parseResult *Node
+var queryResult *Query
 var parseError error
 var currentLexer *Lexer
 
-//line parser.y:11
+//line parser.y:12
 type yySymType struct {
-	yys  int
-	node *Node
-	str  string
-	pos  int
+	yys      int
+	node     *Node
+	str      string
+	pos      int
+	query    *Query
+	sortKeys []SortKey
+	sortKey  SortKey
+	nodes    []*Node
+	items    []SelectItem
+	item     SelectItem
 }
 
 const K_LIKE = 57346
@@ -35,30 +42,52 @@ const K_LEN = 57357
 const K_ANY = 57358
 const K_ALL = 57359
 const K_SUM = 57360
-const NUMERIC_LITERAL = 57361
-const STRING_LITERAL = 57362
-const IDENTIFIER = 57363
-const DATE = 57364
-const RFC3339 = 57365
-const LPAREN = 57366
-const RPAREN = 57367
-const COMMA = 57368
-const PLUS = 57369
-const MINUS = 57370
-const STAR = 57371
-const SLASH = 57372
-const PERCENT = 57373
-const LBRACKET = 57374
-const RBRACKET = 57375
-const EQ = 57376
-const NE = 57377
-const LT = 57378
-const LE = 57379
-const GT = 57380
-const GE = 57381
-const REQ = 57382
-const RNE = 57383
-const UMINUS = 57384
+const K_WITHIN = 57361
+const K_CIDR = 57362
+const K_ORDER = 57363
+const K_BY = 57364
+const K_ASC = 57365
+const K_DESC = 57366
+const K_LIMIT = 57367
+const K_OFFSET = 57368
+const K_SELECT = 57369
+const K_AS = 57370
+const K_WHERE = 57371
+const K_GROUP = 57372
+const K_HAVING = 57373
+const K_COUNT = 57374
+const K_AVG = 57375
+const K_MIN = 57376
+const K_MAX = 57377
+const START_EXPR = 57378
+const START_QUERY = 57379
+const NUMERIC_LITERAL = 57380
+const STRING_LITERAL = 57381
+const IDENTIFIER = 57382
+const DATE = 57383
+const RFC3339 = 57384
+const IP_LITERAL = 57385
+const CIDR_LITERAL = 57386
+const VERSION_LITERAL = 57387
+const LPAREN = 57388
+const RPAREN = 57389
+const COMMA = 57390
+const PLUS = 57391
+const MINUS = 57392
+const STAR = 57393
+const SLASH = 57394
+const PERCENT = 57395
+const LBRACKET = 57396
+const RBRACKET = 57397
+const EQ = 57398
+const NE = 57399
+const LT = 57400
+const LE = 57401
+const GT = 57402
+const GE = 57403
+const REQ = 57404
+const RNE = 57405
+const UMINUS = 57406
 
 var yyToknames = [...]string{
 	"$end",
@@ -79,11 +108,33 @@ var yyToknames = [...]string{
 	"K_ANY",
 	"K_ALL",
 	"K_SUM",
+	"K_WITHIN",
+	"K_CIDR",
+	"K_ORDER",
+	"K_BY",
+	"K_ASC",
+	"K_DESC",
+	"K_LIMIT",
+	"K_OFFSET",
+	"K_SELECT",
+	"K_AS",
+	"K_WHERE",
+	"K_GROUP",
+	"K_HAVING",
+	"K_COUNT",
+	"K_AVG",
+	"K_MIN",
+	"K_MAX",
+	"START_EXPR",
+	"START_QUERY",
 	"NUMERIC_LITERAL",
 	"STRING_LITERAL",
 	"IDENTIFIER",
 	"DATE",
 	"RFC3339",
+	"IP_LITERAL",
+	"CIDR_LITERAL",
+	"VERSION_LITERAL",
 	"LPAREN",
 	"RPAREN",
 	"COMMA",
@@ -111,7 +162,7 @@ const yyEofCode = 1
 const yyErrCode = 2
 const yyInitialStackSize = 16
 
-//line parser.y:167
+//line parser.y:285
 
 //line yacctab:1
 var yyExca = [...]int8{
@@ -122,83 +173,148 @@ var yyExca = [...]int8{
 
 const yyPrivate = 57344
 
-const yyLast = 118
-
-var yyAct = [...]int8{
-	6, 2, 8, 46, 47, 48, 86, 44, 45, 87,
-	85, 5, 92, 49, 50, 51, 52, 53, 7, 4,
-	56, 28, 38, 39, 57, 95, 42, 43, 41, 59,
-	40, 62, 63, 64, 65, 66, 67, 68, 69, 70,
-	71, 61, 29, 78, 79, 93, 44, 45, 60, 82,
-	83, 84, 30, 31, 32, 33, 34, 35, 36, 37,
-	76, 77, 58, 80, 81, 19, 44, 45, 15, 3,
-	9, 25, 26, 88, 89, 90, 91, 20, 21, 22,
-	24, 23, 18, 1, 0, 17, 16, 54, 55, 94,
-	27, 0, 0, 0, 96, 0, 97, 10, 25, 26,
-	11, 12, 13, 14, 20, 21, 22, 24, 23, 18,
-	72, 73, 17, 16, 74, 75, 0, 27,
+const yyLast = 433
+
+var yyAct = [...]uint8{
+	84, 8, 143, 4, 41, 79, 147, 121, 114, 82,
+	52, 53, 9, 154, 56, 57, 55, 171, 54, 59,
+	60, 71, 10, 126, 158, 58, 61, 62, 63, 115,
+	142, 135, 141, 140, 74, 64, 65, 66, 67, 68,
+	139, 78, 125, 138, 113, 77, 87, 88, 89, 90,
+	91, 92, 93, 94, 95, 96, 59, 60, 104, 105,
+	107, 76, 44, 45, 46, 47, 48, 49, 50, 51,
+	75, 7, 108, 109, 59, 60, 117, 118, 119, 120,
+	6, 153, 157, 145, 110, 111, 112, 2, 3, 162,
+	150, 127, 144, 122, 159, 160, 163, 123, 80, 128,
+	129, 130, 131, 133, 134, 97, 98, 42, 136, 99,
+	100, 11, 43, 102, 103, 86, 137, 81, 146, 38,
+	101, 31, 32, 85, 148, 168, 149, 152, 161, 22,
+	69, 70, 124, 39, 155, 151, 72, 156, 73, 21,
+	34, 35, 36, 37, 17, 5, 23, 24, 25, 27,
+	26, 28, 29, 30, 20, 1, 164, 19, 18, 148,
+	0, 0, 33, 167, 169, 165, 0, 166, 0, 0,
+	0, 0, 173, 172, 170, 12, 31, 32, 13, 14,
+	15, 16, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 34, 35, 36, 37, 0,
+	0, 23, 24, 25, 27, 26, 28, 29, 30, 20,
+	0, 0, 19, 18, 83, 0, 0, 33, 12, 31,
+	32, 13, 14, 15, 16, 0, 132, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 34, 35,
+	36, 37, 0, 0, 23, 24, 25, 27, 26, 28,
+	29, 30, 20, 0, 0, 19, 18, 0, 0, 0,
+	33, 12, 31, 32, 13, 14, 15, 16, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 34, 35, 36, 37, 0, 0, 23, 24, 25,
+	27, 26, 28, 29, 30, 20, 0, 0, 19, 18,
+	116, 0, 0, 33, 12, 31, 32, 13, 14, 15,
+	16, 0, 106, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 34, 35, 36, 37, 0, 0,
+	23, 24, 25, 27, 26, 28, 29, 30, 20, 0,
+	0, 19, 18, 0, 0, 0, 33, 12, 31, 32,
+	13, 14, 15, 16, 0, 0, 0, 0, 0, 0,
+	0, 0, 40, 0, 0, 0, 0, 34, 35, 36,
+	37, 0, 0, 23, 24, 25, 27, 26, 28, 29,
+	30, 20, 0, 0, 19, 18, 0, 0, 0, 33,
+	12, 31, 32, 13, 14, 15, 16, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	34, 35, 36, 37, 0, 0, 23, 24, 25, 27,
+	26, 28, 29, 30, 20, 0, 0, 19, 18, 0,
+	0, 0, 33,
 }
 
 var yyPact = [...]int16{
-	85, -1000, -1000, 14, 36, 18, -20, -26, -1000, -1000,
-	85, 85, 85, 85, 85, -1000, 58, 58, 85, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, 85, 85, 85,
-	85, 85, 85, 85, 85, 85, 85, 85, 85, 85,
-	106, 49, 85, 85, 85, 85, 85, 85, 85, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -15, -27, -17, -1000,
-	36, 18, -20, -20, -20, -20, -20, -20, -20, -20,
-	-20, -20, 85, 85, 85, 85, -1000, 1, 39, -20,
-	-26, -26, -1000, -1000, -1000, -1000, -1000, 85, -20, -20,
-	19, -20, -1000, 85, -1000, 85, -20, -20,
+	51, -1000, 378, 335, -1000, 100, 106, 6, -30, -25,
+	-1000, -1000, 378, 378, 378, 378, 378, -1000, 108, 108,
+	378, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, 378, 24, 15, -1, -5, -1000, 77,
+	163, -1000, 378, 378, 378, 378, 378, 378, 378, 378,
+	378, 378, 378, 378, 101, 102, 378, 292, 378, 378,
+	378, 378, 378, 378, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -3, -47, -19, -1000, 249, 378, 378, 378, 68,
+	75, -6, -1000, -1000, 63, 106, 6, -30, -30, -30,
+	-30, -30, -30, -30, -30, -30, -30, 378, 378, 378,
+	206, 378, -1000, 93, 25, -30, 378, -30, -25, -25,
+	-1000, -1000, -1000, -1000, -1000, 378, -4, -7, -14, -15,
+	-17, 66, 45, 378, 60, 163, 378, 41, -30, -30,
+	7, -30, 378, -30, -1000, 378, -30, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, 44, -1000, -24, -1000, 71, 58,
+	74, -1000, -1000, -1000, 378, -30, -30, -1000, 378, -1000,
+	-1000, 77, 378, 378, -30, -1000, 68, -1000, -31, -1000,
+	66, 378, -1000, -1000,
 }
 
-var yyPgo = [...]int8{
-	0, 83, 1, 69, 19, 11, 0, 18, 2, 70,
-	68, 65, 62, 24,
+var yyPgo = [...]uint8{
+	0, 155, 0, 145, 80, 71, 1, 12, 22, 111,
+	144, 139, 138, 136, 133, 132, 129, 128, 126, 125,
+	119, 5, 118, 6, 7, 2, 117, 9,
 }
 
 var yyR1 = [...]int8{
-	0, 1, 2, 3, 3, 4, 4, 5, 5, 5,
+	0, 1, 1, 20, 20, 18, 18, 19, 19, 17,
+	17, 26, 26, 27, 27, 27, 15, 15, 14, 14,
+	21, 21, 22, 22, 23, 23, 23, 24, 24, 25,
+	25, 2, 3, 3, 4, 4, 5, 5, 5, 5,
 	5, 5, 5, 5, 5, 5, 5, 5, 5, 5,
-	5, 5, 5, 5, 5, 5, 6, 6, 6, 7,
-	7, 7, 7, 8, 8, 8, 8, 8, 8, 9,
-	9, 9, 9, 9, 11, 13, 13, 13, 12, 12,
-	10, 10, 10, 10, 10, 10, 10,
+	5, 5, 5, 5, 5, 5, 5, 5, 5, 6,
+	6, 6, 7, 7, 7, 7, 8, 8, 8, 8,
+	8, 8, 9, 9, 9, 9, 9, 9, 16, 16,
+	16, 16, 16, 11, 13, 13, 13, 12, 12, 10,
+	10, 10, 10, 10, 10, 10, 10, 10, 10,
 }
 
 var yyR2 = [...]int8{
-	0, 1, 1, 1, 3, 1, 3, 1, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 4, 4,
-	3, 4, 5, 6, 3, 4, 1, 3, 3, 1,
-	3, 3, 3, 1, 2, 2, 2, 2, 2, 1,
-	2, 2, 3, 1, 3, 0, 1, 2, 1, 3,
-	1, 1, 1, 1, 1, 1, 1,
+	0, 2, 2, 4, 8, 0, 3, 1, 3, 0,
+	2, 1, 3, 1, 1, 3, 0, 2, 0, 1,
+	0, 3, 1, 3, 1, 2, 2, 0, 2, 0,
+	2, 1, 1, 3, 1, 3, 1, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 4, 4, 3,
+	4, 5, 6, 3, 4, 3, 4, 4, 5, 1,
+	3, 3, 1, 3, 3, 3, 1, 2, 2, 2,
+	2, 2, 1, 2, 2, 3, 1, 1, 4, 4,
+	4, 4, 4, 3, 0, 1, 2, 1, 3, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1,
 }
 
 var yyChk = [...]int16{
-	-1000, -1, -2, -3, -4, -5, -6, -7, -8, -9,
-	12, 15, 16, 17, 18, -10, 28, 27, 24, -11,
-	19, 20, 21, 23, 22, 13, 14, 32, 7, 6,
-	34, 35, 36, 37, 38, 39, 40, 41, 4, 5,
-	12, 10, 8, 9, 27, 28, 29, 30, 31, -8,
-	-8, -8, -8, -8, -9, -9, -2, -13, -12, -2,
-	-4, -5, -6, -6, -6, -6, -6, -6, -6, -6,
-	-6, -6, 4, 5, 8, 9, 11, 12, -6, -6,
-	-7, -7, -8, -8, -8, 25, 33, 26, -6, -6,
-	-6, -6, 11, 6, -2, 6, -6, -6,
+	-1000, -1, 36, 37, -2, -3, -4, -5, -6, -7,
+	-8, -9, 12, 15, 16, 17, 18, -10, 50, 49,
+	46, -11, -16, 38, 39, 40, 42, 41, 43, 44,
+	45, 13, 14, 54, 32, 33, 34, 35, -20, -14,
+	27, -2, 7, 6, 56, 57, 58, 59, 60, 61,
+	62, 63, 4, 5, 12, 10, 8, 9, 19, 49,
+	50, 51, 52, 53, -8, -8, -8, -8, -8, -9,
+	-9, -2, -13, -12, -2, 46, 46, 46, 46, -21,
+	21, -26, -27, 51, -2, -4, -5, -6, -6, -6,
+	-6, -6, -6, -6, -6, -6, -6, 4, 5, 8,
+	9, 19, 11, 12, -6, -6, 20, -6, -7, -7,
+	-8, -8, -8, 47, 55, 48, 51, -2, -2, -2,
+	-2, -24, 25, 22, -15, 48, 29, 28, -6, -6,
+	-6, -6, 20, -6, 11, 6, -6, -2, 47, 47,
+	47, 47, 47, -25, 26, 38, -22, -23, -2, -18,
+	30, -27, -2, 40, 6, -6, -6, 38, 48, 23,
+	24, -17, 31, 22, -6, -23, -21, -2, -19, -2,
+	-24, 48, -25, -2,
 }
 
 var yyDef = [...]int8{
-	0, -2, 1, 2, 3, 5, 7, 26, 29, 33,
-	0, 0, 0, 0, 0, 39, 0, 0, 0, 43,
-	50, 51, 52, 53, 54, 55, 56, 45, 0, 0,
+	0, -2, 0, 18, 1, 31, 32, 34, 36, 59,
+	62, 66, 0, 0, 0, 0, 0, 72, 0, 0,
+	0, 76, 77, 89, 90, 91, 92, 93, 94, 95,
+	96, 97, 98, 84, 0, 0, 0, 0, 2, 20,
+	0, 19, 0, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 34,
-	35, 36, 37, 38, 40, 41, 0, 0, 46, 48,
-	4, 6, 8, 9, 10, 11, 12, 13, 14, 15,
-	16, 17, 0, 0, 0, 0, 20, 0, 0, 24,
-	27, 28, 30, 31, 32, 42, 44, 47, 18, 19,
-	0, 25, 21, 0, 49, 0, 22, 23,
+	0, 0, 0, 0, 67, 68, 69, 70, 71, 73,
+	74, 0, 0, 85, 87, 0, 0, 0, 0, 27,
+	0, 16, 11, 13, 14, 33, 35, 37, 38, 39,
+	40, 41, 42, 43, 44, 45, 46, 0, 0, 0,
+	0, 0, 49, 0, 0, 53, 0, 55, 60, 61,
+	63, 64, 65, 75, 83, 86, 0, 0, 0, 0,
+	0, 29, 0, 0, 5, 0, 0, 0, 47, 48,
+	0, 54, 0, 57, 50, 0, 56, 88, 78, 79,
+	80, 81, 82, 3, 0, 28, 21, 22, 24, 9,
+	0, 12, 17, 15, 0, 58, 51, 30, 0, 25,
+	26, 20, 0, 0, 52, 23, 27, 10, 6, 7,
+	29, 0, 4, 8,
 }
 
 var yyTok1 = [...]int8{
@@ -210,7 +326,9 @@ var yyTok2 = [...]int8{
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
-	42,
+	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
+	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
+	62, 63, 64,
 }
 
 var yyTok3 = [...]int8{
@@ -555,299 +673,550 @@ yydefault:
 	switch yynt {
 
 	case 1:
-		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:48
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:69
+		{
+			parseResult = yyDollar[2].node
+		}
+	case 2:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:70
+		{
+			queryResult = yyDollar[2].query
+		}
+	case 3:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:74
 		{
-			parseResult = yyDollar[1].node
+			yyVAL.query = &Query{Filter: yyDollar[1].node, OrderBy: yyDollar[2].sortKeys, limitText: yyDollar[3].str, offsetText: yyDollar[4].str}
 		}
 	case 4:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line parser.y:77
+		{
+			yyVAL.query = &Query{
+				Select: yyDollar[2].items, Filter: yyDollar[3].node, GroupBy: yyDollar[4].nodes, Having: yyDollar[5].node, OrderBy: yyDollar[6].sortKeys,
+				limitText: yyDollar[7].str, offsetText: yyDollar[8].str,
+			}
+		}
+	case 5:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line parser.y:86
+		{
+			yyVAL.nodes = nil
+		}
+	case 6:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:87
+		{
+			yyVAL.nodes = yyDollar[3].nodes
+		}
+	case 7:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:91
+		{
+			yyVAL.nodes = []*Node{yyDollar[1].node}
+		}
+	case 8:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:92
+		{
+			yyVAL.nodes = append(yyDollar[1].nodes, yyDollar[3].node)
+		}
+	case 9:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line parser.y:96
+		{
+			yyVAL.node = nil
+		}
+	case 10:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:97
+		{
+			yyVAL.node = yyDollar[2].node
+		}
+	case 11:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:101
+		{
+			yyVAL.items = []SelectItem{yyDollar[1].item}
+		}
+	case 12:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:102
+		{
+			yyVAL.items = append(yyDollar[1].items, yyDollar[3].item)
+		}
+	case 13:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:106
+		{
+			yyVAL.item = SelectItem{Star: true}
+		}
+	case 14:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:107
+		{
+			yyVAL.item = SelectItem{Expr: yyDollar[1].node}
+		}
+	case 15:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:108
+		{
+			yyVAL.item = SelectItem{Expr: yyDollar[1].node, Alias: yyDollar[3].str}
+		}
+	case 16:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line parser.y:112
+		{
+			yyVAL.node = nil
+		}
+	case 17:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:113
+		{
+			yyVAL.node = yyDollar[2].node
+		}
+	case 18:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line parser.y:117
+		{
+			yyVAL.node = nil
+		}
+	case 20:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line parser.y:122
+		{
+			yyVAL.sortKeys = nil
+		}
+	case 21:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:123
+		{
+			yyVAL.sortKeys = yyDollar[3].sortKeys
+		}
+	case 22:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:127
+		{
+			yyVAL.sortKeys = []SortKey{yyDollar[1].sortKey}
+		}
+	case 23:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:128
+		{
+			yyVAL.sortKeys = append(yyDollar[1].sortKeys, yyDollar[3].sortKey)
+		}
+	case 24:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:132
+		{
+			yyVAL.sortKey = SortKey{Expr: yyDollar[1].node}
+		}
+	case 25:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:133
+		{
+			yyVAL.sortKey = SortKey{Expr: yyDollar[1].node}
+		}
+	case 26:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:134
+		{
+			yyVAL.sortKey = SortKey{Expr: yyDollar[1].node, Desc: true}
+		}
+	case 27:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line parser.y:138
+		{
+			yyVAL.str = ""
+		}
+	case 28:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:139
+		{
+			yyVAL.str = yyDollar[2].str
+		}
+	case 29:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line parser.y:143
+		{
+			yyVAL.str = ""
+		}
+	case 30:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:144
+		{
+			yyVAL.str = yyDollar[2].str
+		}
+	case 33:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:57
+//line parser.y:153
 		{
 			yyVAL.node = NewBinaryOpNode(OpOr, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 6:
+	case 35:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:62
+//line parser.y:158
 		{
 			yyVAL.node = NewBinaryOpNode(OpAnd, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 8:
+	case 37:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:67
+//line parser.y:163
 		{
 			yyVAL.node = NewBinaryOpNode(OpEQ, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 9:
+	case 38:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:68
+//line parser.y:164
 		{
 			yyVAL.node = NewBinaryOpNode(OpNE, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 10:
+	case 39:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:69
+//line parser.y:165
 		{
 			yyVAL.node = NewBinaryOpNode(OpLT, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 11:
+	case 40:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:70
+//line parser.y:166
 		{
 			yyVAL.node = NewBinaryOpNode(OpLE, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 12:
+	case 41:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:71
+//line parser.y:167
 		{
 			yyVAL.node = NewBinaryOpNode(OpGT, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 13:
+	case 42:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:72
+//line parser.y:168
 		{
 			yyVAL.node = NewBinaryOpNode(OpGE, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 14:
+	case 43:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:73
+//line parser.y:169
 		{
 			yyVAL.node = NewBinaryOpNode(OpREQ, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 15:
+	case 44:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:74
+//line parser.y:170
 		{
 			yyVAL.node = NewBinaryOpNode(OpRNE, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 16:
+	case 45:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:75
+//line parser.y:171
 		{
 			yyVAL.node = NewBinaryOpNode(OpLike, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 17:
+	case 46:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:76
+//line parser.y:172
 		{
 			yyVAL.node = NewBinaryOpNode(OpILike, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 18:
+	case 47:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:77
+//line parser.y:173
 		{
 			likeExpr := NewBinaryOpNode(OpLike, yyDollar[1].node, yyDollar[4].node, 0)
 			yyVAL.node = NewUnaryOpNode(OpNot, likeExpr, 0)
 		}
-	case 19:
+	case 48:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:81
+//line parser.y:177
 		{
 			ilikeExpr := NewBinaryOpNode(OpILike, yyDollar[1].node, yyDollar[4].node, 0)
 			yyVAL.node = NewUnaryOpNode(OpNot, ilikeExpr, 0)
 		}
-	case 20:
+	case 49:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:85
+//line parser.y:181
 		{
 			yyVAL.node = NewBinaryOpNode(OpIs, yyDollar[1].node, NewNullNode(0), 0)
 		}
-	case 21:
+	case 50:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:86
+//line parser.y:182
 		{
 			isNullExpr := NewBinaryOpNode(OpIs, yyDollar[1].node, NewNullNode(0), 0)
 			yyVAL.node = NewUnaryOpNode(OpNot, isNullExpr, 0)
 		}
-	case 22:
+	case 51:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:90
+//line parser.y:186
 		{
 			rangeArray := NewArrayNode([]*Node{yyDollar[3].node, yyDollar[5].node}, 0)
 			yyVAL.node = NewBinaryOpNode(OpBetween, yyDollar[1].node, rangeArray, 0)
 		}
-	case 23:
+	case 52:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parser.y:94
+//line parser.y:190
 		{
 			rangeArray := NewArrayNode([]*Node{yyDollar[4].node, yyDollar[6].node}, 0)
 			betweenExpr := NewBinaryOpNode(OpBetween, yyDollar[1].node, rangeArray, 0)
 			yyVAL.node = NewUnaryOpNode(OpNot, betweenExpr, 0)
 		}
-	case 24:
+	case 53:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:99
+//line parser.y:195
 		{
 			yyVAL.node = NewBinaryOpNode(OpIn, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 25:
+	case 54:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:100
+//line parser.y:196
 		{
 			inExpr := NewBinaryOpNode(OpIn, yyDollar[1].node, yyDollar[4].node, 0)
 			yyVAL.node = NewUnaryOpNode(OpNot, inExpr, 0)
 		}
-	case 27:
+	case 55:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:108
+//line parser.y:200
+		{
+			yyVAL.node = NewBinaryOpNode(OpWithin, yyDollar[1].node, yyDollar[3].node, 0)
+		}
+	case 56:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:201
+		{
+			yyVAL.node = NewBinaryOpNode(OpWithin, yyDollar[1].node, yyDollar[4].node, 0)
+		}
+	case 57:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:202
+		{
+			withinExpr := NewBinaryOpNode(OpWithin, yyDollar[1].node, yyDollar[4].node, 0)
+			yyVAL.node = NewUnaryOpNode(OpNot, withinExpr, 0)
+		}
+	case 58:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.y:206
+		{
+			withinExpr := NewBinaryOpNode(OpWithin, yyDollar[1].node, yyDollar[5].node, 0)
+			yyVAL.node = NewUnaryOpNode(OpNot, withinExpr, 0)
+		}
+	case 60:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:214
 		{
 			yyVAL.node = NewBinaryOpNode(OpPlus, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 28:
+	case 61:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:109
+//line parser.y:215
 		{
 			yyVAL.node = NewBinaryOpNode(OpMinus, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 30:
+	case 63:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:114
+//line parser.y:220
 		{
 			yyVAL.node = NewBinaryOpNode(OpStar, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 31:
+	case 64:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:115
+//line parser.y:221
 		{
 			yyVAL.node = NewBinaryOpNode(OpSlash, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 32:
+	case 65:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:116
+//line parser.y:222
 		{
 			yyVAL.node = NewBinaryOpNode(OpPercent, yyDollar[1].node, yyDollar[3].node, 0)
 		}
-	case 34:
+	case 67:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:121
+//line parser.y:227
 		{
 			yyVAL.node = NewUnaryOpNode(OpNot, yyDollar[2].node, 0)
 		}
-	case 35:
+	case 68:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:122
+//line parser.y:228
 		{
 			yyVAL.node = NewUnaryOpNode(OpLen, yyDollar[2].node, 0)
 		}
-	case 36:
+	case 69:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:123
+//line parser.y:229
 		{
 			yyVAL.node = NewUnaryOpNode(OpAny, yyDollar[2].node, 0)
 		}
-	case 37:
+	case 70:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:124
+//line parser.y:230
 		{
 			yyVAL.node = NewUnaryOpNode(OpAll, yyDollar[2].node, 0)
 		}
-	case 38:
+	case 71:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:125
+//line parser.y:231
 		{
 			yyVAL.node = NewUnaryOpNode(OpSum, yyDollar[2].node, 0)
 		}
-	case 40:
+	case 73:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:130
+//line parser.y:236
 		{
 			yyVAL.node = NewUnaryOpNode(OpUMinus, yyDollar[2].node, 0)
 		}
-	case 41:
+	case 74:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:131
+//line parser.y:237
 		{
 			yyVAL.node = yyDollar[2].node
 		}
-	case 42:
+	case 75:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:132
+//line parser.y:238
 		{
 			yyVAL.node = yyDollar[2].node
 		}
-	case 43:
+	case 76:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:133
+//line parser.y:239
 		{
 			yyVAL.node = yyDollar[1].node
 		}
-	case 44:
+	case 77:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:240
+		{
+			yyVAL.node = yyDollar[1].node
+		}
+	case 78:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:244
+		{
+			yyVAL.node = NewUnaryOpNode(OpCount, nil, 0)
+		}
+	case 79:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:245
+		{
+			yyVAL.node = NewUnaryOpNode(OpCount, yyDollar[3].node, 0)
+		}
+	case 80:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:246
+		{
+			yyVAL.node = NewUnaryOpNode(OpAvg, yyDollar[3].node, 0)
+		}
+	case 81:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:247
+		{
+			yyVAL.node = NewUnaryOpNode(OpMin, yyDollar[3].node, 0)
+		}
+	case 82:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:248
+		{
+			yyVAL.node = NewUnaryOpNode(OpMax, yyDollar[3].node, 0)
+		}
+	case 83:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:137
+//line parser.y:252
 		{
 			yyVAL.node = yyDollar[2].node
 		}
-	case 45:
+	case 84:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parser.y:141
+//line parser.y:256
 		{
 			yyVAL.node = NewArrayNode([]*Node{}, 0)
 		}
-	case 46:
+	case 85:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:142
+//line parser.y:257
 		{
 			yyVAL.node = yyDollar[1].node
 		}
-	case 47:
+	case 86:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:143
+//line parser.y:258
 		{
 			yyVAL.node = yyDollar[1].node
 		}
-	case 48:
+	case 87:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:147
+//line parser.y:262
 		{
 			yyVAL.node = NewArrayNode([]*Node{yyDollar[1].node}, 0)
 		}
-	case 49:
+	case 88:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:150
+//line parser.y:265
 		{
 			// Append to existing array
 			yyDollar[1].node.Children = append(yyDollar[1].node.Children, yyDollar[3].node)
 			yyVAL.node = yyDollar[1].node
 		}
-	case 50:
+	case 89:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:158
+//line parser.y:273
 		{
 			yyVAL.node = NewNumberNode(yyDollar[1].str, 0)
 		}
-	case 51:
+	case 90:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:159
+//line parser.y:274
 		{
 			yyVAL.node = NewStringNode(yyDollar[1].str, 0)
 		}
-	case 52:
+	case 91:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:160
+//line parser.y:275
 		{
 			yyVAL.node = NewIdentifierNode(yyDollar[1].str, 0)
 		}
-	case 53:
+	case 92:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:161
+//line parser.y:276
 		{
 			yyVAL.node = NewTimestampNode(yyDollar[1].str, 0)
 		}
-	case 54:
+	case 93:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:162
+//line parser.y:277
 		{
 			yyVAL.node = NewDateNode(yyDollar[1].str, 0)
 		}
-	case 55:
+	case 94:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:163
+//line parser.y:278
+		{
+			yyVAL.node = NewIPNode(yyDollar[1].str, 0)
+		}
+	case 95:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:279
+		{
+			yyVAL.node = NewCIDRNode(yyDollar[1].str, 0)
+		}
+	case 96:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:280
+		{
+			yyVAL.node = NewVersionNode(yyDollar[1].str, 0)
+		}
+	case 97:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:281
 		{
 			yyVAL.node = NewBooleanNode(true, 0)
 		}
-	case 56:
+	case 98:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:164
+//line parser.y:282
 		{
 			yyVAL.node = NewBooleanNode(false, 0)
 		}
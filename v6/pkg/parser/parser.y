@@ -3,21 +3,34 @@ package parser
 
 // Global variables for the parser
 var parseResult *Node
+var queryResult *Query
 var parseError error
 var currentLexer *Lexer
 %}
 
 // Union type for semantic values
 %union {
-    node   *Node
-    str    string
-    pos    int
+    node     *Node
+    str      string
+    pos      int
+    query    *Query
+    sortKeys []SortKey
+    sortKey  SortKey
+    nodes    []*Node
+    items    []SelectItem
+    item     SelectItem
 }
 
 // Token declarations
 %token K_LIKE K_ILIKE K_AND K_OR K_BETWEEN K_IN K_IS K_NULL
 %token K_NOT K_TRUE K_FALSE K_LEN K_ANY K_ALL K_SUM
+%token K_WITHIN K_CIDR
+%token K_ORDER K_BY K_ASC K_DESC K_LIMIT K_OFFSET
+%token K_SELECT K_AS K_WHERE K_GROUP K_HAVING
+%token K_COUNT K_AVG K_MIN K_MAX
+%token START_EXPR START_QUERY
 %token <str> NUMERIC_LITERAL STRING_LITERAL IDENTIFIER DATE RFC3339
+%token <str> IP_LITERAL CIDR_LITERAL VERSION_LITERAL
 %token LPAREN RPAREN COMMA
 %token PLUS MINUS STAR SLASH PERCENT
 %token LBRACKET RBRACKET
@@ -28,7 +41,7 @@ var currentLexer *Lexer
 %left K_OR                         
 %left K_AND
 %left EQ NE LT LE GT GE REQ RNE
-%left K_LIKE K_ILIKE K_IS K_BETWEEN K_IN
+%left K_LIKE K_ILIKE K_IS K_BETWEEN K_IN K_WITHIN
 %left PLUS MINUS                   
 %left STAR SLASH PERCENT           
 %right K_NOT K_LEN K_ANY K_ALL K_SUM   
@@ -37,7 +50,15 @@ var currentLexer *Lexer
 // Non-terminal types
 %type <node> input expr or_expr and_expr comparison_expr
 %type <node> additive_expr multiplicative_expr not_expr unary_expr
-%type <node> primary array array_elements opt_array_elements
+%type <node> primary array array_elements opt_array_elements opt_filter opt_where
+%type <node> aggregate opt_having
+%type <nodes> opt_group_by group_keys
+%type <query> query
+%type <sortKeys> opt_order_by sort_keys
+%type <sortKey> sort_key
+%type <str> opt_limit opt_offset
+%type <items> select_items
+%type <item> select_item
 
 // Start symbol
 %start input
@@ -45,7 +66,82 @@ var currentLexer *Lexer
 %%
 
 input:
-    expr { parseResult = $1 }
+      START_EXPR expr   { parseResult = $2 }
+    | START_QUERY query { queryResult = $2 }
+    ;
+
+query:
+      opt_filter opt_order_by opt_limit opt_offset {
+        $$ = &Query{Filter: $1, OrderBy: $2, limitText: $3, offsetText: $4}
+    }
+    | K_SELECT select_items opt_where opt_group_by opt_having opt_order_by opt_limit opt_offset {
+        $$ = &Query{
+            Select: $2, Filter: $3, GroupBy: $4, Having: $5, OrderBy: $6,
+            limitText: $7, offsetText: $8,
+        }
+    }
+    ;
+
+opt_group_by:
+      /* empty */                  { $$ = nil }
+    | K_GROUP K_BY group_keys      { $$ = $3 }
+    ;
+
+group_keys:
+      expr                         { $$ = []*Node{$1} }
+    | group_keys COMMA expr        { $$ = append($1, $3) }
+    ;
+
+opt_having:
+      /* empty */                  { $$ = nil }
+    | K_HAVING expr                { $$ = $2 }
+    ;
+
+select_items:
+      select_item                     { $$ = []SelectItem{$1} }
+    | select_items COMMA select_item  { $$ = append($1, $3) }
+    ;
+
+select_item:
+      STAR                         { $$ = SelectItem{Star: true} }
+    | expr                         { $$ = SelectItem{Expr: $1} }
+    | expr K_AS IDENTIFIER         { $$ = SelectItem{Expr: $1, Alias: $3} }
+    ;
+
+opt_where:
+      /* empty */                  { $$ = nil }
+    | K_WHERE expr                 { $$ = $2 }
+    ;
+
+opt_filter:
+      /* empty */                  { $$ = nil }
+    | expr
+    ;
+
+opt_order_by:
+      /* empty */                  { $$ = nil }
+    | K_ORDER K_BY sort_keys       { $$ = $3 }
+    ;
+
+sort_keys:
+      sort_key                     { $$ = []SortKey{$1} }
+    | sort_keys COMMA sort_key     { $$ = append($1, $3) }
+    ;
+
+sort_key:
+      expr                         { $$ = SortKey{Expr: $1} }
+    | expr K_ASC                   { $$ = SortKey{Expr: $1} }
+    | expr K_DESC                  { $$ = SortKey{Expr: $1, Desc: true} }
+    ;
+
+opt_limit:
+      /* empty */                  { $$ = "" }
+    | K_LIMIT NUMERIC_LITERAL      { $$ = $2 }
+    ;
+
+opt_offset:
+      /* empty */                  { $$ = "" }
+    | K_OFFSET NUMERIC_LITERAL     { $$ = $2 }
     ;
 
 expr:
@@ -101,6 +197,16 @@ comparison_expr:
         inExpr := NewBinaryOpNode(OpIn, $1, $4, 0)
         $$ = NewUnaryOpNode(OpNot, inExpr, 0)
     }
+    | comparison_expr K_WITHIN additive_expr       { $$ = NewBinaryOpNode(OpWithin, $1, $3, 0) }
+    | comparison_expr K_IN K_CIDR additive_expr    { $$ = NewBinaryOpNode(OpWithin, $1, $4, 0) }
+    | comparison_expr K_NOT K_WITHIN additive_expr {
+        withinExpr := NewBinaryOpNode(OpWithin, $1, $4, 0)
+        $$ = NewUnaryOpNode(OpNot, withinExpr, 0)
+    }
+    | comparison_expr K_NOT K_IN K_CIDR additive_expr {
+        withinExpr := NewBinaryOpNode(OpWithin, $1, $5, 0)
+        $$ = NewUnaryOpNode(OpNot, withinExpr, 0)
+    }
     ;
 
 additive_expr:
@@ -131,6 +237,15 @@ unary_expr:
     | PLUS unary_expr              { $$ = $2 }  // unary plus is a no-op
     | LPAREN expr RPAREN           { $$ = $2 }
     | array                        { $$ = $1 }
+    | aggregate                    { $$ = $1 }
+    ;
+
+aggregate:
+      K_COUNT LPAREN STAR RPAREN   { $$ = NewUnaryOpNode(OpCount, nil, 0) }
+    | K_COUNT LPAREN expr RPAREN   { $$ = NewUnaryOpNode(OpCount, $3, 0) }
+    | K_AVG LPAREN expr RPAREN     { $$ = NewUnaryOpNode(OpAvg, $3, 0) }
+    | K_MIN LPAREN expr RPAREN     { $$ = NewUnaryOpNode(OpMin, $3, 0) }
+    | K_MAX LPAREN expr RPAREN     { $$ = NewUnaryOpNode(OpMax, $3, 0) }
     ;
 
 array:
@@ -160,6 +275,9 @@ primary:
     | IDENTIFIER            { $$ = NewIdentifierNode($1, 0) }
     | RFC3339               { $$ = NewTimestampNode($1, 0) }
     | DATE                  { $$ = NewDateNode($1, 0) }
+    | IP_LITERAL            { $$ = NewIPNode($1, 0) }
+    | CIDR_LITERAL          { $$ = NewCIDRNode($1, 0) }
+    | VERSION_LITERAL       { $$ = NewVersionNode($1, 0) }
     | K_TRUE                { $$ = NewBooleanNode(true, 0) }
     | K_FALSE               { $$ = NewBooleanNode(false, 0) }
     ;
@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Query is a TSL query, a filter expression with projection, grouping, sorting
+// and paging clauses
+type Query struct {
+	Select  []SelectItem // nil when the query has no SELECT clause
+	Filter  *Node        // nil when the query has no filter
+	GroupBy []*Node      // grouping keys, nil when the query has no GROUP BY clause
+	Having  *Node        // nil when the query has no HAVING clause
+	OrderBy []SortKey    // sort keys, in order of precedence
+	Limit   *int         // nil when the query has no LIMIT clause
+	Offset  int
+
+	// clause values as written, validated after parsing
+	limitText  string
+	offsetText string
+}
+
+// SelectItem is one SELECT projection item, an expression with an optional
+// alias, or * for all fields
+type SelectItem struct {
+	Expr  *Node
+	Alias string
+	Star  bool
+}
+
+// SortKey is one ORDER BY key
+type SortKey struct {
+	Expr *Node
+	Desc bool
+}
+
+// parseClauses validates the aggregate functions and converts the LIMIT and
+// OFFSET clause values
+func (q *Query) parseClauses(tokens []Token) error {
+	if err := checkFilterAggregates(tokens); err != nil {
+		return err
+	}
+
+	if q.limitText != "" {
+		n, err := parseCount(q.limitText, K_LIMIT, "LIMIT", tokens)
+		if err != nil {
+			return err
+		}
+		q.Limit = &n
+	}
+
+	if q.offsetText != "" {
+		n, err := parseCount(q.offsetText, K_OFFSET, "OFFSET", tokens)
+		if err != nil {
+			return err
+		}
+		q.Offset = n
+	}
+
+	return nil
+}
+
+// checkFilterAggregates reports aggregate functions used in the query filter,
+// the filter is evaluated per record, before grouping
+func checkFilterAggregates(tokens []Token) error {
+	inFilter := len(tokens) == 0 || tokens[0].Type != K_SELECT
+	for _, token := range tokens {
+		switch token.Type {
+		case K_WHERE:
+			inFilter = true
+		case K_GROUP, K_HAVING, K_ORDER, K_LIMIT, K_OFFSET:
+			inFilter = false
+		case K_COUNT, K_AVG, K_MIN, K_MAX:
+			if inFilter {
+				return &ParseError{
+					Message:  fmt.Sprintf("aggregate function %s is not allowed in a filter, use HAVING", strings.ToUpper(token.Value)),
+					Position: token.Position,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseCount parses a non-negative integer clause value
+func parseCount(text string, clause int, name string, tokens []Token) (int, error) {
+	n, err := strconv.Atoi(text)
+	if err == nil && n >= 0 {
+		return n, nil
+	}
+
+	// Report the position of the value following the clause keyword
+	position := 0
+	for i, token := range tokens {
+		if token.Type == clause && i+1 < len(tokens) {
+			position = tokens[i+1].Position
+		}
+	}
+	return 0, &ParseError{
+		Message:  fmt.Sprintf("%s must be a non-negative integer, got %s", name, text),
+		Position: position,
+	}
+}
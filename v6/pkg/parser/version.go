@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionPattern matches a semantic version, with an optional "v" prefix
+var versionPattern = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// Version is a semantic version (https://semver.org), e.g. v1.28.0 or v1.0.0-rc.1
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string // dot separated pre-release identifiers, e.g. ["rc", "1"]
+	Build      string   // build metadata, ignored when comparing versions
+}
+
+// ParseVersion parses a semantic version string, e.g. "v1.2.3" or "1.2.3-beta.2+build.5"
+func ParseVersion(s string) (Version, error) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid version: %s", s)
+	}
+
+	var v Version
+	var err error
+	for i, p := range []*uint64{&v.Major, &v.Minor, &v.Patch} {
+		if *p, err = strconv.ParseUint(m[i+1], 10, 64); err != nil {
+			return Version{}, fmt.Errorf("invalid version: %s", s)
+		}
+	}
+	if m[4] != "" {
+		v.Prerelease = strings.Split(m[4], ".")
+	}
+	v.Build = m[5]
+
+	return v, nil
+}
+
+// Compare compares two versions using semantic version precedence, returning
+// -1, 0 or +1. Build metadata is ignored.
+func (v Version) Compare(o Version) int {
+	if c := cmp.Compare(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	// A pre-release version has lower precedence than a normal version
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := comparePrerelease(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	// A larger set of pre-release identifiers has higher precedence
+	return cmp.Compare(len(v.Prerelease), len(o.Prerelease))
+}
+
+// comparePrerelease compares two pre-release identifiers, numeric identifiers
+// compare numerically and have lower precedence than alphanumeric identifiers
+func comparePrerelease(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		return cmp.Compare(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// String returns the version in its canonical form, e.g. v1.0.0-rc.1+build.5
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// MarshalText encodes the version as text, it is used by the JSON and YAML encoders
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// Value implements the database/sql driver.Valuer interface, the version is
+// passed to the database as text
+func (v Version) Value() (driver.Value, error) {
+	return v.String(), nil
+}
@@ -0,0 +1,85 @@
+package store
+
+import "math/bits"
+
+// bitmap is a set of record IDs, one bit per ID
+type bitmap []uint64
+
+// set adds an ID to the bitmap, growing it when needed
+func (b *bitmap) set(id ID) {
+	word := int(id / 64)
+	if word >= len(*b) {
+		*b = append(*b, make([]uint64, word-len(*b)+1)...)
+	}
+	(*b)[word] |= 1 << (id % 64)
+}
+
+// clear removes an ID from the bitmap
+func (b bitmap) clear(id ID) {
+	if word := int(id / 64); word < len(b) {
+		b[word] &^= 1 << (id % 64)
+	}
+}
+
+// has returns true if the bitmap holds an ID
+func (b bitmap) has(id ID) bool {
+	word := int(id / 64)
+	return word < len(b) && b[word]&(1<<(id%64)) != 0
+}
+
+// clone returns a copy of the bitmap
+func (b bitmap) clone() bitmap {
+	return append(bitmap(nil), b...)
+}
+
+// and returns the IDs held by both bitmaps
+func (b bitmap) and(o bitmap) bitmap {
+	result := make(bitmap, min(len(b), len(o)))
+	for i := range result {
+		result[i] = b[i] & o[i]
+	}
+	return result
+}
+
+// or returns the IDs held by either bitmap
+func (b bitmap) or(o bitmap) bitmap {
+	if len(b) < len(o) {
+		b, o = o, b
+	}
+	result := b.clone()
+	for i, word := range o {
+		result[i] |= word
+	}
+	return result
+}
+
+// andNot returns the IDs held by the bitmap and not by the other bitmap
+func (b bitmap) andNot(o bitmap) bitmap {
+	result := b.clone()
+	for i := 0; i < len(result) && i < len(o); i++ {
+		result[i] &^= o[i]
+	}
+	return result
+}
+
+// count returns the number of IDs in the bitmap
+func (b bitmap) count() int {
+	n := 0
+	for _, word := range b {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
+
+// ids returns the IDs of the bitmap in increasing order
+func (b bitmap) ids() []ID {
+	ids := make([]ID, 0, b.count())
+	for i, word := range b {
+		for word != 0 {
+			bit := bits.TrailingZeros64(word)
+			ids = append(ids, ID(i*64+bit))
+			word &= word - 1
+		}
+	}
+	return ids
+}
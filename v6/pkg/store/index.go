@@ -0,0 +1,416 @@
+package store
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// IndexKind is the kind of a field index
+type IndexKind int
+
+const (
+	// HashIndex answers equality and IN lookups
+	HashIndex IndexKind = iota
+
+	// SortedIndex answers range, BETWEEN, equality and IN lookups on numbers
+	// and dates
+	SortedIndex
+
+	// PrefixIndex answers LIKE 'prefix%' and equality lookups on strings
+	PrefixIndex
+)
+
+// String returns the name of the index kind
+func (k IndexKind) String() string {
+	switch k {
+	case HashIndex:
+		return "hash"
+	case SortedIndex:
+		return "sorted"
+	case PrefixIndex:
+		return "prefix"
+	default:
+		return "unknown"
+	}
+}
+
+// index is a field index, lookups return a superset of the records matching a
+// predicate, the records are then checked using the semantics walker
+type index interface {
+	kind() IndexKind
+	add(id ID, v value)
+	remove(id ID)
+
+	// lookup returns the candidate records for "field <op> literals", and false
+	// if the index can not answer the lookup
+	lookup(op tsl.Operator, literals []value) (bitmap, bool)
+}
+
+// newIndex creates an empty index of a kind
+func newIndex(kind IndexKind) (index, bool) {
+	switch kind {
+	case HashIndex:
+		idx := &hashIndex{}
+		for class := range idx.values {
+			idx.values[class] = map[string]map[ID]struct{}{}
+		}
+		return idx, true
+	case SortedIndex:
+		return &sortedIndex{gen: map[ID]uint32{}}, true
+	case PrefixIndex:
+		return &prefixIndex{root: &trieNode{}}, true
+	default:
+		return nil, false
+	}
+}
+
+// indexBase tracks the indexed records and the class of their values
+type indexBase struct {
+	all     bitmap
+	members [classCount]bitmap
+	current map[ID]value
+}
+
+// track records the value of a record
+func (b *indexBase) track(id ID, v value) {
+	if b.current == nil {
+		b.current = map[ID]value{}
+	}
+	b.current[id] = v
+	b.all.set(id)
+	b.members[v.class].set(id)
+}
+
+// untrack forgets a record, and returns its value
+func (b *indexBase) untrack(id ID) (value, bool) {
+	v, ok := b.current[id]
+	if !ok {
+		return value{}, false
+	}
+	delete(b.current, id)
+	b.all.clear(id)
+	b.members[v.class].clear(id)
+	return v, true
+}
+
+// others returns the records with values of other classes, a lookup can not
+// rule them out since the walker may convert values between classes
+func (b *indexBase) others(class valueClass) bitmap {
+	return b.all.andNot(b.members[class])
+}
+
+// hashIndex maps value keys to records
+type hashIndex struct {
+	indexBase
+	values [classCount]map[string]map[ID]struct{}
+}
+
+func (h *hashIndex) kind() IndexKind { return HashIndex }
+
+func (h *hashIndex) add(id ID, v value) {
+	h.track(id, v)
+	if v.class == classOther {
+		return
+	}
+
+	key := v.key()
+	ids, ok := h.values[v.class][key]
+	if !ok {
+		ids = map[ID]struct{}{}
+		h.values[v.class][key] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (h *hashIndex) remove(id ID) {
+	v, ok := h.untrack(id)
+	if !ok || v.class == classOther {
+		return
+	}
+
+	key := v.key()
+	delete(h.values[v.class][key], id)
+	if len(h.values[v.class][key]) == 0 {
+		delete(h.values[v.class], key)
+	}
+}
+
+func (h *hashIndex) lookup(op tsl.Operator, literals []value) (bitmap, bool) {
+	if op != tsl.OpEQ && op != tsl.OpIn {
+		return nil, false
+	}
+
+	var result bitmap
+	for _, literal := range literals {
+		if literal.class == classOther {
+			return nil, false
+		}
+		for id := range h.values[literal.class][literal.key()] {
+			result.set(id)
+		}
+		result = result.or(h.others(literal.class))
+	}
+	return result, true
+}
+
+// sortedEntry is one value of a sorted index, entries of removed or updated
+// records are stale and skipped
+type sortedEntry struct {
+	value value
+	id    ID
+	gen   uint32
+}
+
+// sortedIndex keeps number and date values in order.
+//
+// New entries are kept pending, and merged into the sorted entries by the
+// next lookup, so bulk inserts do not pay for keeping the order.
+type sortedIndex struct {
+	indexBase
+
+	mu      sync.Mutex
+	entries [classCount][]sortedEntry
+	pending [classCount][]sortedEntry
+	stale   [classCount]int
+
+	gen     map[ID]uint32
+	nextGen uint32
+}
+
+func (s *sortedIndex) kind() IndexKind { return SortedIndex }
+
+func (s *sortedIndex) add(id ID, v value) {
+	s.track(id, v)
+	if !v.ordered() {
+		return
+	}
+
+	s.nextGen++
+	s.gen[id] = s.nextGen
+	s.pending[v.class] = append(s.pending[v.class], sortedEntry{value: v, id: id, gen: s.nextGen})
+}
+
+func (s *sortedIndex) remove(id ID) {
+	v, ok := s.untrack(id)
+	if !ok || !v.ordered() {
+		return
+	}
+
+	delete(s.gen, id)
+	s.stale[v.class]++
+}
+
+// valid returns true if an entry holds the current value of its record
+func (s *sortedIndex) valid(e sortedEntry) bool {
+	return s.gen[e.id] == e.gen
+}
+
+// prepare merges the pending entries of a class, and drops stale entries when
+// they take most of the index
+func (s *sortedIndex) prepare(class valueClass) {
+	pending := s.pending[class]
+	if len(pending) == 0 && s.stale[class] <= len(s.entries[class])/2 {
+		return
+	}
+
+	entryCompare := func(a, b sortedEntry) int {
+		if c := a.value.compare(b.value); c != 0 {
+			return c
+		}
+		return int(a.id) - int(b.id)
+	}
+	slices.SortFunc(pending, entryCompare)
+
+	entries := s.entries[class]
+	merged := make([]sortedEntry, 0, len(entries)+len(pending))
+	i, j := 0, 0
+	for i < len(entries) || j < len(pending) {
+		var e sortedEntry
+		if j == len(pending) || (i < len(entries) && entryCompare(entries[i], pending[j]) <= 0) {
+			e, i = entries[i], i+1
+		} else {
+			e, j = pending[j], j+1
+		}
+		if s.valid(e) {
+			merged = append(merged, e)
+		}
+	}
+
+	s.entries[class] = merged
+	s.pending[class] = nil
+	s.stale[class] = 0
+}
+
+func (s *sortedIndex) lookup(op tsl.Operator, literals []value) (bitmap, bool) {
+	if len(literals) == 0 {
+		return nil, false
+	}
+	class := literals[0].class
+	for _, literal := range literals {
+		if !literal.ordered() || literal.class != class {
+			return nil, false
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prepare(class)
+
+	entries := s.entries[class]
+	lower := func(v value) int {
+		return sort.Search(len(entries), func(i int) bool { return entries[i].value.compare(v) >= 0 })
+	}
+	upper := func(v value) int {
+		return sort.Search(len(entries), func(i int) bool { return entries[i].value.compare(v) > 0 })
+	}
+
+	// Ranges of entries matching the lookup
+	var ranges [][2]int
+	switch {
+	case op == tsl.OpEQ && len(literals) == 1:
+		ranges = append(ranges, [2]int{lower(literals[0]), upper(literals[0])})
+	case op == tsl.OpIn:
+		for _, literal := range literals {
+			ranges = append(ranges, [2]int{lower(literal), upper(literal)})
+		}
+	case op == tsl.OpLT && len(literals) == 1:
+		ranges = append(ranges, [2]int{0, lower(literals[0])})
+	case op == tsl.OpLE && len(literals) == 1:
+		ranges = append(ranges, [2]int{0, upper(literals[0])})
+	case op == tsl.OpGT && len(literals) == 1:
+		ranges = append(ranges, [2]int{upper(literals[0]), len(entries)})
+	case op == tsl.OpGE && len(literals) == 1:
+		ranges = append(ranges, [2]int{lower(literals[0]), len(entries)})
+	case op == tsl.OpBetween && len(literals) == 2:
+		ranges = append(ranges, [2]int{lower(literals[0]), upper(literals[1])})
+	default:
+		return nil, false
+	}
+
+	result := s.others(class)
+	for _, r := range ranges {
+		for _, e := range entries[r[0]:max(r[0], r[1])] {
+			if s.valid(e) {
+				result.set(e.id)
+			}
+		}
+	}
+	return result, true
+}
+
+// trieNode is a node of a prefix trie, holding the records whose value ends at
+// the node
+type trieNode struct {
+	children map[byte]*trieNode
+	ids      map[ID]struct{}
+}
+
+// collect adds the records of a node and its descendants to a bitmap
+func (n *trieNode) collect(result *bitmap) {
+	for id := range n.ids {
+		result.set(id)
+	}
+	for _, child := range n.children {
+		child.collect(result)
+	}
+}
+
+// prefixIndex keeps string values in a prefix trie
+type prefixIndex struct {
+	indexBase
+	root *trieNode
+}
+
+func (p *prefixIndex) kind() IndexKind { return PrefixIndex }
+
+func (p *prefixIndex) add(id ID, v value) {
+	p.track(id, v)
+	if v.class != classString {
+		return
+	}
+
+	node := p.root
+	for i := 0; i < len(v.str); i++ {
+		child, ok := node.children[v.str[i]]
+		if !ok {
+			if node.children == nil {
+				node.children = map[byte]*trieNode{}
+			}
+			child = &trieNode{}
+			node.children[v.str[i]] = child
+		}
+		node = child
+	}
+	if node.ids == nil {
+		node.ids = map[ID]struct{}{}
+	}
+	node.ids[id] = struct{}{}
+}
+
+func (p *prefixIndex) remove(id ID) {
+	v, ok := p.untrack(id)
+	if !ok || v.class != classString {
+		return
+	}
+
+	// Remove the record, and prune the nodes left empty
+	var remove func(n *trieNode, s string) bool
+	remove = func(n *trieNode, s string) bool {
+		if s == "" {
+			delete(n.ids, id)
+		} else if child, ok := n.children[s[0]]; ok && remove(child, s[1:]) {
+			delete(n.children, s[0])
+		}
+		return len(n.ids) == 0 && len(n.children) == 0
+	}
+	remove(p.root, v.str)
+}
+
+// find returns the node of a string, or nil
+func (p *prefixIndex) find(s string) *trieNode {
+	node := p.root
+	for i := 0; i < len(s) && node != nil; i++ {
+		node = node.children[s[i]]
+	}
+	return node
+}
+
+func (p *prefixIndex) lookup(op tsl.Operator, literals []value) (bitmap, bool) {
+	if len(literals) != 1 || literals[0].class != classString {
+		return nil, false
+	}
+
+	result := p.others(classString)
+	switch op {
+	case tsl.OpEQ:
+		if node := p.find(literals[0].str); node != nil {
+			for id := range node.ids {
+				result.set(id)
+			}
+		}
+	case tsl.OpLike:
+		prefix := likePrefix(literals[0].str)
+		if prefix == "" {
+			return nil, false
+		}
+		if node := p.find(prefix); node != nil {
+			node.collect(&result)
+		}
+	default:
+		return nil, false
+	}
+	return result, true
+}
+
+// likePrefix returns the literal prefix of a LIKE pattern, the prefix ends at
+// the first wildcard or escaping backslash
+func likePrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, `%_\`); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
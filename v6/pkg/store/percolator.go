@@ -0,0 +1,403 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+	"github.com/yaacov/tree-search-language/v6/pkg/walkers/semantics"
+)
+
+// QueryError is returned when a registered query fails to evaluate
+type QueryError struct {
+	ID  string
+	Err error
+}
+
+func (e QueryError) Error() string {
+	return fmt.Sprintf("query %s: %v", e.ID, e.Err)
+}
+
+func (e QueryError) Unwrap() error {
+	return e.Err
+}
+
+// Percolator matches documents against registered TSL queries, it is safe for
+// concurrent use.
+//
+// Queries are indexed by their equality, IN, range and BETWEEN predicates, a
+// document is only evaluated against the queries whose indexed predicates may
+// match it. Queries without indexable predicates, e.g. a NOT or an OR with an
+// unindexed branch, are evaluated for every document.
+//
+// Example:
+//
+//	p := store.NewPercolator()
+//	p.Add("joe-go-books", tree)
+//
+//	ids, err := p.Match(ctx, func(name string) (interface{}, bool) {
+//		v, ok := event[name]
+//		return v, ok
+//	})
+type Percolator struct {
+	mu      sync.RWMutex
+	opts    []semantics.Option
+	queries map[string]*registeredQuery
+	fields  map[string]*fieldAnchors
+	always  map[string]struct{}
+}
+
+// registeredQuery is a query and its index anchors
+type registeredQuery struct {
+	tree    *tsl.TSLNode
+	anchors []anchor
+}
+
+// anchor is an indexed predicate, a query can only match a document if one of
+// its anchors matches
+type anchor struct {
+	field  string
+	op     tsl.Operator // OpEQ, OpGE (lower bound), OpLE (upper bound) or OpBetween
+	values []value
+}
+
+// bound is a range bound of a query
+type bound struct {
+	value value
+	query string
+}
+
+// between is a BETWEEN range of a query
+type between struct {
+	lower value
+	upper value
+	query string
+}
+
+// fieldAnchors indexes the anchors of one field, by value class
+type fieldAnchors struct {
+	equal   [classCount]map[string]map[string]struct{}
+	lower   [classCount][]bound   // sorted by value
+	upper   [classCount][]bound   // sorted by value
+	between [classCount][]between // sorted by lower bound
+
+	// queries counts the anchors of each query by class
+	queries [classCount]map[string]int
+}
+
+// NewPercolator creates an empty percolator, the evaluation options apply to
+// the evaluation of the candidate queries.
+func NewPercolator(opts ...semantics.Option) *Percolator {
+	return &Percolator{
+		opts:    opts,
+		queries: map[string]*registeredQuery{},
+		fields:  map[string]*fieldAnchors{},
+		always:  map[string]struct{}{},
+	}
+}
+
+// Add registers a query, replacing a query with the same ID.
+func (p *Percolator) Add(id string, tree *tsl.TSLNode) {
+	anchors, ok := cover(tree)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.remove(id)
+	p.queries[id] = &registeredQuery{tree: tree, anchors: anchors}
+	if !ok {
+		p.always[id] = struct{}{}
+		return
+	}
+	for _, a := range anchors {
+		p.field(a.field).add(id, a)
+	}
+}
+
+// Remove unregisters a query, and returns false if no query has the ID.
+func (p *Percolator) Remove(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.remove(id)
+}
+
+// Len returns the number of registered queries.
+func (p *Percolator) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return len(p.queries)
+}
+
+// Match returns the IDs of the registered queries matching a document, in
+// ID order.
+//
+// Queries that fail to evaluate are skipped and reported as QueryError, all
+// the errors are joined. Queries ruled out by the index are not evaluated, so
+// their errors are never reported.
+func (p *Percolator) Match(ctx context.Context, eval semantics.EvalFunc) ([]string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	evalContext := func(_ context.Context, name string) (interface{}, bool, error) {
+		v, ok := eval(name)
+		return v, ok, nil
+	}
+
+	// Collect the candidate queries
+	candidates := map[string]struct{}{}
+	for id := range p.always {
+		candidates[id] = struct{}{}
+	}
+	for field, anchors := range p.fields {
+		v, err := semantics.WalkContext(ctx, identifier(field), evalContext)
+		if err != nil {
+			if errors.As(err, &tsl.KeyNotFoundError{}) {
+				// Anchors of a missing field never match
+				continue
+			}
+			return nil, err
+		}
+		anchors.candidates(newValue(v), candidates)
+	}
+
+	ids := make([]string, 0, len(candidates))
+	for id := range candidates {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var matches []string
+	var errs []error
+	for _, id := range ids {
+		result, err := semantics.WalkContext(ctx, p.queries[id].tree, evalContext, p.opts...)
+		if err != nil {
+			if errors.As(err, &tsl.CanceledError{}) || errors.As(err, &tsl.DeadlineExceededError{}) {
+				return nil, err
+			}
+			errs = append(errs, QueryError{ID: id, Err: err})
+			continue
+		}
+
+		matched, ok := result.(bool)
+		if !ok {
+			errs = append(errs, QueryError{ID: id, Err: tsl.TypeMismatchError{Expected: "boolean", Got: result}})
+			continue
+		}
+		if matched {
+			matches = append(matches, id)
+		}
+	}
+
+	return matches, errors.Join(errs...)
+}
+
+// remove unregisters a query
+func (p *Percolator) remove(id string) bool {
+	query, ok := p.queries[id]
+	if !ok {
+		return false
+	}
+
+	delete(p.queries, id)
+	delete(p.always, id)
+	for _, a := range query.anchors {
+		p.fields[a.field].remove(id, a)
+	}
+	return true
+}
+
+// field returns the anchors index of a field
+func (p *Percolator) field(name string) *fieldAnchors {
+	f, ok := p.fields[name]
+	if !ok {
+		f = &fieldAnchors{}
+		for class := range f.equal {
+			f.equal[class] = map[string]map[string]struct{}{}
+			f.queries[class] = map[string]int{}
+		}
+		p.fields[name] = f
+	}
+	return f
+}
+
+// add indexes an anchor of a query
+func (f *fieldAnchors) add(id string, a anchor) {
+	class := a.values[0].class
+	f.queries[class][id]++
+
+	switch a.op {
+	case tsl.OpEQ:
+		key := a.values[0].key()
+		if f.equal[class][key] == nil {
+			f.equal[class][key] = map[string]struct{}{}
+		}
+		f.equal[class][key][id] = struct{}{}
+	case tsl.OpGE:
+		f.lower[class] = insertBound(f.lower[class], bound{value: a.values[0], query: id})
+	case tsl.OpLE:
+		f.upper[class] = insertBound(f.upper[class], bound{value: a.values[0], query: id})
+	case tsl.OpBetween:
+		r := between{lower: a.values[0], upper: a.values[1], query: id}
+		i := sort.Search(len(f.between[class]), func(i int) bool {
+			return f.between[class][i].lower.compare(r.lower) > 0
+		})
+		f.between[class] = slices.Insert(f.between[class], i, r)
+	}
+}
+
+// remove drops an anchor of a query
+func (f *fieldAnchors) remove(id string, a anchor) {
+	class := a.values[0].class
+	if f.queries[class][id]--; f.queries[class][id] <= 0 {
+		delete(f.queries[class], id)
+	}
+
+	switch a.op {
+	case tsl.OpEQ:
+		key := a.values[0].key()
+		delete(f.equal[class][key], id)
+		if len(f.equal[class][key]) == 0 {
+			delete(f.equal[class], key)
+		}
+	case tsl.OpGE:
+		f.lower[class] = slices.DeleteFunc(f.lower[class], func(b bound) bool { return b.query == id })
+	case tsl.OpLE:
+		f.upper[class] = slices.DeleteFunc(f.upper[class], func(b bound) bool { return b.query == id })
+	case tsl.OpBetween:
+		f.between[class] = slices.DeleteFunc(f.between[class], func(r between) bool { return r.query == id })
+	}
+}
+
+// candidates adds the queries whose anchors may match a document value.
+//
+// Range bounds are treated as inclusive. Anchors with literals of another
+// class than the value can not be ruled out, since the walker may convert
+// values between classes, e.g. date strings to dates.
+func (f *fieldAnchors) candidates(v value, result map[string]struct{}) {
+	for class := range f.queries {
+		if valueClass(class) == v.class {
+			continue
+		}
+		for id := range f.queries[class] {
+			result[id] = struct{}{}
+		}
+	}
+	if v.class == classOther {
+		return
+	}
+
+	for id := range f.equal[v.class][v.key()] {
+		result[id] = struct{}{}
+	}
+	if !v.ordered() {
+		return
+	}
+
+	// Lower bounds at or below the value
+	lower := f.lower[v.class]
+	n := sort.Search(len(lower), func(i int) bool { return lower[i].value.compare(v) > 0 })
+	for _, b := range lower[:n] {
+		result[b.query] = struct{}{}
+	}
+
+	// Upper bounds at or above the value
+	upper := f.upper[v.class]
+	n = sort.Search(len(upper), func(i int) bool { return upper[i].value.compare(v) >= 0 })
+	for _, b := range upper[n:] {
+		result[b.query] = struct{}{}
+	}
+
+	// Ranges starting at or below the value, and ending at or above it
+	ranges := f.between[v.class]
+	n = sort.Search(len(ranges), func(i int) bool { return ranges[i].lower.compare(v) > 0 })
+	for _, r := range ranges[:n] {
+		if r.upper.compare(v) >= 0 {
+			result[r.query] = struct{}{}
+		}
+	}
+}
+
+// insertBound inserts a bound keeping the bounds sorted by value
+func insertBound(bounds []bound, b bound) []bound {
+	i := sort.Search(len(bounds), func(i int) bool { return bounds[i].value.compare(b.value) > 0 })
+	return slices.Insert(bounds, i, b)
+}
+
+// cover returns anchors such that a document matching the tree matches at
+// least one of them, and false when the tree has no such anchors.
+//
+// An AND needs the anchors of one branch, the branch with fewer anchors is
+// used, an OR needs the anchors of both branches.
+func cover(n *tsl.TSLNode) ([]anchor, bool) {
+	if n.Type() != tsl.KindBinaryExpr {
+		return nil, false
+	}
+	op := n.Value().(tsl.TSLExpressionOp)
+
+	switch op.Operator {
+	case tsl.OpAnd:
+		left, leftOk := cover(op.Left)
+		right, rightOk := cover(op.Right)
+		switch {
+		case leftOk && rightOk && len(right) < len(left):
+			return right, true
+		case leftOk:
+			return left, true
+		default:
+			return right, rightOk
+		}
+
+	case tsl.OpOr:
+		left, leftOk := cover(op.Left)
+		right, rightOk := cover(op.Right)
+		if !leftOk || !rightOk {
+			return nil, false
+		}
+		return append(left, right...), true
+	}
+
+	if op.Left.Type() != tsl.KindIdentifier {
+		return nil, false
+	}
+	field := op.Left.Value().(string)
+	literals, ok := literalValues(op.Right)
+	if !ok {
+		return nil, false
+	}
+	for _, literal := range literals {
+		if literal.class == classOther {
+			return nil, false
+		}
+	}
+
+	switch op.Operator {
+	case tsl.OpEQ, tsl.OpIn:
+		if op.Operator == tsl.OpEQ && len(literals) != 1 {
+			return nil, false
+		}
+		anchors := make([]anchor, 0, len(literals))
+		for _, literal := range literals {
+			anchors = append(anchors, anchor{field: field, op: tsl.OpEQ, values: []value{literal}})
+		}
+		return anchors, true
+	case tsl.OpGT, tsl.OpGE:
+		if len(literals) == 1 && literals[0].ordered() {
+			return []anchor{{field: field, op: tsl.OpGE, values: literals}}, true
+		}
+	case tsl.OpLT, tsl.OpLE:
+		if len(literals) == 1 && literals[0].ordered() {
+			return []anchor{{field: field, op: tsl.OpLE, values: literals}}, true
+		}
+	case tsl.OpBetween:
+		if len(literals) == 2 && literals[0].ordered() && literals[0].class == literals[1].class {
+			return []anchor{{field: field, op: tsl.OpBetween, values: literals}}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,217 @@
+// Copyright 2019 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+	"github.com/yaacov/tree-search-language/v6/pkg/walkers/semantics"
+)
+
+// evalOf returns an evaluation function for a document
+func evalOf(doc record) semantics.EvalFunc {
+	return func(name string) (interface{}, bool) {
+		return get(doc, name)
+	}
+}
+
+var _ = Describe("Percolator", func() {
+	var p *Percolator
+
+	BeforeEach(func() {
+		p = NewPercolator()
+		queries := map[string]string{
+			"joe":          "author = 'Joe'",
+			"joe-or-jane":  "author in ['Joe', 'Jane']",
+			"long":         "pages > 500",
+			"short-joe":    "pages <= 100 and author = 'Joe'",
+			"medium":       "pages between 200 and 400",
+			"recent":       "created >= 2024-06-01",
+			"go":           "title ~= 'Go' or author = 'Rob'",
+			"not-joe":      "not (author = 'Joe')",
+			"joe-or-long":  "author = 'Joe' or pages > 1000",
+			"never":        "author in []",
+			"missing-isbn": "isbn = '123'",
+		}
+		for id, text := range queries {
+			p.Add(id, mustParse(text))
+		}
+	})
+
+	DescribeTable("Returns the matching queries",
+		func(doc record, expected []string) {
+			ids, err := p.Match(context.Background(), evalOf(doc))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids).To(Equal(expected))
+		},
+
+		Entry("equality and ranges",
+			record{"author": "Joe", "pages": 80, "title": "Go Basics", "created": "2024-07-01", "isbn": "1"},
+			[]string{"go", "joe", "joe-or-jane", "joe-or-long", "recent", "short-joe"}),
+		Entry("between",
+			record{"author": "Ann", "pages": 300, "title": "Rust", "created": "2023-01-01", "isbn": "123"},
+			[]string{"medium", "missing-isbn", "not-joe"}),
+		Entry("no matches",
+			record{"author": "Joe", "pages": 200, "title": "C", "created": "2020-01-01", "isbn": "1"},
+			[]string{"joe", "joe-or-jane", "joe-or-long", "medium"}),
+	)
+
+	It("adds and removes queries at runtime", func() {
+		doc := record{"author": "Jane", "pages": 900, "title": "C", "created": "2020-01-01", "isbn": "1"}
+
+		ids, err := p.Match(context.Background(), evalOf(doc))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"joe-or-jane", "long", "not-joe"}))
+
+		Expect(p.Remove("long")).To(BeTrue())
+		Expect(p.Remove("long")).To(BeFalse())
+		p.Add("not-joe", mustParse("pages > 800"))
+		p.Add("jane", mustParse("author = 'Jane'"))
+		Expect(p.Len()).To(Equal(11))
+
+		ids, err = p.Match(context.Background(), evalOf(doc))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"jane", "joe-or-jane", "not-joe"}))
+	})
+
+	It("registers queries with timestamp literals", func() {
+		p.Add("march", mustParse("created >= 2024-03-01T12:00:00Z and created < 2024-04-01T00:00:00Z"))
+		p.Add("exact", mustParse("created = 2024-03-05T08:30:00Z"))
+
+		doc := record{"author": "Ann", "pages": 50, "title": "C", "created": time.Date(2024, 3, 5, 8, 30, 0, 0, time.UTC), "isbn": "1"}
+		ids, err := p.Match(context.Background(), evalOf(doc))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"exact", "march", "not-joe"}))
+
+		doc["created"] = time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC)
+		ids, err = p.Match(context.Background(), evalOf(doc))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"not-joe"}))
+	})
+
+	It("reports queries that fail to evaluate", func() {
+		doc := record{"author": "Joe", "pages": "many", "title": "C", "created": "2020-01-01", "isbn": "1"}
+
+		ids, err := p.Match(context.Background(), evalOf(doc))
+		Expect(ids).To(Equal([]string{"joe", "joe-or-jane"}))
+
+		var queryErr QueryError
+		Expect(errors.As(err, &queryErr)).To(BeTrue())
+		Expect(queryErr.ID).To(Equal("joe-or-long"))
+		Expect(errors.As(err, &tsl.TypeMismatchError{})).To(BeTrue())
+	})
+
+	It("matches like evaluating every query", func() {
+		r := rand.New(rand.NewSource(11))
+		authors := []string{"Joe", "Jane", "Ann", "Bob"}
+		fields := []string{"pages", "rating", "created"}
+
+		// Random queries over a few fields
+		predicate := func() string {
+			switch r.Intn(6) {
+			case 0:
+				return fmt.Sprintf("author = '%s'", authors[r.Intn(len(authors))])
+			case 1:
+				return fmt.Sprintf("author in ['%s', '%s']", authors[r.Intn(len(authors))], authors[r.Intn(len(authors))])
+			case 2:
+				return fmt.Sprintf("%s %s %d", fields[r.Intn(2)], []string{"<", "<=", ">", ">="}[r.Intn(4)], r.Intn(50))
+			case 3:
+				lo := r.Intn(40)
+				return fmt.Sprintf("pages between %d and %d", lo, lo+r.Intn(20))
+			case 4:
+				return fmt.Sprintf("created > 2024-01-%02d", 1+r.Intn(28))
+			default:
+				return fmt.Sprintf("rating != %d", r.Intn(5))
+			}
+		}
+		trees := map[string]*tsl.TSLNode{}
+		for i := 0; i < 300; i++ {
+			text := predicate()
+			switch r.Intn(3) {
+			case 0:
+				text = fmt.Sprintf("(%s) and (%s)", text, predicate())
+			case 1:
+				text = fmt.Sprintf("(%s) or (%s)", text, predicate())
+			}
+			id := fmt.Sprintf("q%03d", i)
+			trees[id] = mustParse(text)
+			p.Add(id, trees[id])
+		}
+
+		for i := 0; i < 100; i++ {
+			doc := record{
+				"author":  authors[r.Intn(len(authors))],
+				"pages":   r.Intn(60),
+				"rating":  r.Intn(5),
+				"created": fmt.Sprintf("2024-01-%02d", 1+r.Intn(28)),
+				"title":   "Go",
+				"isbn":    "1",
+			}
+
+			var expected []string
+			for id, tree := range trees {
+				result, err := semantics.Walk(tree, evalOf(doc))
+				Expect(err).ToNot(HaveOccurred())
+				if result == true {
+					expected = append(expected, id)
+				}
+			}
+			slices.Sort(expected)
+
+			ids, err := p.Match(context.Background(), evalOf(doc))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(slices.DeleteFunc(ids, func(id string) bool { return trees[id] == nil })).To(Equal(expected))
+		}
+	})
+
+	It("is safe for concurrent use", func() {
+		doc := record{"author": "Joe", "pages": 80, "title": "C", "created": "2024-07-01", "isbn": "1"}
+		tree := mustParse("author = 'Joe' and pages < 100")
+
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				for i := 0; i < 100; i++ {
+					id := fmt.Sprintf("w%d-%d", w, i)
+					p.Add(id, tree)
+					ids, err := p.Match(context.Background(), evalOf(doc))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(ids).To(ContainElement(id))
+					if i%2 == 0 {
+						Expect(p.Remove(id)).To(BeTrue())
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		Expect(p.Len()).To(Equal(11 + 200))
+	})
+})
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+	"github.com/yaacov/tree-search-language/v6/pkg/walkers/semantics"
+)
+
+// plan holds the candidate records of a TSL tree, and a description of the
+// index lookups used to find them
+type plan struct {
+	ids  bitmap
+	desc string
+}
+
+// lookupOperators are the operators answered by index lookups
+var lookupOperators = map[tsl.Operator]bool{
+	tsl.OpEQ: true, tsl.OpIn: true, tsl.OpBetween: true, tsl.OpLike: true,
+	tsl.OpLT: true, tsl.OpLE: true, tsl.OpGT: true, tsl.OpGE: true,
+}
+
+// plan maps a TSL tree onto index lookups, and returns nil when all the
+// records are candidates.
+//
+// AND branches intersect the candidates of the branches that use indexes, OR
+// branches join candidates only when both branches use indexes. Other nodes,
+// e.g. NOT, can not narrow the candidates.
+func (s *Store[T]) plan(n *tsl.TSLNode) *plan {
+	if n.Type() != tsl.KindBinaryExpr {
+		return nil
+	}
+	op := n.Value().(tsl.TSLExpressionOp)
+
+	switch op.Operator {
+	case tsl.OpAnd:
+		left, right := s.plan(op.Left), s.plan(op.Right)
+		switch {
+		case left != nil && right != nil:
+			return &plan{ids: left.ids.and(right.ids), desc: fmt.Sprintf("AND(%s, %s)", left.desc, right.desc)}
+		case left != nil:
+			return left
+		default:
+			return right
+		}
+
+	case tsl.OpOr:
+		left, right := s.plan(op.Left), s.plan(op.Right)
+		if left == nil || right == nil {
+			return nil
+		}
+		return &plan{ids: left.ids.or(right.ids), desc: fmt.Sprintf("OR(%s, %s)", left.desc, right.desc)}
+
+	default:
+		if !lookupOperators[op.Operator] || op.Left.Type() != tsl.KindIdentifier {
+			return nil
+		}
+		literals, ok := literalValues(op.Right)
+		if !ok {
+			return nil
+		}
+
+		field := op.Left.Value().(string)
+		for _, kind := range []IndexKind{HashIndex, SortedIndex, PrefixIndex} {
+			for _, idx := range s.indexes[field] {
+				if idx.kind() != kind {
+					continue
+				}
+				if ids, ok := idx.lookup(op.Operator, literals); ok {
+					return &plan{ids: ids, desc: fmt.Sprintf("%s(%s)", kind, field)}
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// literalValues returns the values of a literal or an array of literals
+func literalValues(n *tsl.TSLNode) ([]value, bool) {
+	nodes := []*tsl.TSLNode{n}
+	if n.Type() == tsl.KindArrayLiteral {
+		nodes = n.Value().(tsl.TSLArrayLiteral).Values
+	}
+
+	values := make([]value, 0, len(nodes))
+	for _, node := range nodes {
+		var ok bool
+		switch node.Type() {
+		case tsl.KindNumericLiteral, tsl.KindStringLiteral, tsl.KindBooleanLiteral,
+			tsl.KindDateLiteral, tsl.KindTimestampLiteral:
+		default:
+			return nil, false
+		}
+
+		v, err := semantics.WalkContext(context.Background(), node, nil)
+		if err != nil {
+			return nil, false
+		}
+
+		// Date literals are strings, the walker compares them as dates,
+		// timestamp literals are already times
+		if node.Type() == tsl.KindDateLiteral {
+			if v, ok = parseDate(v.(string)); !ok {
+				return nil, false
+			}
+		}
+		values = append(values, newValue(v))
+	}
+	return values, true
+}
+
+// parseDate parses a date literal
+func parseDate(s string) (time.Time, bool) {
+	t, err := time.Parse("2006-01-02", s)
+	return t, err == nil
+}
@@ -0,0 +1,270 @@
+// Package store implements indexed in-memory search using TSL trees, a record
+// store searched using TSL trees, and a percolator matching documents against
+// registered TSL queries.
+//
+// Fields can be indexed using hash indexes (equality and IN), sorted indexes
+// (ranges and BETWEEN on numbers and dates) and prefix tries (LIKE 'abc%').
+// A search maps the TSL tree onto index lookups, intersecting the candidates
+// of AND branches and joining the candidates of OR branches, and then checks
+// the candidates using the semantics walker, so predicates without an index
+// are still applied.
+//
+// Example:
+//
+//	books := store.New(get)
+//	books.AddIndex("author", store.HashIndex)
+//	books.AddIndex("pages", store.SortedIndex)
+//
+//	id := books.Insert(book)
+//
+//	tree, err := tsl.ParseTSL("author = 'Joe' and pages > 100 and title ~= 'Go'")
+//	matches, err := books.Search(ctx, tree)
+//
+// The percolator indexes registered queries by their equality and range
+// predicates, and evaluates only the queries that may match a document.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+	"github.com/yaacov/tree-search-language/v6/pkg/walkers/semantics"
+)
+
+// ID identifies a record of a store, IDs are assigned in insertion order and
+// are not reused
+type ID int
+
+// NotFoundError is returned when a record ID is not in the store
+type NotFoundError struct {
+	ID ID
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("record not found: %d", e.ID)
+}
+
+// Store is an indexed in-memory record store, it is safe for concurrent use.
+type Store[T any] struct {
+	mu      sync.RWMutex
+	get     semantics.Accessor[T]
+	opts    []semantics.Option
+	records []T
+	live    bitmap
+	indexes map[string][]index
+}
+
+// New creates an empty store, get returns the field values of a record and
+// the evaluation options apply to the checks of search candidates.
+//
+// Indexes use the built-in value comparisons, fields compared using a
+// registered comparator (see semantics.WithRegistry) should not be indexed.
+func New[T any](get semantics.Accessor[T], opts ...semantics.Option) *Store[T] {
+	return &Store[T]{
+		get:     get,
+		opts:    opts,
+		indexes: map[string][]index{},
+	}
+}
+
+// AddIndex indexes a field, the records already in the store are indexed.
+func (s *Store[T]) AddIndex(field string, kind IndexKind) error {
+	idx, ok := newIndex(kind)
+	if !ok {
+		return tsl.UnexpectedTypeError{Type: kind}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range s.live.ids() {
+		idx.add(id, s.fieldValue(s.records[id], field))
+	}
+	s.indexes[field] = append(s.indexes[field], idx)
+	return nil
+}
+
+// Insert adds a record to the store, and returns its ID.
+func (s *Store[T]) Insert(record T) ID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := ID(len(s.records))
+	s.records = append(s.records, record)
+	s.live.set(id)
+	s.index(id, record)
+
+	return id
+}
+
+// Update replaces a record.
+func (s *Store[T]) Update(id ID, record T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.live.has(id) {
+		return NotFoundError{ID: id}
+	}
+
+	s.unindex(id)
+	s.records[id] = record
+	s.index(id, record)
+
+	return nil
+}
+
+// Delete removes a record.
+func (s *Store[T]) Delete(id ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.live.has(id) {
+		return NotFoundError{ID: id}
+	}
+
+	s.unindex(id)
+	var zero T
+	s.records[id] = zero
+	s.live.clear(id)
+
+	return nil
+}
+
+// Get returns a record, and false if the ID is not in the store.
+func (s *Store[T]) Get(id ID) (T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.live.has(id) {
+		var zero T
+		return zero, false
+	}
+	return s.records[id], true
+}
+
+// Len returns the number of records in the store.
+func (s *Store[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.live.count()
+}
+
+// Search returns the records matching a TSL tree, in ID order.
+//
+// Record errors are returned as tsl.RecordError with the record ID as index,
+// like semantics.Filter. Only the candidates of the index lookups are
+// evaluated, so records ruled out by an index are never reported.
+func (s *Store[T]) Search(ctx context.Context, tree *tsl.TSLNode) ([]T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids, err := s.search(ctx, tree)
+	if ids == nil {
+		return nil, err
+	}
+
+	records := make([]T, len(ids))
+	for i, id := range ids {
+		records[i] = s.records[id]
+	}
+	return records, err
+}
+
+// SearchIDs returns the IDs of the records matching a TSL tree, in order.
+func (s *Store[T]) SearchIDs(ctx context.Context, tree *tsl.TSLNode) ([]ID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.search(ctx, tree)
+}
+
+// Explain describes the index lookups used to search for a TSL tree, e.g.
+// "AND(hash(author), sorted(pages))", or "scan" when all the records are
+// candidates.
+func (s *Store[T]) Explain(tree *tsl.TSLNode) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if p := s.plan(tree); p != nil {
+		return p.desc
+	}
+	return "scan"
+}
+
+// search checks the candidate records of a TSL tree
+func (s *Store[T]) search(ctx context.Context, tree *tsl.TSLNode) ([]ID, error) {
+	candidates := s.live
+	if p := s.plan(tree); p != nil {
+		candidates = p.ids.and(s.live)
+	}
+	ids := candidates.ids()
+
+	get := func(id ID, name string) (interface{}, bool) {
+		return s.get(s.records[id], name)
+	}
+	matches, err := semantics.Filter(ctx, tree, ids, get, s.opts...)
+	return matches, recordIDs(err, ids)
+}
+
+// index adds a record to the field indexes
+func (s *Store[T]) index(id ID, record T) {
+	for field, indexes := range s.indexes {
+		v := s.fieldValue(record, field)
+		for _, idx := range indexes {
+			idx.add(id, v)
+		}
+	}
+}
+
+// unindex removes a record from the field indexes
+func (s *Store[T]) unindex(id ID) {
+	for _, indexes := range s.indexes {
+		for _, idx := range indexes {
+			idx.remove(id)
+		}
+	}
+}
+
+// fieldValue returns the indexed value of a record field, as evaluated by the
+// semantics walker
+func (s *Store[T]) fieldValue(record T, field string) value {
+	eval := func(_ context.Context, name string) (interface{}, bool, error) {
+		v, ok := s.get(record, name)
+		return v, ok, nil
+	}
+
+	v, err := semantics.WalkContext(context.Background(), identifier(field), eval)
+	if err != nil {
+		return value{class: classOther}
+	}
+	return newValue(v)
+}
+
+// identifier returns an identifier node
+func identifier(name string) *tsl.TSLNode {
+	return &tsl.TSLNode{Node: &tsl.Node{Kind: tsl.KindIdentifier, Value: name}}
+}
+
+// recordIDs replaces the candidate positions of record errors with record IDs
+func recordIDs(err error, ids []ID) error {
+	if err == nil {
+		return nil
+	}
+
+	switch e := err.(type) {
+	case tsl.RecordError:
+		return tsl.RecordError{Index: int(ids[e.Index]), Err: e.Err}
+	case interface{ Unwrap() []error }:
+		var errs []error
+		for _, err := range e.Unwrap() {
+			errs = append(errs, recordIDs(err, ids))
+		}
+		return errors.Join(errs...)
+	default:
+		return err
+	}
+}
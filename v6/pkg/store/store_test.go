@@ -0,0 +1,290 @@
+// Copyright 2019 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+	"github.com/yaacov/tree-search-language/v6/pkg/walkers/semantics"
+)
+
+func TestStore(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Indexed store")
+}
+
+// record is a test record type
+type record map[string]interface{}
+
+func get(r record, name string) (interface{}, bool) {
+	v, ok := r[name]
+	return v, ok
+}
+
+func mustParse(text string) *tsl.TSLNode {
+	tree, err := tsl.ParseTSL(text)
+	Expect(err).ToNot(HaveOccurred())
+	return tree
+}
+
+// newBooks returns a store of books with indexed author, pages, created and title fields
+func newBooks() *Store[record] {
+	books := New(get)
+	Expect(books.AddIndex("author", HashIndex)).To(Succeed())
+	Expect(books.AddIndex("pages", SortedIndex)).To(Succeed())
+	Expect(books.AddIndex("created", SortedIndex)).To(Succeed())
+	Expect(books.AddIndex("title", PrefixIndex)).To(Succeed())
+	return books
+}
+
+// titles returns the titles of records
+func titles(records []record) []string {
+	var titles []string
+	for _, r := range records {
+		titles = append(titles, r["title"].(string))
+	}
+	return titles
+}
+
+var _ = Describe("Store", func() {
+	var books *Store[record]
+
+	BeforeEach(func() {
+		books = newBooks()
+		day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+		books.Insert(record{"title": "Go in Action", "author": "Joe", "pages": 300, "created": day(1), "edited": day(2)})
+		books.Insert(record{"title": "Go Patterns", "author": "Jane", "pages": 150, "created": day(5), "edited": day(5)})
+		books.Insert(record{"title": "Rust Basics", "author": "Joe", "pages": 90, "created": day(10), "edited": day(12)})
+		books.Insert(record{"title": "Graph Theory", "author": "Ann", "pages": 520, "created": day(20), "edited": day(21)})
+		books.Insert(record{"title": "Gophers", "author": "Jane", "pages": 610, "created": "2024-01-25", "edited": day(15)})
+	})
+
+	DescribeTable("Searches using the indexes",
+		func(text string, plan string, expected []string) {
+			tree := mustParse(text)
+			Expect(books.Explain(tree)).To(Equal(plan))
+
+			matches, err := books.Search(context.Background(), tree)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(titles(matches)).To(Equal(expected))
+		},
+
+		Entry("equality", "author = 'Joe'", "hash(author)",
+			[]string{"Go in Action", "Rust Basics"}),
+		Entry("in", "author in ['Ann', 'Jane']", "hash(author)",
+			[]string{"Go Patterns", "Graph Theory", "Gophers"}),
+		Entry("range", "pages >= 150 and pages < 520", "AND(sorted(pages), sorted(pages))",
+			[]string{"Go in Action", "Go Patterns"}),
+		Entry("between", "pages between 100 and 300", "sorted(pages)",
+			[]string{"Go in Action", "Go Patterns"}),
+		Entry("date range", "created >= 2024-01-04 and created <= 2024-01-21", "AND(sorted(created), sorted(created))",
+			[]string{"Go Patterns", "Rust Basics", "Graph Theory"}),
+		Entry("date strings", "created > 2024-01-22", "sorted(created)",
+			[]string{"Gophers"}),
+		Entry("timestamp range", "created >= 2024-01-05T00:00:00Z and created < 2024-01-20T00:00:00Z", "AND(sorted(created), sorted(created))",
+			[]string{"Go Patterns", "Rust Basics"}),
+		Entry("timestamp between", "created between 2024-01-01T00:00:00Z and 2024-01-05T00:00:00Z", "sorted(created)",
+			[]string{"Go in Action", "Go Patterns"}),
+		Entry("timestamp equality", "created = 2024-01-10T00:00:00Z", "sorted(created)",
+			[]string{"Rust Basics"}),
+		Entry("timestamp without index", "edited > 2024-01-15T12:00:00Z", "scan",
+			[]string{"Graph Theory"}),
+		Entry("prefix", "title like 'Go%'", "prefix(title)",
+			[]string{"Go in Action", "Go Patterns", "Gophers"}),
+		Entry("prefix with single character wildcard", "title like 'Go_%'", "prefix(title)",
+			[]string{"Go in Action", "Go Patterns", "Gophers"}),
+		Entry("prefix with escaped wildcard", `title like 'Go\\%%'`, "prefix(title)",
+			nil),
+		Entry("prefix with regular expression characters", "title like 'Go (%'", "prefix(title)",
+			nil),
+		Entry("and with a predicate without index", "author = 'Joe' and title ~= 'Rust'", "hash(author)",
+			[]string{"Rust Basics"}),
+		Entry("or", "author = 'Ann' or pages < 100", "OR(hash(author), sorted(pages))",
+			[]string{"Rust Basics", "Graph Theory"}),
+		Entry("or with a predicate without index", "author = 'Ann' or title ~= 'Rust'", "scan",
+			[]string{"Rust Basics", "Graph Theory"}),
+		Entry("not", "not (author = 'Joe')", "scan",
+			[]string{"Go Patterns", "Graph Theory", "Gophers"}),
+		Entry("no matches", "author = 'Nobody'", "hash(author)",
+			nil),
+	)
+
+	DescribeTable("Finds the literal prefix of LIKE patterns",
+		func(pattern string, prefix string) {
+			Expect(likePrefix(pattern)).To(Equal(prefix))
+		},
+		Entry("wildcard", "Go%", "Go"),
+		Entry("single character wildcard", "Go_s", "Go"),
+		Entry("regular expression characters", "Go (2nd ed.)%", "Go (2nd ed.)"),
+		Entry("escaped wildcard", `Go\%%`, "Go"),
+		Entry("no wildcards", "Gophers", "Gophers"),
+	)
+
+	It("keeps indexes up to date on update and delete", func() {
+		tree := mustParse("author = 'Joe' and pages > 100")
+
+		matches, err := books.SearchIDs(context.Background(), tree)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(matches).To(Equal([]ID{0}))
+
+		Expect(books.Update(2, record{"title": "Rust Basics", "author": "Joe", "pages": 400})).To(Succeed())
+		Expect(books.Delete(0)).To(Succeed())
+
+		matches, err = books.SearchIDs(context.Background(), tree)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(matches).To(Equal([]ID{2}))
+		Expect(books.Len()).To(Equal(4))
+
+		_, ok := books.Get(0)
+		Expect(ok).To(BeFalse())
+		Expect(books.Delete(0)).To(Equal(NotFoundError{ID: 0}))
+		Expect(books.Update(7, record{})).To(Equal(NotFoundError{ID: 7}))
+	})
+
+	It("indexes the records already in the store", func() {
+		Expect(books.AddIndex("author", SortedIndex)).To(Succeed())
+		Expect(books.AddIndex("missing", HashIndex)).To(Succeed())
+		Expect(books.AddIndex("pages", IndexKind(9))).To(BeAssignableToTypeOf(tsl.UnexpectedTypeError{}))
+
+		ids, err := books.SearchIDs(context.Background(), mustParse("author = 'Ann'"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]ID{3}))
+	})
+
+	It("reports record errors using record IDs", func() {
+		books.Insert(record{"title": "Go Mistakes", "author": "Bob", "pages": "many"})
+		_, err := books.Search(context.Background(), mustParse("pages > 500"))
+
+		var recordErr tsl.RecordError
+		Expect(errors.As(err, &recordErr)).To(BeTrue())
+		Expect(recordErr.Index).To(Equal(5))
+	})
+})
+
+var _ = Describe("Store search", func() {
+	// randomBook returns a book with random field values
+	randomBook := func(r *rand.Rand) record {
+		authors := []string{"Joe", "Jane", "Ann", "Bob"}
+		book := record{
+			"title":   fmt.Sprintf("%c%c book", 'a'+r.Intn(4), 'a'+r.Intn(4)),
+			"author":  authors[r.Intn(len(authors))],
+			"pages":   r.Intn(50),
+			"created": time.Date(2024, 1, 1+r.Intn(30), 0, 0, 0, 0, time.UTC),
+			"rating":  r.Intn(5),
+		}
+		if r.Intn(10) == 0 {
+			book["pages"] = nil
+		}
+		return book
+	}
+
+	queries := []string{
+		"author = 'Joe'",
+		"author in ['Joe', 'Bob'] and pages > 20",
+		"rating between 1 and 3 or author = 'Ann'",
+		"pages <= 5 or pages >= 45",
+		"pages = 7 and rating > 2",
+		"created >= 2024-01-15 and title like 'a%'",
+		"title like 'ab%' or title like 'c_ %'",
+		"title = 'bb book' and not (author = 'Joe')",
+		"pages is null or author = 'Jane'",
+		"(author = 'Joe' or pages < 10) and created < 2024-01-10",
+		"pages in [1, 2, 3, 4.0] and rating != 1",
+		"pages > 2.5",
+	}
+
+	It("matches a full scan after inserts, updates and deletes", func() {
+		r := rand.New(rand.NewSource(7))
+		books := newBooks()
+
+		var all []record
+		var ids []ID
+		for i := 0; i < 500; i++ {
+			book := randomBook(r)
+			ids = append(ids, books.Insert(book))
+			all = append(all, book)
+		}
+
+		check := func() {
+			var live []record
+			for _, id := range ids {
+				if book, ok := books.Get(id); ok {
+					live = append(live, book)
+				}
+			}
+			for _, text := range queries {
+				expected, err := semantics.Filter(context.Background(), mustParse(text), live, get)
+				Expect(err).ToNot(HaveOccurred())
+
+				matches, err := books.Search(context.Background(), mustParse(text))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(matches).To(Equal(expected), text)
+			}
+		}
+		check()
+
+		for i := 0; i < 200; i++ {
+			id := ids[r.Intn(len(ids))]
+			if r.Intn(2) == 0 {
+				_ = books.Update(id, randomBook(r))
+			} else {
+				_ = books.Delete(id)
+			}
+			if i%50 == 0 {
+				check()
+			}
+		}
+		check()
+	})
+
+	It("is safe for concurrent use", func() {
+		books := newBooks()
+		tree := mustParse("author = 'Joe' and pages > 10")
+
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(seed int64) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				r := rand.New(rand.NewSource(seed))
+				for i := 0; i < 200; i++ {
+					id := books.Insert(randomBook(r))
+					if i%3 == 0 {
+						Expect(books.Update(id, randomBook(r))).To(Succeed())
+					}
+					_, err := books.Search(context.Background(), tree)
+					Expect(err).ToNot(HaveOccurred())
+				}
+			}(int64(w))
+		}
+		wg.Wait()
+
+		Expect(books.Len()).To(Equal(800))
+	})
+})
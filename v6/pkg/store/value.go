@@ -0,0 +1,89 @@
+package store
+
+import (
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// valueClass is the kind of an indexed value, index lookups only match values
+// of the same class as the literal
+type valueClass int
+
+const (
+	// classOther holds values the indexes do not order or hash, e.g. missing
+	// fields, nulls, floats, arrays or registered types, they are always
+	// candidates
+	classOther valueClass = iota
+	classNumber
+	classTime
+	classString
+	classBool
+
+	classCount
+)
+
+// value is a field or literal value, normalized for indexing
+type value struct {
+	class valueClass
+	num   *big.Rat
+	time  time.Time
+	str   string
+	bool  bool
+}
+
+// newValue normalizes a value evaluated by the semantics walker.
+//
+// Integers and decimals compare exactly, floats are compared by the walker
+// using float64 arithmetic, so they are not indexed.
+func newValue(v interface{}) value {
+	switch v := v.(type) {
+	case int64:
+		return value{class: classNumber, num: new(big.Rat).SetInt64(v)}
+	case tsl.Decimal:
+		return value{class: classNumber, num: v.Rat()}
+	case time.Time:
+		return value{class: classTime, time: v}
+	case string:
+		return value{class: classString, str: v}
+	case bool:
+		return value{class: classBool, bool: v}
+	default:
+		return value{class: classOther}
+	}
+}
+
+// key returns a hash key for the value, equal values of a class have equal keys
+func (v value) key() string {
+	switch v.class {
+	case classNumber:
+		return v.num.RatString()
+	case classTime:
+		return v.time.UTC().Format(time.RFC3339Nano)
+	case classString:
+		return v.str
+	case classBool:
+		return strconv.FormatBool(v.bool)
+	default:
+		return ""
+	}
+}
+
+// compare orders two values of the same ordered class
+func (v value) compare(o value) int {
+	switch v.class {
+	case classNumber:
+		return v.num.Cmp(o.num)
+	case classTime:
+		return v.time.Compare(o.time)
+	default:
+		return 0
+	}
+}
+
+// ordered returns true for value classes that support ranges
+func (v value) ordered() bool {
+	return v.class == classNumber || v.class == classTime
+}
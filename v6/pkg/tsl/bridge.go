@@ -15,6 +15,9 @@ var (
 		parser.NodeArrayLiteral:     KindArrayLiteral,
 		parser.NodeBooleanLiteral:   KindBooleanLiteral,
 		parser.NodeNullLiteral:      KindNullLiteral,
+		parser.NodeIPLiteral:        KindIPLiteral,
+		parser.NodeCIDRLiteral:      KindCIDRLiteral,
+		parser.NodeVersionLiteral:   KindVersionLiteral,
 	}
 
 	operatorMap = map[parser.OpType]Operator{
@@ -44,6 +47,12 @@ var (
 		parser.OpREQ:     OpREQ,
 		parser.OpRNE:     OpRNE,
 		parser.OpUMinus:  OpUMinus,
+		parser.OpWithin:  OpWithin,
+		parser.OpCount:   OpCount,
+		parser.OpAvg:     OpAvg,
+		parser.OpMin:     OpMin,
+		parser.OpMax:     OpMax,
+		parser.OpAggSum:  OpAggSum,
 	}
 )
 
@@ -0,0 +1,396 @@
+package tsl
+
+import (
+	"math"
+	"math/big"
+	"net/netip"
+	"time"
+)
+
+// atom is a number or a string literal compared with a field
+type atom struct {
+	num *big.Rat // nil for strings
+	str string
+}
+
+// equal reports if two atoms are the same value
+func (a atom) equal(o atom) bool {
+	if a.num == nil || o.num == nil {
+		return a.num == nil && o.num == nil && a.str == o.str
+	}
+	return a.num.Cmp(o.num) == 0
+}
+
+// constraint is a comparison of a field with literals, a field must hold one
+// of the values of an OpIn constraint, and can not hold the values of an OpNE
+// constraint
+type constraint struct {
+	op     Operator // OpIn, OpNE, OpLT, OpLE, OpGT, OpGE, OpBetween or OpIs
+	values []atom
+
+	// negated is set for NOT BETWEEN and IS NOT NULL
+	negated bool
+
+	// nullable is set when a null field matches the constraint, e.g. for
+	// "a != 1" or "not (a < 1)"
+	nullable bool
+}
+
+// negate returns the constraint matched by the fields that do not match the
+// constraint, among the fields it compares without errors
+func (c constraint) negate() constraint {
+	complement := map[Operator]Operator{
+		OpIn: OpNE, OpNE: OpIn, OpLT: OpGE, OpLE: OpGT, OpGT: OpLE, OpGE: OpLT,
+	}
+
+	switch c.op {
+	case OpBetween:
+		// BETWEEN fails on null fields, negated or not
+		return constraint{op: c.op, values: c.values, negated: !c.negated}
+	case OpIs:
+		return constraint{op: c.op, negated: !c.negated, nullable: !c.nullable}
+	default:
+		return constraint{op: complement[c.op], values: c.values, nullable: !c.nullable}
+	}
+}
+
+// matches reports if a field holding a value, nil for null, matches the
+// constraint, ordering comparisons and BETWEEN never match strings
+func (c constraint) matches(v *atom) bool {
+	if v == nil {
+		return c.nullable
+	}
+
+	switch c.op {
+	case OpIs:
+		return c.negated
+	case OpIn:
+		return containsAtom(c.values, *v)
+	case OpNE:
+		return !containsAtom(c.values, *v)
+	}
+	if v.num == nil {
+		return false
+	}
+
+	if c.op == OpBetween {
+		inside := v.num.Cmp(c.values[0].num) >= 0 && v.num.Cmp(c.values[1].num) <= 0
+		return inside != c.negated
+	}
+	cmp := v.num.Cmp(c.values[0].num)
+	result := map[Operator]bool{OpLT: cmp < 0, OpLE: cmp <= 0, OpGT: cmp > 0, OpGE: cmp >= 0}
+	return result[c.op]
+}
+
+// contradicts reports if the comparisons of a field in AND operands can never
+// be true together.
+//
+// A field compared with numbers may hold an exact number (int64, decimal), a
+// float64 or NaN, the walker compares floats using float64 arithmetic and NaN
+// is not comparable to numbers, each case must contradict.
+func contradicts(operands []*Node) bool {
+	fields := map[string][]constraint{}
+	for _, o := range operands {
+		if field, c, ok := fieldConstraint(o); ok {
+			fields[field] = append(fields[field], c)
+		}
+	}
+
+	for _, constraints := range fields {
+		if !fieldSatisfiable(constraints) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldSatisfiable reports if a field value may match all the constraints
+func fieldSatisfiable(constraints []constraint) bool {
+	if satisfiable(constraints) || nanSatisfiable(constraints) {
+		return true
+	}
+	rounded, ok := roundConstraints(constraints)
+	return !ok || satisfiable(rounded)
+}
+
+// fieldConstraint returns the constraint of a comparison of a field with
+// literals, or of its negation
+func fieldConstraint(n *Node) (string, constraint, bool) {
+	if n.Kind == KindUnaryExpr && n.Operator == OpNot && n.Right != nil {
+		field, c, ok := fieldConstraint(n.Right)
+		return field, c.negate(), ok
+	}
+	if n.Kind != KindBinaryExpr || n.Left == nil || n.Right == nil {
+		return "", constraint{}, false
+	}
+
+	field, other, op := n.Left, n.Right, n.Operator
+	if field.Kind != KindIdentifier {
+		// Literal on the left, e.g. "5 < a"
+		flipped := map[Operator]Operator{OpEQ: OpEQ, OpNE: OpNE, OpLT: OpGT, OpLE: OpGE, OpGT: OpLT, OpGE: OpLE}
+		var ok bool
+		if op, ok = flipped[op]; !ok {
+			return "", constraint{}, false
+		}
+		field, other = n.Right, n.Left
+	}
+	name, ok := field.Value.(string)
+	if field.Kind != KindIdentifier || !ok {
+		return "", constraint{}, false
+	}
+
+	if op == OpIs {
+		return name, constraint{op: OpIs, nullable: true}, other.Kind == KindNullLiteral
+	}
+
+	literals := []*Node{other}
+	if op == OpIn || op == OpBetween {
+		if other.Kind != KindArrayLiteral {
+			return "", constraint{}, false
+		}
+		literals = other.Children
+	}
+
+	values := make([]atom, 0, len(literals))
+	for _, literal := range literals {
+		a, ok := atomValue(literal)
+		if !ok {
+			return "", constraint{}, false
+		}
+		values = append(values, a)
+	}
+
+	switch op {
+	case OpEQ, OpIn:
+		return name, constraint{op: OpIn, values: values}, true
+	case OpNE:
+		return name, constraint{op: OpNE, values: values, nullable: true}, true
+	case OpLT, OpLE, OpGT, OpGE:
+		return name, constraint{op: op, values: values}, values[0].num != nil
+	case OpBetween:
+		return name, constraint{op: op, values: values}, len(values) == 2 && values[0].num != nil && values[1].num != nil
+	}
+	return "", constraint{}, false
+}
+
+// atomValue returns the value of a numeric literal, or of a string literal the
+// walker compares as a plain string.
+//
+// Strings holding dates, IP addresses or versions are not atoms, the walker
+// may compare them with dates, addresses or versions, and different strings
+// can match the same value, e.g. "v1.0.0" and "1.0.0".
+func atomValue(n *Node) (atom, bool) {
+	if r, ok := numberValue(n); ok {
+		return atom{num: r}, true
+	}
+
+	s, ok := n.Value.(string)
+	if n.Kind != KindStringLiteral || !ok || !plainString(s) {
+		return atom{}, false
+	}
+	return atom{str: s}, true
+}
+
+// plainString reports if the walker compares a string as a plain string, and
+// not as a date, an IP address or a version
+func plainString(s string) bool {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return false
+		}
+	}
+	if _, err := netip.ParseAddr(s); err == nil {
+		return false
+	}
+	if _, err := netip.ParsePrefix(s); err == nil {
+		return false
+	}
+	_, err := ParseVersion(s)
+	return err != nil
+}
+
+// satisfiable reports if an exact number, a string or null can match all the
+// constraints of a field
+func satisfiable(constraints []constraint) bool {
+	// Null matches when all the constraints match null
+	null := true
+	for _, c := range constraints {
+		null = null && c.nullable
+	}
+	if null {
+		return true
+	}
+
+	type bound struct {
+		value  *big.Rat
+		strict bool
+	}
+	var lower, upper *bound
+	tighten := func(b **bound, v *big.Rat, strict bool, sign int) {
+		if *b == nil {
+			*b = &bound{value: v, strict: strict}
+			return
+		}
+		cmp := v.Cmp((*b).value) * sign
+		if cmp > 0 || cmp == 0 && strict {
+			*b = &bound{value: v, strict: strict}
+		}
+	}
+
+	var set, excluded []atom
+	var outside [][]atom
+	hasSet := false
+	for _, c := range constraints {
+		switch c.op {
+		case OpIs:
+			if !c.negated {
+				return false
+			}
+		case OpIn:
+			if !hasSet {
+				set, hasSet = c.values, true
+				continue
+			}
+			var both []atom
+			for _, a := range set {
+				if containsAtom(c.values, a) {
+					both = append(both, a)
+				}
+			}
+			set = both
+		case OpNE:
+			excluded = append(excluded, c.values...)
+		case OpGT, OpGE:
+			tighten(&lower, c.values[0].num, c.op == OpGT, 1)
+		case OpLT, OpLE:
+			tighten(&upper, c.values[0].num, c.op == OpLT, -1)
+		case OpBetween:
+			if c.negated {
+				outside = append(outside, c.values)
+				continue
+			}
+			tighten(&lower, c.values[0].num, false, 1)
+			tighten(&upper, c.values[1].num, false, -1)
+		}
+	}
+
+	// Ranges and BETWEEN fail on strings
+	numeric := lower != nil || upper != nil || len(outside) > 0
+
+	within := func(a atom) bool {
+		if containsAtom(excluded, a) {
+			return false
+		}
+		if !numeric {
+			return true
+		}
+		if a.num == nil {
+			return false
+		}
+		if lower != nil {
+			if cmp := a.num.Cmp(lower.value); cmp < 0 || cmp == 0 && lower.strict {
+				return false
+			}
+		}
+		if upper != nil {
+			if cmp := a.num.Cmp(upper.value); cmp > 0 || cmp == 0 && upper.strict {
+				return false
+			}
+		}
+		for _, r := range outside {
+			if a.num.Cmp(r[0].num) >= 0 && a.num.Cmp(r[1].num) <= 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	if hasSet {
+		for _, a := range set {
+			if within(a) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if lower == nil || upper == nil {
+		return true
+	}
+
+	// A range holds infinitely many numbers, unless it is a single number, or
+	// it is within a NOT BETWEEN range
+	switch cmp := lower.value.Cmp(upper.value); {
+	case cmp > 0:
+		return false
+	case cmp == 0:
+		return within(atom{num: lower.value})
+	}
+	for _, r := range outside {
+		if lower.value.Cmp(r[0].num) >= 0 && upper.value.Cmp(r[1].num) <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// nanSatisfiable reports if a NaN float can match all the constraints of a
+// field. The walker does not compare NaN to numbers, comparisons are false and
+// their negations are true, as for null fields, but NaN is not null.
+func nanSatisfiable(constraints []constraint) bool {
+	for _, c := range constraints {
+		switch c.op {
+		case OpIs, OpBetween:
+			if !c.negated {
+				return false
+			}
+		default:
+			if !c.nullable {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// roundConstraints returns the constraints with numbers rounded to float64,
+// as compared with float fields, and false when a number overflows a float64
+func roundConstraints(constraints []constraint) ([]constraint, bool) {
+	rounded := make([]constraint, len(constraints))
+	for i, c := range constraints {
+		rounded[i] = c
+		rounded[i].values = make([]atom, len(c.values))
+		for j, a := range c.values {
+			if a.num == nil {
+				rounded[i].values[j] = a
+				continue
+			}
+			f, _ := a.num.Float64()
+			if math.IsInf(f, 0) {
+				return nil, false
+			}
+			rounded[i].values[j] = atom{num: new(big.Rat).SetFloat64(f)}
+		}
+	}
+	return rounded, true
+}
+
+// containsAtom reports if a value is in a list of atoms
+func containsAtom(atoms []atom, a atom) bool {
+	for _, o := range atoms {
+		if o.equal(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsNumber reports if a list of atoms holds a number
+func containsNumber(atoms []atom) bool {
+	for _, a := range atoms {
+		if a.num != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,9 @@
 package tsl
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // SyntaxError represents a TSL parsing error with context
 type SyntaxError struct {
@@ -85,3 +88,120 @@ type KeyNotFoundError struct {
 func (e KeyNotFoundError) Error() string {
 	return fmt.Sprintf("key not found: %s", e.Key)
 }
+
+// DeadlineExceededError is returned when an evaluation runs past its deadline
+type DeadlineExceededError struct{}
+
+func (e DeadlineExceededError) Error() string {
+	return "evaluation deadline exceeded"
+}
+
+// Unwrap returns context.DeadlineExceeded
+func (e DeadlineExceededError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// CanceledError is returned when an evaluation is canceled
+type CanceledError struct{}
+
+func (e CanceledError) Error() string {
+	return "evaluation canceled"
+}
+
+// Unwrap returns context.Canceled
+func (e CanceledError) Unwrap() error {
+	return context.Canceled
+}
+
+// BudgetExceededError is returned when an evaluation exceeds one of its budgets
+type BudgetExceededError struct {
+	Budget string
+	Limit  int
+}
+
+func (e BudgetExceededError) Error() string {
+	return fmt.Sprintf("evaluation budget exceeded: %s limit is %d", e.Budget, e.Limit)
+}
+
+// RecordError is returned when filtering a collection fails on one of its records
+type RecordError struct {
+	Index int
+	Err   error
+}
+
+func (e RecordError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the record evaluation
+func (e RecordError) Unwrap() error {
+	return e.Err
+}
+
+// ClauseLimitError is returned when a normal form conversion exceeds its clause limit
+type ClauseLimitError struct {
+	Form  string
+	Limit int
+}
+
+func (e ClauseLimitError) Error() string {
+	return fmt.Sprintf("%s conversion exceeds the clause limit of %d", e.Form, e.Limit)
+}
+
+// UnsupportedOperatorError is returned when an operator has no equivalent in an SQL dialect
+type UnsupportedOperatorError struct {
+	Operator Operator
+	Dialect  string
+}
+
+func (e UnsupportedOperatorError) Error() string {
+	return fmt.Sprintf("operator %v is not supported by the %s dialect", e.Operator, e.Dialect)
+}
+
+// UnmappedIdentifierError is returned when an identifier is not mapped to an SQL column
+type UnmappedIdentifierError struct {
+	Identifier string
+}
+
+func (e UnmappedIdentifierError) Error() string {
+	return fmt.Sprintf("identifier %q is not mapped to a column", e.Identifier)
+}
+
+// InvalidIdentifierError is returned when an identifier is not a valid SQL column name
+type InvalidIdentifierError struct {
+	Identifier string
+}
+
+func (e InvalidIdentifierError) Error() string {
+	return fmt.Sprintf("identifier %q is not a valid column name", e.Identifier)
+}
+
+// UnsupportedFeatureError is returned when an SQL dialect can not express a feature, e.g. JSON paths
+type UnsupportedFeatureError struct {
+	Feature string
+	Dialect string
+}
+
+func (e UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("%s is not supported by the %s dialect", e.Feature, e.Dialect)
+}
+
+// ArrayOperandError is returned when the operand of an array operator does not
+// use exactly one array identifier
+type ArrayOperandError struct {
+	Operator Operator
+	Arrays   []string
+}
+
+func (e ArrayOperandError) Error() string {
+	return fmt.Sprintf("operand of %v must use exactly one array identifier, got %v", e.Operator, e.Arrays)
+}
+
+// JoinRequiredError is returned when an SQL filter needs a JOIN clause the caller can not receive
+type JoinRequiredError struct {
+	Join string
+}
+
+func (e JoinRequiredError) Error() string {
+	return fmt.Sprintf("filter requires the join %q, use WalkWithJoins or NewFilter", e.Join)
+}
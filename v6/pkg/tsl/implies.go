@@ -0,0 +1,249 @@
+package tsl
+
+import (
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// Answer is the result of a check that may not be decided
+type Answer int
+
+const (
+	// AnswerUnknown is returned when the check can not be decided
+	AnswerUnknown Answer = iota
+	// AnswerYes is returned when the check holds for every record
+	AnswerYes
+	// AnswerNo is returned when a record is found where the check fails
+	AnswerNo
+)
+
+// String returns the name of an answer
+func (a Answer) String() string {
+	switch a {
+	case AnswerYes:
+		return "yes"
+	case AnswerNo:
+		return "no"
+	default:
+		return "unknown"
+	}
+}
+
+// Implies reports if every record matching the query a also matches the query
+// b, e.g. to check that a user filter is within the scope the user is allowed
+// to see, or that a cached result of b holds the results of a.
+//
+// Implies reasons about the intervals and sets of values of each field, using
+// comparisons of fields with numbers and strings, IN, BETWEEN and IS NULL.
+// The answer is AnswerYes when no record can match a and not b, AnswerNo when
+// the comparisons of each field can hold together on such a record, and
+// AnswerUnknown otherwise, e.g. for queries using LIKE or arithmetic on fields.
+//
+// AnswerYes means that on every record where a is true, and b evaluates to a
+// boolean using the semantics walker, b is true. Comparators registered for
+// the field types (see semantics.Register) are assumed to compare numbers and
+// strings like the built-in comparisons.
+//
+// Example:
+//
+//	a, _ := tsl.ParseTSL("status = 'open' and priority between 3 and 5")
+//	b, _ := tsl.ParseTSL("priority > 2")
+//	tsl.Implies(a, b) // AnswerYes
+//	tsl.Implies(b, a) // AnswerNo
+func Implies(a, b *TSLNode) Answer {
+	if a == nil || a.Node == nil || b == nil || b.Node == nil {
+		return AnswerUnknown
+	}
+
+	// a implies b when "a and not b" is never true
+	counter := &Node{
+		Kind:     KindBinaryExpr,
+		Operator: OpAnd,
+		Left:     a.Node,
+		Right:    &Node{Kind: KindUnaryExpr, Operator: OpNot, Right: b.Node},
+	}
+	dnf, err := ToDNF(&TSLNode{Node: counter})
+	if err != nil {
+		return AnswerUnknown
+	}
+	terms := flatten(OpOr, dnf.Node)
+	if !slices.ContainsFunc(terms, satisfiableTerm) {
+		return AnswerYes
+	}
+
+	// When a and b only compare fields with literals, they evaluate to
+	// booleans on records holding values the comparisons accept, and a record
+	// matches a and not b when it matches one of the terms
+	kinds, ok := comparedFields(fold(a.Node), fold(b.Node))
+	if !ok {
+		return AnswerUnknown
+	}
+	for _, term := range terms {
+		if kinds.witnessTerm(term) {
+			return AnswerNo
+		}
+	}
+	return AnswerUnknown
+}
+
+// satisfiableTerm reports if a DNF term may be true on a record
+func satisfiableTerm(term *Node) bool {
+	if v, ok := boolValue(term); ok {
+		return v
+	}
+
+	fields := map[string][]constraint{}
+	for _, o := range flatten(OpAnd, term) {
+		if field, c, ok := fieldConstraint(o); ok {
+			fields[field] = append(fields[field], c)
+		}
+	}
+	for _, constraints := range fields {
+		if !fieldSatisfiable(constraints) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equivalent reports if the queries a and b match the same records, AnswerYes
+// when both imply each other, AnswerNo when a record matches only one of them,
+// and AnswerUnknown otherwise (see Implies).
+func Equivalent(a, b *TSLNode) Answer {
+	ab, ba := Implies(a, b), Implies(b, a)
+	switch {
+	case ab == AnswerYes && ba == AnswerYes:
+		return AnswerYes
+	case ab == AnswerNo || ba == AnswerNo:
+		return AnswerNo
+	default:
+		return AnswerUnknown
+	}
+}
+
+// fieldKinds holds the fields compared in queries, and the values they can
+// hold without failing a comparison, ordering comparisons fail on strings and
+// BETWEEN also fails on nulls
+type fieldKinds struct {
+	numeric map[string]bool
+	notNull map[string]bool
+}
+
+// comparedFields returns the kinds of the fields of nodes made of AND, OR and
+// NOT of boolean literals and comparisons of fields with literals, ok is false
+// for other nodes
+func comparedFields(nodes ...*Node) (fieldKinds, bool) {
+	kinds := fieldKinds{numeric: map[string]bool{}, notNull: map[string]bool{}}
+	var collect func(n *Node) bool
+	collect = func(n *Node) bool {
+		if _, ok := boolValue(n); ok {
+			return true
+		}
+		switch {
+		case n.Kind == KindUnaryExpr && n.Operator == OpNot && n.Right != nil:
+			return collect(n.Right)
+		case n.Kind == KindBinaryExpr && (n.Operator == OpAnd || n.Operator == OpOr):
+			return n.Left != nil && n.Right != nil && collect(n.Left) && collect(n.Right)
+		}
+
+		field, c, ok := fieldConstraint(n)
+		switch c.op {
+		case OpLT, OpLE, OpGT, OpGE:
+			kinds.numeric[field] = true
+		case OpBetween:
+			kinds.numeric[field] = true
+			kinds.notNull[field] = true
+		}
+		return ok
+	}
+
+	for _, n := range nodes {
+		if !collect(n) {
+			return fieldKinds{}, false
+		}
+	}
+	return kinds, true
+}
+
+// witnessTerm reports if a record matches a DNF term, each field of the term
+// has a value matching its comparisons
+func (k fieldKinds) witnessTerm(term *Node) bool {
+	if v, ok := boolValue(term); ok {
+		return v
+	}
+
+	fields := map[string][]constraint{}
+	for _, o := range flatten(OpAnd, term) {
+		field, c, ok := fieldConstraint(o)
+		if !ok {
+			return false
+		}
+		fields[field] = append(fields[field], c)
+	}
+	for field, constraints := range fields {
+		if !k.witness(field, constraints) {
+			return false
+		}
+	}
+	return true
+}
+
+// witness reports if a value of a field matches all its constraints. The
+// values between two consecutive numbers of the constraints, or beyond them,
+// match the same constraints, and strings other than the string literals match
+// the same constraints, so trying null, the literals, the numbers next to them
+// and between them, and one other string is enough.
+func (k fieldKinds) witness(field string, constraints []constraint) bool {
+	var candidates []atom
+	var numbers []*big.Rat
+	fresh := "~"
+	for _, c := range constraints {
+		for _, v := range c.values {
+			if v.num != nil {
+				numbers = append(numbers, v.num)
+				continue
+			}
+			candidates = append(candidates, v)
+			if len(v.str) >= len(fresh) {
+				fresh = strings.Repeat("~", len(v.str)+1)
+			}
+		}
+	}
+	candidates = append(candidates, atom{str: fresh})
+	if k.numeric[field] {
+		candidates = nil
+	}
+
+	slices.SortFunc(numbers, func(x, y *big.Rat) int { return x.Cmp(y) })
+	numbers = slices.CompactFunc(numbers, func(x, y *big.Rat) bool { return x.Cmp(y) == 0 })
+	if len(numbers) == 0 {
+		numbers = []*big.Rat{new(big.Rat)}
+	}
+	one := big.NewRat(1, 1)
+	for i, r := range numbers {
+		candidates = append(candidates, atom{num: r}, atom{num: new(big.Rat).Sub(r, one)}, atom{num: new(big.Rat).Add(r, one)})
+		if i > 0 {
+			mid := new(big.Rat).Add(numbers[i-1], r)
+			candidates = append(candidates, atom{num: mid.Quo(mid, big.NewRat(2, 1))})
+		}
+	}
+
+	matchAll := func(v *atom) bool {
+		for _, c := range constraints {
+			if !c.matches(v) {
+				return false
+			}
+		}
+		return true
+	}
+	if !k.notNull[field] && matchAll(nil) {
+		return true
+	}
+	for i := range candidates {
+		if matchAll(&candidates[i]) {
+			return true
+		}
+	}
+	return false
+}
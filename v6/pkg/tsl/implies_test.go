@@ -0,0 +1,75 @@
+package tsl
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TSL Implies", func() {
+	DescribeTable("checks implication",
+		func(a, b string, expected Answer) {
+			Expect(Implies(mustParse(a), mustParse(b))).To(Equal(expected))
+		},
+		// Intervals
+		Entry("narrower range", "a > 5", "a > 3", AnswerYes),
+		Entry("wider range", "a > 3", "a > 5", AnswerNo),
+		Entry("flipped literal", "5 < a", "a >= 5", AnswerYes),
+		Entry("between inside a range", "a between 2 and 3", "a between 1 and 4", AnswerYes),
+		Entry("between outside a range", "a between 1 and 4", "a between 2 and 3", AnswerNo),
+		Entry("between and bounds", "a between 1 and 3", "a >= 1 and a <= 3", AnswerYes),
+		Entry("value in a range", "a = 2", "a < 3", AnswerYes),
+		Entry("value outside a range", "a = 3", "a < 3", AnswerNo),
+
+		// Sets
+		Entry("subset", "a in [1, 2]", "a in [1, 2, 3]", AnswerYes),
+		Entry("superset", "a in [1, 2, 3]", "a in [1, 2]", AnswerNo),
+		Entry("value in a set", "a = 'x'", "a in ['x', 'y']", AnswerYes),
+		Entry("excluded value", "a = 'x'", "a != 'y'", AnswerYes),
+		Entry("not excluded value", "a != 'y'", "a = 'x'", AnswerNo),
+		Entry("set in a range", "a in [2, 3]", "a between 1 and 3", AnswerYes),
+		Entry("between fails on strings", "a in ['x', 'y']", "a between 1 and 3", AnswerYes),
+
+		// Nulls
+		Entry("null is not in a range", "a is null", "a < 5", AnswerNo),
+		Entry("null is not a value", "a is null", "not (a > 1)", AnswerYes),
+		Entry("value is not null", "a = 1", "a is not null", AnswerYes),
+		Entry("not equal matches null", "a != 1", "a is not null", AnswerNo),
+		Entry("negated range matches NaN", "a is not null and a not between 1 and 3", "a < 1 or a > 3", AnswerUnknown),
+		Entry("negated bound matches NaN", "a is not null and not (a < 1)", "a >= 1", AnswerUnknown),
+		Entry("null fails both bounds", "a != 1", "a < 5 or a >= 5", AnswerNo),
+		Entry("between fails on null", "b = 1", "b = 1 and a between 1 and 3", AnswerNo),
+		Entry("ranges fail on strings", "a in ['x', 2]", "a < 5", AnswerYes),
+
+		// Several fields
+		Entry("conjunction", "a > 5 and b = 1", "a > 3", AnswerYes),
+		Entry("disjunction", "a > 5 or b = 1", "a > 3", AnswerNo),
+		Entry("both disjuncts", "a > 5 or a = 4", "a > 3", AnswerYes),
+		Entry("scope", "status = 'open' and priority between 3 and 5", "priority > 2", AnswerYes),
+		Entry("out of scope", "status = 'open'", "status = 'open' and owner = 'me'", AnswerNo),
+
+		// Outside of the comparison subset
+		Entry("same pattern", "a like 'x%'", "a like 'x%'", AnswerYes),
+		Entry("different patterns", "a like 'x%'", "a like 'xy%'", AnswerUnknown),
+		Entry("arithmetic", "a + 1 > 5", "a > 4", AnswerUnknown),
+		Entry("comparison of fields", "a = b", "a > 1", AnswerUnknown),
+		Entry("pattern in the other query", "a > 5 or a like 'x%'", "a > 3", AnswerUnknown),
+	)
+
+	DescribeTable("checks equivalence",
+		func(a, b string, expected Answer) {
+			Expect(Equivalent(mustParse(a), mustParse(b))).To(Equal(expected))
+		},
+		Entry("in and or", "a in [1, 2]", "a = 1 or a = 2", AnswerYes),
+		Entry("between and bounds", "a between 1 and 3", "a >= 1 and a <= 3", AnswerYes),
+		Entry("de morgan", "not (a = 1 or b = 2)", "a != 1 and b != 2", AnswerYes),
+		Entry("different sets", "a in [1, 2]", "a in [1, 3]", AnswerNo),
+		Entry("one direction", "a > 5", "a > 3", AnswerNo),
+		Entry("patterns", "a like 'x%'", "a like 'xy%'", AnswerUnknown),
+	)
+
+	It("names answers", func() {
+		Expect(AnswerYes.String()).To(Equal("yes"))
+		Expect(AnswerNo.String()).To(Equal("no"))
+		Expect(AnswerUnknown.String()).To(Equal("unknown"))
+	})
+})
@@ -15,6 +15,9 @@ const (
 	KindArrayLiteral     Kind = 7 // AST_ARRAY
 	KindBooleanLiteral   Kind = 8 // AST_BOOL
 	KindNullLiteral      Kind = 9 // AST_NULL
+	KindIPLiteral        Kind = 10
+	KindCIDRLiteral      Kind = 11
+	KindVersionLiteral   Kind = 12
 )
 
 // String returns the string representation of a NodeKind
@@ -28,6 +31,12 @@ func (kind Kind) String() string {
 		return "BOOLEAN"
 	case KindNullLiteral:
 		return "NULL"
+	case KindIPLiteral:
+		return "IP"
+	case KindCIDRLiteral:
+		return "CIDR"
+	case KindVersionLiteral:
+		return "VERSION"
 	case KindDateLiteral:
 		return "DATE"
 	case KindTimestampLiteral:
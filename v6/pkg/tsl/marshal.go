@@ -1,6 +1,10 @@
 package tsl
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
 
 // MarshalJSON implements json.Marshaler interface
 func (n *TSLNode) MarshalJSON() ([]byte, error) {
@@ -92,6 +96,14 @@ func (n *TSLNode) MarshalYAML() (interface{}, error) {
 	// For all other node types, use the default alias
 	return nodeAlias{
 		Type:  n.Type().String(),
-		Value: n.Value(),
+		Value: yamlValue(n.Value()),
 	}, nil
 }
+
+// yamlValue converts decimals into exact YAML float scalars
+func yamlValue(v interface{}) interface{} {
+	if d, ok := v.(Decimal); ok {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: d.String()}
+	}
+	return v
+}
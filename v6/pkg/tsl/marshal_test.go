@@ -14,6 +14,13 @@ func TestMarshal(t *testing.T) {
 	RunSpecs(t, "TSL Marshal Suite")
 }
 
+// mustParse parses a TSL expression, failing the spec on errors
+func mustParse(input string) *TSLNode {
+	tree, err := ParseTSL(input)
+	Expect(err).ToNot(HaveOccurred())
+	return tree
+}
+
 var _ = Describe("TSL Node Marshaling", func() {
 	DescribeTable("marshaling TSL nodes to JSON and YAML",
 		func(input string, expectedJSON string, expectedYAML string) {
@@ -45,5 +52,25 @@ var _ = Describe("TSL Node Marshaling", func() {
 			`{"type":"BINARY_EXP","operator":"IN","left":{"type":"IDENTIFIER","value":"tags"},"right":{"type":"ARRAY","values":[{"type":"STRING","value":"a"},{"type":"STRING","value":"b"}]}}`,
 			"type: BINARY_EXP\noperator: IN\nleft:\n    type: IDENTIFIER\n    value: tags\nright:\n    type: ARRAY\n    values:\n        - type: STRING\n          value: a\n        - type: STRING\n          value: b\n",
 		),
+		Entry("decimal literal",
+			"price = 0.1",
+			`{"type":"BINARY_EXP","operator":"EQ","left":{"type":"IDENTIFIER","value":"price"},"right":{"type":"NUMBER","value":0.1}}`,
+			"type: BINARY_EXP\noperator: EQ\nleft:\n    type: IDENTIFIER\n    value: price\nright:\n    type: NUMBER\n    value: 0.1\n",
+		),
+		Entry("ip within cidr",
+			"ip within 10.0.0.0/8",
+			`{"type":"BINARY_EXP","operator":"WITHIN","left":{"type":"IDENTIFIER","value":"ip"},"right":{"type":"CIDR","value":"10.0.0.0/8"}}`,
+			"type: BINARY_EXP\noperator: WITHIN\nleft:\n    type: IDENTIFIER\n    value: ip\nright:\n    type: CIDR\n    value: 10.0.0.0/8\n",
+		),
+		Entry("version literal",
+			"version >= v1.28.0",
+			`{"type":"BINARY_EXP","operator":"GE","left":{"type":"IDENTIFIER","value":"version"},"right":{"type":"VERSION","value":"v1.28.0"}}`,
+			"type: BINARY_EXP\noperator: GE\nleft:\n    type: IDENTIFIER\n    value: version\nright:\n    type: VERSION\n    value: v1.28.0\n",
+		),
+		Entry("large integer literal",
+			"id = 9007199254740993",
+			`{"type":"BINARY_EXP","operator":"EQ","left":{"type":"IDENTIFIER","value":"id"},"right":{"type":"NUMBER","value":9007199254740993}}`,
+			"type: BINARY_EXP\noperator: EQ\nleft:\n    type: IDENTIFIER\n    value: id\nright:\n    type: NUMBER\n    value: 9007199254740993\n",
+		),
 	)
 })
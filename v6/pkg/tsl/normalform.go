@@ -0,0 +1,199 @@
+package tsl
+
+import (
+	"slices"
+	"strings"
+)
+
+// DefaultMaxClauses is the default clause limit of normal form conversions
+const DefaultMaxClauses = 1024
+
+// NormalFormOption configures a normal form conversion
+type NormalFormOption func(*normalFormOptions)
+
+// normalFormOptions holds the conversion options
+type normalFormOptions struct {
+	maxClauses int
+}
+
+// WithMaxClauses limits the number of clauses of a normal form, including the
+// intermediate forms of its sub-expressions, zero means no limit.
+func WithMaxClauses(n int) NormalFormOption {
+	return func(o *normalFormOptions) {
+		o.maxClauses = n
+	}
+}
+
+// ToCNF converts a TSL tree to conjunctive normal form, an AND of clauses
+// where each clause is an OR of comparisons.
+//
+// The tree is simplified first (see Simplify), so NOT is pushed down to the
+// comparisons, e.g. "not (a not like 'x%' or b is not null)" becomes
+// "a like 'x%' and b is null". Comparisons that can not be negated, e.g.
+// "not (a < 1)", are kept as NOT of the comparison.
+//
+// Conversion may grow the tree exponentially, a ClauseLimitError is returned
+// when the number of clauses exceeds the limit, DefaultMaxClauses unless set
+// using WithMaxClauses.
+//
+// Example:
+//
+//	tree, _ := tsl.ParseTSL("a = 1 or (b = 2 and c = 3)")
+//	cnf, err := tsl.ToCNF(tree)
+//	// cnf is "(a = 1 or b = 2) and (a = 1 or c = 3)"
+func ToCNF(n *TSLNode, opts ...NormalFormOption) (*TSLNode, error) {
+	return normalForm(n, OpAnd, opts)
+}
+
+// ToDNF converts a TSL tree to disjunctive normal form, an OR of terms where
+// each term is an AND of comparisons, e.g. to search each term using a
+// separate index scan.
+//
+// NOT is pushed down, and the clause limit applies, like in ToCNF.
+//
+// Example:
+//
+//	tree, _ := tsl.ParseTSL("a = 1 and (b = 2 or c = 3)")
+//	dnf, err := tsl.ToDNF(tree)
+//	// dnf is "(a = 1 and b = 2) or (a = 1 and c = 3)"
+func ToDNF(n *TSLNode, opts ...NormalFormOption) (*TSLNode, error) {
+	return normalForm(n, OpOr, opts)
+}
+
+// normalForm converts a tree to an outer AND or OR of inner clauses
+func normalForm(n *TSLNode, outer Operator, opts []NormalFormOption) (*TSLNode, error) {
+	if n == nil || n.Node == nil {
+		return nil, nil
+	}
+
+	o := normalFormOptions{maxClauses: DefaultMaxClauses}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	simplified, _ := Simplify(n)
+	root := simplified.Node
+	if _, ok := boolValue(root); ok {
+		return simplified, nil
+	}
+
+	c := &converter{outer: outer, maxClauses: o.maxClauses}
+	clauses, err := c.clauses(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// No clauses is the identity of the outer operator, and an empty clause
+	// is the identity of the inner operator, that absorbs the outer operator
+	switch {
+	case len(clauses) == 0:
+		return &TSLNode{Node: boolNode(outer == OpAnd, root.Position)}, nil
+	case len(clauses[0]) == 0:
+		return &TSLNode{Node: boolNode(outer == OpOr, root.Position)}, nil
+	}
+
+	// Operands repeated by the distribution are cloned, so the tree has no
+	// shared nodes
+	nodes := make([]*Node, len(clauses))
+	for i, clause := range clauses {
+		operands := make([]*Node, len(clause))
+		for j, operand := range clause {
+			operands[j] = operand.Clone()
+		}
+		nodes[i] = chain(dual(outer), operands, root.Position)
+	}
+	return &TSLNode{Node: chain(outer, nodes, root.Position)}, nil
+}
+
+// converter holds the state of a normal form conversion
+type converter struct {
+	outer      Operator
+	maxClauses int
+}
+
+// clauses returns the clauses of a simplified node, each clause is a list of
+// operands of the inner operator
+func (c *converter) clauses(n *Node) ([][]*Node, error) {
+	if n.Kind != KindBinaryExpr || (n.Operator != OpAnd && n.Operator != OpOr) {
+		return [][]*Node{{n}}, nil
+	}
+
+	left, err := c.clauses(n.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.clauses(n.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	var clauses [][]*Node
+	if n.Operator == c.outer {
+		clauses = append(left, right...)
+	} else {
+		// Distribute the inner operator over the clauses of both operands
+		if err := c.check(len(left) * len(right)); err != nil {
+			return nil, err
+		}
+		for _, l := range left {
+			for _, r := range right {
+				clauses = append(clauses, append(slices.Clone(l), r...))
+			}
+		}
+	}
+
+	return c.reduce(clauses, n.Position)
+}
+
+// reduce drops duplicate clauses, duplicate operands of clauses, and clauses
+// that are always the identity of the outer operator, e.g. "x or not x" in a
+// CNF. A clause that absorbs the outer operator, e.g. a contradiction in a
+// DNF, is returned as the only, empty, clause.
+func (c *converter) reduce(clauses [][]*Node, pos int) ([][]*Node, error) {
+	inner := dual(c.outer)
+
+	var result [][]*Node
+	seen := map[string]bool{}
+	for _, clause := range clauses {
+		combined := combine(inner, clause, pos)
+		if v, ok := boolValue(combined); ok {
+			if v == (c.outer == OpAnd) {
+				continue
+			}
+			return [][]*Node{{}}, nil
+		}
+
+		operands := flatten(inner, combined)
+		keys := make([]string, len(operands))
+		for i, operand := range operands {
+			keys[i] = nodeKey(operand)
+		}
+		slices.Sort(keys)
+		if key := strings.Join(keys, ","); !seen[key] {
+			seen[key] = true
+			result = append(result, operands)
+		}
+	}
+	return result, c.check(len(result))
+}
+
+// check returns an error when a number of clauses exceeds the clause limit
+func (c *converter) check(clauses int) error {
+	if c.maxClauses > 0 && clauses > c.maxClauses {
+		form := "CNF"
+		if c.outer == OpOr {
+			form = "DNF"
+		}
+		return ClauseLimitError{Form: form, Limit: c.maxClauses}
+	}
+	return nil
+}
+
+// chain joins nodes using AND or OR
+func chain(op Operator, nodes []*Node, pos int) *Node {
+	n := nodes[0]
+	for _, o := range nodes[1:] {
+		n = &Node{Kind: KindBinaryExpr, Operator: op, Left: n, Right: o, Position: pos}
+	}
+	return n
+}
@@ -0,0 +1,90 @@
+package tsl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TSL Normal Forms", func() {
+	DescribeTable("converts to conjunctive normal form",
+		func(input string, expected string) {
+			cnf, err := ToCNF(mustParse(input))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(nodeKey(cnf.Node)).To(Equal(nodeKey(mustParse(expected).Node)))
+		},
+		Entry("comparison", "a = 1", "a = 1"),
+		Entry("distributes or", "a = 1 or (b = 2 and c = 3)", "(a = 1 or b = 2) and (a = 1 or c = 3)"),
+		Entry("distributes both sides", "(a = 1 and b = 2) or (c = 3 and d = 4)",
+			"(a = 1 or c = 3) and (a = 1 or d = 4) and (b = 2 or c = 3) and (b = 2 or d = 4)"),
+		Entry("keeps clauses", "(a = 1 or b = 2) and c = 3", "(a = 1 or b = 2) and c = 3"),
+		Entry("not like", "not (a not like 'x%' or b = 1)", "a like 'x%' and b != 1"),
+		Entry("not in", "not (a not in [1, 2] and b = 1)", "a in [1, 2] or b != 1"),
+		Entry("not between", "not (a not between 1 and 2) or b = 1", "a between 1 and 2 or b = 1"),
+		Entry("is not null", "not (a is not null and b is null)", "a is null or not (b is null)"),
+		Entry("not in a clause", "not (a in [1, 2]) or (b = 1 and c = 2)",
+			"(not (a in [1, 2]) or b = 1) and (not (a in [1, 2]) or c = 2)"),
+		Entry("drops always true clauses", "(a = 1 and b = 2) or a != 1", "b = 2 or a != 1"),
+		Entry("drops duplicate clauses", "(a = 1 and b = 2) or (a = 1 and b = 2)", "a = 1 and b = 2"),
+		Entry("always true", "(a ~= 'x' and b ~= 'y') or (a ~! 'x' or b ~! 'y')", "true"),
+		Entry("always true sub-expression", "c = 1 and ((a ~= 'x' and b ~= 'y') or (a ~! 'x' or b ~! 'y'))", "c = 1"),
+		Entry("constant", "a = 1 and 1 > 2", "false"),
+	)
+
+	DescribeTable("converts to disjunctive normal form",
+		func(input string, expected string) {
+			dnf, err := ToDNF(mustParse(input))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(nodeKey(dnf.Node)).To(Equal(nodeKey(mustParse(expected).Node)))
+		},
+		Entry("comparison", "a = 1", "a = 1"),
+		Entry("distributes and", "a = 1 and (b = 2 or c = 3)", "(a = 1 and b = 2) or (a = 1 and c = 3)"),
+		Entry("keeps terms", "(a = 1 and b = 2) or c = 3", "(a = 1 and b = 2) or c = 3"),
+		Entry("de morgan", "not (a = 1 or b not in [1, 2]) or c = 3", "(a != 1 and b in [1, 2]) or c = 3"),
+		Entry("is not null", "a is not null and (b is null or b > 5)",
+			"(not (a is null) and b is null) or (not (a is null) and b > 5)"),
+		Entry("drops contradictions", "a > 5 and (a < 3 or b = 1)", "a > 5 and b = 1"),
+		Entry("always false", "a = 'x' and (a = 'y' or a = 'z')", "false"),
+		Entry("always false sub-expression", "c = 1 or (a = 'x' and (a = 'y' or a = 'z'))", "c = 1"),
+	)
+
+	It("limits the number of clauses", func() {
+		// (a0 and b0) or (a1 and b1) or ... has 2^n CNF clauses and n DNF terms
+		terms := make([]string, 12)
+		for i := range terms {
+			terms[i] = fmt.Sprintf("(a%d = 1 and b%d = 1)", i, i)
+		}
+		tree := mustParse(strings.Join(terms, " or "))
+
+		_, err := ToCNF(tree)
+		Expect(err).To(Equal(ClauseLimitError{Form: "CNF", Limit: DefaultMaxClauses}))
+
+		_, err = ToCNF(tree, WithMaxClauses(100))
+		var limitErr ClauseLimitError
+		Expect(errors.As(err, &limitErr)).To(BeTrue())
+		Expect(limitErr.Limit).To(Equal(100))
+
+		cnf, err := ToCNF(tree, WithMaxClauses(0))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flatten(OpAnd, cnf.Node)).To(HaveLen(4096))
+
+		dnf, err := ToDNF(tree, WithMaxClauses(12))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flatten(OpOr, dnf.Node)).To(HaveLen(12))
+
+		_, err = ToDNF(tree, WithMaxClauses(11))
+		Expect(err).To(Equal(ClauseLimitError{Form: "DNF", Limit: 11}))
+	})
+
+	It("does not share nodes", func() {
+		cnf, err := ToCNF(mustParse("a = 1 or (b = 2 and c = 3)"))
+		Expect(err).NotTo(HaveOccurred())
+
+		clauses := flatten(OpAnd, cnf.Node)
+		Expect(clauses).To(HaveLen(2))
+		Expect(clauses[0].Left).NotTo(BeIdenticalTo(clauses[1].Left))
+	})
+})
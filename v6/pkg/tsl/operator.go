@@ -40,6 +40,16 @@ const (
 
 	// Unary Operators
 	OpUMinus Operator = 296 // UMINUS
+
+	// Network Operators
+	OpWithin Operator = 297 // K_WITHIN (IP address within CIDR)
+
+	// Aggregate Operators, COUNT with no operand is COUNT(*)
+	OpCount  Operator = 298 // K_COUNT
+	OpAvg    Operator = 299 // K_AVG
+	OpMin    Operator = 300 // K_MIN
+	OpMax    Operator = 301 // K_MAX
+	OpAggSum Operator = 302 // SUM in grouped queries
 )
 
 // String returns the string representation of an OperatorType
@@ -87,6 +97,10 @@ func (op Operator) String() string {
 	case OpIs:
 		return "IS"
 
+	// Network Operators
+	case OpWithin:
+		return "WITHIN"
+
 	// Arithmetic Operators
 	case OpPlus:
 		return "ADD"
@@ -111,6 +125,18 @@ func (op Operator) String() string {
 	case OpSum:
 		return "SUM"
 
+	// Aggregate Operators
+	case OpCount:
+		return "COUNT"
+	case OpAvg:
+		return "AVG"
+	case OpMin:
+		return "MIN"
+	case OpMax:
+		return "MAX"
+	case OpAggSum:
+		return "AGG_SUM"
+
 	default:
 		return "UNKNOWN"
 	}
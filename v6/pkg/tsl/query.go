@@ -0,0 +1,208 @@
+package tsl
+
+import (
+	"fmt"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/parser"
+)
+
+// Query is a TSL query, a filter expression with projection, grouping, sorting
+// and paging clauses.
+//
+// Example:
+//
+//	status = 'open' ORDER BY priority DESC, created LIMIT 20 OFFSET 40
+//	SELECT title, pages * 2 AS double_pages WHERE author = 'Joe' ORDER BY title
+//	SELECT author, COUNT(*) AS books GROUP BY author HAVING AVG(pages) > 100
+type Query struct {
+	Select  []SelectItem // nil when the query has no SELECT clause
+	Filter  *TSLNode     // nil when the query has no filter
+	GroupBy []*TSLNode   // grouping keys, nil when the query has no GROUP BY clause
+	Having  *TSLNode     // nil when the query has no HAVING clause
+	OrderBy []SortKey    // sort keys, in order of precedence
+	Limit   *int         // nil when the query has no LIMIT clause
+	Offset  int
+}
+
+// Grouped returns true if the query groups records, it has a GROUP BY or HAVING
+// clause, or uses aggregate functions
+func (q *Query) Grouped() bool {
+	if q.GroupBy != nil || q.Having != nil {
+		return true
+	}
+	for _, item := range q.Select {
+		if HasAggregate(item.Expr) {
+			return true
+		}
+	}
+	for _, key := range q.OrderBy {
+		if HasAggregate(key.Expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAggregate returns true for aggregate function operators
+func IsAggregate(op Operator) bool {
+	switch op {
+	case OpCount, OpAvg, OpMin, OpMax, OpAggSum:
+		return true
+	}
+	return false
+}
+
+// HasAggregate returns true if an expression uses an aggregate function
+func HasAggregate(n *TSLNode) bool {
+	if n == nil {
+		return false
+	}
+	return hasAggregate(n.Node)
+}
+
+// hasAggregate returns true if a node or one of its children is an aggregate
+func hasAggregate(node *Node) bool {
+	if node == nil {
+		return false
+	}
+	if node.Kind == KindUnaryExpr && IsAggregate(node.Operator) {
+		return true
+	}
+	if hasAggregate(node.Left) || hasAggregate(node.Right) {
+		return true
+	}
+	for _, child := range node.Children {
+		if hasAggregate(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupSums replaces the SUM operators of grouped query clauses with the SUM
+// aggregate, SUM inside another aggregate keeps summing record arrays
+func (q *Query) groupSums() {
+	exprs := []*TSLNode{q.Having}
+	for _, item := range q.Select {
+		exprs = append(exprs, item.Expr)
+	}
+	for _, key := range q.OrderBy {
+		exprs = append(exprs, key.Expr)
+	}
+	for _, expr := range exprs {
+		if expr != nil {
+			groupSum(expr.Node)
+		}
+	}
+}
+
+// groupSum replaces SUM operators outside aggregates with the SUM aggregate
+func groupSum(node *Node) {
+	if node == nil {
+		return
+	}
+	if node.Kind == KindUnaryExpr {
+		if node.Operator == OpSum {
+			node.Operator = OpAggSum
+			return
+		}
+		if IsAggregate(node.Operator) {
+			return
+		}
+	}
+
+	groupSum(node.Left)
+	groupSum(node.Right)
+	for _, child := range node.Children {
+		groupSum(child)
+	}
+}
+
+// SelectItem is one SELECT projection item, an expression with an optional
+// alias, or * for all fields
+type SelectItem struct {
+	Expr  *TSLNode
+	Alias string
+	Star  bool
+}
+
+// Name returns the output name of the item at a position of the SELECT clause,
+// the alias, the identifier name, or "columnN" for other unnamed expressions
+func (item SelectItem) Name(position int) string {
+	switch {
+	case item.Alias != "":
+		return item.Alias
+	case item.Expr.Type() == KindIdentifier:
+		return item.Expr.Value().(string)
+	default:
+		return fmt.Sprintf("column%d", position+1)
+	}
+}
+
+// SortKey is one ORDER BY key, an identifier or an expression
+type SortKey struct {
+	Expr *TSLNode
+	Desc bool
+}
+
+// ParseQuery parses a TSL query, an optional SELECT projection, a filter
+// expression and optional ORDER BY, LIMIT and OFFSET clauses. With a SELECT
+// clause the filter follows the WHERE keyword, and may be followed by GROUP BY
+// and HAVING clauses.
+//
+// The clause keywords (SELECT, AS, WHERE, GROUP, HAVING, ORDER, BY, ASC, DESC,
+// LIMIT, OFFSET) are reserved in queries, ParseTSL still accepts them as
+// identifiers. COUNT, AVG, MIN and MAX are aggregate functions when followed by
+// a parenthesis. In a grouped query SUM outside another aggregate is the SUM
+// aggregate (OpAggSum), elsewhere it sums the elements of an array.
+func ParseQuery(input string) (*Query, error) {
+	parserQuery, err := parser.ParseQuery(input)
+	if err != nil {
+		// Return a TSL-specific error with position information
+		if parseErr, ok := err.(*parser.ParseError); ok {
+			return nil, &SyntaxError{
+				Message:  parseErr.Message,
+				Position: parseErr.Position,
+				Context:  "",
+				Input:    input,
+			}
+		}
+		return nil, err
+	}
+
+	query := &Query{
+		Filter: wrapTSLNode(parserQuery.Filter),
+		Having: wrapTSLNode(parserQuery.Having),
+		Limit:  parserQuery.Limit,
+		Offset: parserQuery.Offset,
+	}
+	for _, item := range parserQuery.Select {
+		query.Select = append(query.Select, SelectItem{
+			Expr:  wrapTSLNode(item.Expr),
+			Alias: item.Alias,
+			Star:  item.Star,
+		})
+	}
+	for _, key := range parserQuery.GroupBy {
+		query.GroupBy = append(query.GroupBy, wrapTSLNode(key))
+	}
+	for _, key := range parserQuery.OrderBy {
+		query.OrderBy = append(query.OrderBy, SortKey{
+			Expr: wrapTSLNode(key.Expr),
+			Desc: key.Desc,
+		})
+	}
+	if query.Grouped() {
+		query.groupSums()
+	}
+
+	return query, nil
+}
+
+// wrapTSLNode creates a TSLNode from a parser node, nil stays nil
+func wrapTSLNode(parserNode *parser.Node) *TSLNode {
+	if parserNode == nil {
+		return nil
+	}
+	return &TSLNode{Node: wrapParserNode(parserNode)}
+}
@@ -0,0 +1,233 @@
+package tsl
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TSL Query Parsing", func() {
+	// sortKey describes an expected sort key as its expression text and direction
+	type sortKey struct {
+		expr string
+		desc bool
+	}
+
+	limit := func(n int) *int { return &n }
+
+	DescribeTable("parses query clauses",
+		func(input string, filter string, orderBy []sortKey, expectedLimit *int, offset int) {
+			query, err := ParseQuery(input)
+			Expect(err).NotTo(HaveOccurred())
+
+			if filter == "" {
+				Expect(query.Filter).To(BeNil())
+			} else {
+				Expect(query.Filter).To(Equal(mustParse(filter)))
+			}
+
+			Expect(query.OrderBy).To(HaveLen(len(orderBy)))
+			for i, key := range orderBy {
+				Expect(query.OrderBy[i].Expr).To(Equal(mustParse(key.expr)))
+				Expect(query.OrderBy[i].Desc).To(Equal(key.desc))
+			}
+			Expect(query.Limit).To(Equal(expectedLimit))
+			Expect(query.Offset).To(Equal(offset))
+		},
+		Entry("filter only",
+			"status = 'open'",
+			"status = 'open'", nil, nil, 0),
+		Entry("order by keys",
+			"status = 'open' ORDER BY priority DESC, created LIMIT 20",
+			"status = 'open'",
+			[]sortKey{{"priority", true}, {"created", false}},
+			limit(20), 0),
+		Entry("ascending key",
+			"status = 'open' order by created asc",
+			"status = 'open'",
+			[]sortKey{{"created", false}}, nil, 0),
+		Entry("expression key",
+			"order by len tags desc",
+			"",
+			[]sortKey{{"len tags", true}}, nil, 0),
+		Entry("limit and offset",
+			"age > 20 LIMIT 10 OFFSET 30",
+			"age > 20", nil, limit(10), 30),
+		Entry("limit zero",
+			"LIMIT 0",
+			"", nil, limit(0), 0),
+		Entry("offset only",
+			"ORDER BY name OFFSET 5",
+			"", []sortKey{{"name", false}}, nil, 5),
+		Entry("empty query",
+			"",
+			"", nil, nil, 0),
+	)
+
+	DescribeTable("parses select items",
+		func(input string, filter string, names []string, expressions []string) {
+			query, err := ParseQuery(input)
+			Expect(err).NotTo(HaveOccurred())
+
+			if filter == "" {
+				Expect(query.Filter).To(BeNil())
+			} else {
+				Expect(query.Filter).To(Equal(mustParse(filter)))
+			}
+
+			Expect(query.Select).To(HaveLen(len(names)))
+			for i, item := range query.Select {
+				Expect(item.Name(i)).To(Equal(names[i]))
+				if expressions[i] == "*" {
+					Expect(item.Star).To(BeTrue())
+				} else {
+					Expect(item.Expr).To(Equal(mustParse(expressions[i])))
+				}
+			}
+		},
+		Entry("computed fields",
+			"SELECT title, pages * 2 AS double_pages, LEN tags AS tag_count WHERE pages > 100",
+			"pages > 100",
+			[]string{"title", "double_pages", "tag_count"},
+			[]string{"title", "pages * 2", "len tags"}),
+		Entry("unnamed expression",
+			"SELECT id, price + tax",
+			"",
+			[]string{"id", "column2"},
+			[]string{"id", "price + tax"}),
+		Entry("star and alias",
+			"select *, name as title where a = 1 order by title limit 3",
+			"a = 1",
+			[]string{"column1", "title"},
+			[]string{"*", "name"}),
+	)
+
+	// operator returns the operator of an expression node
+	operator := func(n *TSLNode) Operator {
+		return n.Value().(TSLExpressionOp).Operator
+	}
+
+	It("parses grouped queries", func() {
+		query, err := ParseQuery("SELECT author, COUNT(*) AS books, sum(pages) WHERE year > 2000 GROUP BY author HAVING count(*) > 1 ORDER BY books DESC")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(query.Grouped()).To(BeTrue())
+		Expect(query.Filter).To(Equal(mustParse("year > 2000")))
+		Expect(query.GroupBy).To(Equal([]*TSLNode{mustParse("author")}))
+
+		Expect(operator(query.Select[1].Expr)).To(Equal(OpCount))
+		Expect(query.Select[1].Expr.Value().(TSLExpressionOp).Right).To(BeNil())
+		Expect(operator(query.Select[2].Expr)).To(Equal(OpAggSum))
+		Expect(query.Select[2].Expr.Value().(TSLExpressionOp).Right).To(Equal(mustParse("pages")))
+
+		Expect(operator(query.Having)).To(Equal(OpGT))
+		Expect(operator(query.Having.Value().(TSLExpressionOp).Left)).To(Equal(OpCount))
+		Expect(query.OrderBy[0].Expr).To(Equal(mustParse("books")))
+	})
+
+	DescribeTable("converts SUM to the SUM aggregate in grouped queries",
+		func(input string, grouped bool, outer Operator, inner Operator) {
+			query, err := ParseQuery(input)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(query.Grouped()).To(Equal(grouped))
+
+			expr := query.Select[0].Expr
+			Expect(operator(expr)).To(Equal(outer))
+			if inner != 0 {
+				Expect(operator(expr.Value().(TSLExpressionOp).Right)).To(Equal(inner))
+			}
+		},
+		Entry("not grouped", "SELECT SUM scores WHERE SUM scores > 10", false, OpSum, Operator(0)),
+		Entry("grouped", "SELECT SUM(scores) GROUP BY team", true, OpAggSum, Operator(0)),
+		Entry("grouped by aggregate", "SELECT SUM(score), MAX(score)", true, OpAggSum, Operator(0)),
+		Entry("inside aggregate", "SELECT MAX(SUM scores)", true, OpMax, OpSum),
+	)
+
+	It("keeps aggregate function names as identifiers", func() {
+		query, err := ParseQuery("SELECT count, max AS top WHERE count > min ORDER BY avg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(query.Grouped()).To(BeFalse())
+		Expect(query.Select[0].Expr).To(Equal(mustParse("count")))
+		Expect(query.Filter).To(Equal(mustParse("count > min")))
+
+		// Aggregate functions are only recognized in queries
+		_, err = ParseTSL("count(a) > 1")
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("rejects invalid queries",
+		func(input string, position int) {
+			_, err := ParseQuery(input)
+			Expect(err).To(HaveOccurred())
+
+			syntaxErr, ok := err.(*SyntaxError)
+			Expect(ok).To(BeTrue())
+			Expect(syntaxErr.Position).To(Equal(position))
+		},
+		Entry("fractional limit", "a = 1 LIMIT 1.5", 12),
+		Entry("size suffix offset", "a = 1 LIMIT 1 OFFSET 2Ki", 21),
+		Entry("missing by", "a = 1 ORDER a", 12),
+		Entry("limit before order", "a = 1 LIMIT 2 ORDER BY a", 14),
+		Entry("filter without where", "SELECT a b = 1", 9),
+		Entry("missing alias", "SELECT a AS WHERE b = 1", 12),
+		Entry("aggregate in where", "SELECT a WHERE COUNT(*) > 1", 15),
+		Entry("aggregate in filter", "MAX(a) > 1 ORDER BY a", 0),
+		Entry("having before group", "SELECT a HAVING a > 1 GROUP BY a", 22),
+		Entry("star outside count", "SELECT MAX(*)", 11),
+	)
+
+	It("keeps clause keywords as identifiers in filter expressions", func() {
+		tree, err := ParseTSL("order = 1 and desc = 'x' and limit > offset")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tree.Type()).To(Equal(KindBinaryExpr))
+
+		identifiers := []string{}
+		var collect func(n *Node)
+		collect = func(n *Node) {
+			if n == nil {
+				return
+			}
+			if n.Kind == KindIdentifier {
+				identifiers = append(identifiers, n.Value.(string))
+			}
+			collect(n.Left)
+			collect(n.Right)
+		}
+		collect(tree.Node)
+		Expect(identifiers).To(Equal([]string{"order", "desc", "limit", "offset"}))
+
+		_, err = ParseTSL("a = 1 ORDER BY a")
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("keeps network keywords as identifiers outside network operators",
+		func(input string, expectedJSON string) {
+			tree, err := ParseTSL(input)
+			Expect(err).NotTo(HaveOccurred())
+			data, err := tree.MarshalJSON()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(MatchJSON(expectedJSON))
+		},
+		Entry("cidr column", "cidr = '10.0.0.0/8'",
+			`{"type":"BINARY_EXP","operator":"EQ","left":{"type":"IDENTIFIER","value":"cidr"},"right":{"type":"STRING","value":"10.0.0.0/8"}}`),
+		Entry("within column", "within = 1",
+			`{"type":"BINARY_EXP","operator":"EQ","left":{"type":"IDENTIFIER","value":"within"},"right":{"type":"NUMBER","value":1}}`),
+		Entry("negated within column", "not within",
+			`{"type":"UNARY_EXP","operator":"NOT","right":{"type":"IDENTIFIER","value":"within"}}`),
+		Entry("cidr array", "ip in cidr",
+			`{"type":"BINARY_EXP","operator":"IN","left":{"type":"IDENTIFIER","value":"ip"},"right":{"type":"IDENTIFIER","value":"cidr"}}`),
+		Entry("cidr array before and", "ip in cidr and within",
+			`{"type":"BINARY_EXP","operator":"AND","left":{"type":"BINARY_EXP","operator":"IN","left":{"type":"IDENTIFIER","value":"ip"},"right":{"type":"IDENTIFIER","value":"cidr"}},"right":{"type":"IDENTIFIER","value":"within"}}`),
+		Entry("within a cidr column", "within within cidr",
+			`{"type":"BINARY_EXP","operator":"WITHIN","left":{"type":"IDENTIFIER","value":"within"},"right":{"type":"IDENTIFIER","value":"cidr"}}`),
+		Entry("in cidr a cidr column", "ip not in cidr cidr",
+			`{"type":"UNARY_EXP","operator":"NOT","right":{"type":"BINARY_EXP","operator":"WITHIN","left":{"type":"IDENTIFIER","value":"ip"},"right":{"type":"IDENTIFIER","value":"cidr"}}}`),
+	)
+
+	It("keeps network keywords as identifiers in queries", func() {
+		query, err := ParseQuery("SELECT cidr, within WHERE within > 1 ORDER BY cidr")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(query.Select[0].Expr).To(Equal(mustParse("cidr")))
+		Expect(query.Select[1].Expr).To(Equal(mustParse("within")))
+		Expect(query.Filter).To(Equal(mustParse("within > 1")))
+		Expect(query.OrderBy[0].Expr).To(Equal(mustParse("cidr")))
+	})
+})
@@ -0,0 +1,345 @@
+package tsl
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ConstantExpr is a sub-expression that is always true or always false
+type ConstantExpr struct {
+	// Node is the sub-expression in the original tree
+	Node *TSLNode
+
+	// Value is the value of the sub-expression
+	Value bool
+}
+
+// Simplify returns a simplified copy of a TSL tree, and the sub-expressions of
+// the tree found to be always true or always false.
+//
+// Simplify flattens nested AND and OR expressions, folds constant arithmetic
+// and comparisons (e.g. "1 + 2 > x" to "3 > x"), pushes NOT inward using De
+// Morgan's laws, removes duplicate operands, and detects contradictions such
+// as "x and not x", "a = 'x' and a = 'y'" or "a > 5 and a < 3".
+//
+// Whenever the original tree evaluates to a boolean using the semantics
+// walker, the simplified tree evaluates to the same boolean. Errors of removed
+// sub-expressions, e.g. a missing field in "x and false", are not reported by
+// the simplified tree. Comparators registered for the field types (see
+// semantics.Register) are assumed to compare numbers and strings like the
+// built-in comparisons.
+//
+// Example:
+//
+//	tree, _ := tsl.ParseTSL("not (a != 1 or b > 2 + 3) and a = 1")
+//	simplified, constants := tsl.Simplify(tree)
+//	// simplified is "a = 1 and not (b > 5)", constants is empty
+func Simplify(n *TSLNode) (*TSLNode, []ConstantExpr) {
+	if n == nil || n.Node == nil {
+		return nil, nil
+	}
+
+	s := &simplifier{}
+	return &TSLNode{Node: s.logical(n.Node)}, s.constants
+}
+
+// simplifier holds the constant sub-expressions found while simplifying a tree
+type simplifier struct {
+	constants []ConstantExpr
+}
+
+// logical simplifies a node evaluated as a boolean, e.g. the root of the tree
+// or an operand of AND, OR and NOT
+func (s *simplifier) logical(n *Node) *Node {
+	mark := len(s.constants)
+
+	var result *Node
+	switch {
+	case n.Kind == KindUnaryExpr && n.Operator == OpNot && n.Right != nil:
+		result = negate(s.logical(n.Right))
+	case n.Kind == KindBinaryExpr && (n.Operator == OpAnd || n.Operator == OpOr):
+		result = combine(n.Operator, []*Node{s.logical(n.Left), s.logical(n.Right)}, n.Position)
+	default:
+		result = fold(n)
+	}
+
+	// Only the outermost constant sub-expression is reported
+	if v, ok := boolValue(result); ok {
+		if _, literal := boolValue(n); !literal {
+			s.constants = append(s.constants[:mark], ConstantExpr{Node: &TSLNode{Node: n}, Value: v})
+		}
+	}
+	return result
+}
+
+// negate returns the negation of a simplified node, NOT is pushed inward to
+// the comparisons
+func negate(n *Node) *Node {
+	if v, ok := boolValue(n); ok {
+		return boolNode(!v, n.Position)
+	}
+
+	switch n.Kind {
+	case KindUnaryExpr:
+		if n.Operator == OpNot {
+			return n.Right
+		}
+	case KindBinaryExpr:
+		switch n.Operator {
+		case OpAnd, OpOr:
+			operands := flatten(n.Operator, n)
+			for i, operand := range operands {
+				operands[i] = negate(operand)
+			}
+			return combine(dual(n.Operator), operands, n.Position)
+		case OpEQ, OpNE, OpREQ, OpRNE:
+			return negateComparison(n)
+		}
+	}
+	return &Node{Kind: KindUnaryExpr, Operator: OpNot, Right: n, Position: n.Position}
+}
+
+// negateComparison returns the complement of an equality or regular expression
+// comparison, the walker evaluates != and ~! as the negation of = and ~=
+func negateComparison(n *Node) *Node {
+	complement := map[Operator]Operator{OpEQ: OpNE, OpNE: OpEQ, OpREQ: OpRNE, OpRNE: OpREQ}
+	return &Node{Kind: KindBinaryExpr, Operator: complement[n.Operator], Left: n.Left, Right: n.Right, Position: n.Position}
+}
+
+// dual returns OR for AND, and AND for OR
+func dual(op Operator) Operator {
+	if op == OpAnd {
+		return OpOr
+	}
+	return OpAnd
+}
+
+// flatten returns the operands of nested op expressions
+func flatten(op Operator, n *Node) []*Node {
+	if n.Kind == KindBinaryExpr && n.Operator == op {
+		return append(flatten(op, n.Left), flatten(op, n.Right)...)
+	}
+	return []*Node{n}
+}
+
+// combine joins simplified operands using AND or OR, dropping duplicates and
+// identity values, and returns a boolean literal when the result is constant
+func combine(op Operator, operands []*Node, pos int) *Node {
+	// false absorbs AND expressions, and true absorbs OR expressions
+	absorbing := op == OpOr
+
+	var result []*Node
+	seen := map[string]bool{}
+	for _, operand := range operands {
+		for _, o := range flatten(op, operand) {
+			if v, ok := boolValue(o); ok {
+				if v == absorbing {
+					return boolNode(absorbing, pos)
+				}
+				continue
+			}
+
+			key := nodeKey(o)
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, o)
+			}
+		}
+	}
+
+	// "x and not x" is false, and "x or not x" is true
+	for _, o := range result {
+		if o.Kind == KindBinaryExpr && (o.Operator == OpAnd || o.Operator == OpOr) {
+			continue
+		}
+		if seen[nodeKey(negate(o))] {
+			return boolNode(absorbing, pos)
+		}
+	}
+
+	if op == OpAnd && contradicts(result) {
+		return boolNode(false, pos)
+	}
+
+	if len(result) == 0 {
+		return boolNode(!absorbing, pos)
+	}
+	return chain(op, result, pos)
+}
+
+// fold returns a copy of a node with its constant sub-expressions evaluated
+func fold(n *Node) *Node {
+	if n == nil {
+		return nil
+	}
+
+	folded := &Node{
+		Kind:     n.Kind,
+		Value:    n.Value,
+		Operator: n.Operator,
+		Position: n.Position,
+		Left:     fold(n.Left),
+		Right:    fold(n.Right),
+	}
+	if n.Children != nil {
+		folded.Children = make([]*Node, len(n.Children))
+		for i, child := range n.Children {
+			folded.Children[i] = fold(child)
+		}
+	}
+
+	switch n.Kind {
+	case KindUnaryExpr:
+		switch n.Operator {
+		case OpNot:
+			if v, ok := boolValue(folded.Right); ok {
+				return boolNode(!v, n.Position)
+			}
+		case OpUMinus:
+			if r, ok := numberValue(folded.Right); ok {
+				if literal, ok := numberNode(r.Neg(r), n.Position); ok {
+					return literal
+				}
+			}
+		}
+	case KindBinaryExpr:
+		if literal, ok := foldBinary(folded); ok {
+			return literal
+		}
+	}
+	return folded
+}
+
+// foldBinary evaluates a binary expression of literals, using the walker's
+// semantics for numbers and booleans
+func foldBinary(n *Node) (*Node, bool) {
+	if l, ok := boolValue(n.Left); ok {
+		if r, ok := boolValue(n.Right); ok {
+			switch n.Operator {
+			case OpAnd:
+				return boolNode(l && r, n.Position), true
+			case OpOr:
+				return boolNode(l || r, n.Position), true
+			case OpEQ:
+				return boolNode(l == r, n.Position), true
+			case OpNE:
+				return boolNode(l != r, n.Position), true
+			}
+		}
+		return nil, false
+	}
+
+	l, ok := numberValue(n.Left)
+	if !ok {
+		return nil, false
+	}
+	r, ok := numberValue(n.Right)
+	if !ok {
+		return nil, false
+	}
+
+	switch n.Operator {
+	case OpEQ, OpNE, OpLT, OpLE, OpGT, OpGE:
+		cmp := l.Cmp(r)
+		result := map[Operator]bool{
+			OpEQ: cmp == 0, OpNE: cmp != 0, OpLT: cmp < 0, OpLE: cmp <= 0, OpGT: cmp > 0, OpGE: cmp >= 0,
+		}
+		return boolNode(result[n.Operator], n.Position), true
+	case OpPlus:
+		return numberNode(l.Add(l, r), n.Position)
+	case OpMinus:
+		return numberNode(l.Sub(l, r), n.Position)
+	case OpStar:
+		return numberNode(l.Mul(l, r), n.Position)
+	case OpSlash:
+		// Division by zero is left to the walker, that reports it
+		if r.Sign() == 0 {
+			return nil, false
+		}
+		return numberNode(l.Quo(l, r), n.Position)
+	case OpPercent:
+		// Modulus works on the integer parts of the operands
+		li := new(big.Int).Quo(l.Num(), l.Denom())
+		ri := new(big.Int).Quo(r.Num(), r.Denom())
+		if ri.Sign() == 0 {
+			return nil, false
+		}
+		return numberNode(new(big.Rat).SetInt(li.Rem(li, ri)), n.Position)
+	}
+	return nil, false
+}
+
+// boolValue returns the value of a boolean literal
+func boolValue(n *Node) (bool, bool) {
+	if n == nil || n.Kind != KindBooleanLiteral {
+		return false, false
+	}
+	v, ok := n.Value.(bool)
+	return v, ok
+}
+
+// boolNode returns a boolean literal
+func boolNode(v bool, pos int) *Node {
+	return &Node{Kind: KindBooleanLiteral, Value: v, Position: pos}
+}
+
+// numberValue returns the value of a numeric literal
+func numberValue(n *Node) (*big.Rat, bool) {
+	if n == nil || n.Kind != KindNumericLiteral {
+		return nil, false
+	}
+	switch v := n.Value.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case Decimal:
+		return v.Rat(), true
+	default:
+		return nil, false
+	}
+}
+
+// numberNode returns a numeric literal holding the value the walker computes
+// for a number, integers are int64 and other numbers are decimals. Integers
+// the walker holds as uint64 are not literals, and return false.
+func numberNode(r *big.Rat, pos int) (*Node, bool) {
+	var v interface{} = NewDecimal(r)
+	if r.IsInt() {
+		switch {
+		case r.Num().IsInt64():
+			v = r.Num().Int64()
+		case r.Num().IsUint64():
+			return nil, false
+		}
+	}
+	return &Node{Kind: KindNumericLiteral, Value: v, Position: pos}, true
+}
+
+// nodeKey returns a string identifying the content of a node, ignoring
+// positions, nodes with equal keys evaluate to the same value
+func nodeKey(n *Node) string {
+	var b strings.Builder
+	writeKey(&b, n)
+	return b.String()
+}
+
+// writeKey writes the key of a node
+func writeKey(b *strings.Builder, n *Node) {
+	if n == nil {
+		b.WriteString("nil")
+		return
+	}
+
+	switch n.Kind {
+	case KindBinaryExpr, KindUnaryExpr:
+		fmt.Fprintf(b, "(%d %d ", n.Kind, n.Operator)
+	default:
+		fmt.Fprintf(b, "(%d %T %q ", n.Kind, n.Value, fmt.Sprint(n.Value))
+	}
+	writeKey(b, n.Left)
+	writeKey(b, n.Right)
+	for _, child := range n.Children {
+		writeKey(b, child)
+	}
+	b.WriteString(")")
+}
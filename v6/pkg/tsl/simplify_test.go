@@ -0,0 +1,118 @@
+package tsl
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TSL Simplify", func() {
+	DescribeTable("simplifies trees",
+		func(input string, expected string) {
+			simplified, _ := Simplify(mustParse(input))
+			Expect(nodeKey(simplified.Node)).To(Equal(nodeKey(mustParse(expected).Node)))
+		},
+		// Flattening and duplicates
+		Entry("nested and", "a = 1 and (b = 2 and c = 3)", "a = 1 and b = 2 and c = 3"),
+		Entry("nested or", "(a = 1 or b = 2) or (c = 3 or d = 4)", "a = 1 or b = 2 or c = 3 or d = 4"),
+		Entry("duplicate conjuncts", "a = 1 and b = 2 and a = 1", "a = 1 and b = 2"),
+		Entry("duplicate disjuncts", "a = 1 or (a = 1 or b = 2)", "a = 1 or b = 2"),
+		Entry("mixed operators", "a = 1 and (b = 2 or b = 2)", "a = 1 and b = 2"),
+
+		// Constant folding
+		Entry("arithmetic", "1 + 2 > x", "3 > x"),
+		Entry("nested arithmetic", "x = 2 * (3 + 4) - 1", "x = 13"),
+		Entry("decimal division", "x < 1 / 4", "x < 0.25"),
+		Entry("integer modulus", "x = 7 % 3", "x = 1"),
+		Entry("literal comparison", "x = 1 and 2 > 1", "x = 1"),
+		Entry("false conjunct", "x = 1 and 1 > 2", "false"),
+		Entry("true disjunct", "x = 1 or 2 >= 2", "true"),
+		Entry("boolean literals", "x = 1 and true", "x = 1"),
+		Entry("division by zero is kept", "x = 1 / 0", "x = 1 / 0"),
+		Entry("arithmetic on fields is kept", "x + 1 > 2 + 3", "x + 1 > 5"),
+
+		// Negation
+		Entry("double negation", "not (not (a = 1))", "a = 1"),
+		Entry("negated equality", "not (a = 1)", "a != 1"),
+		Entry("negated regular expression", "not (a ~= 'x')", "a ~! 'x'"),
+		Entry("negated range is kept", "not (a < 1)", "not (a < 1)"),
+		Entry("de morgan and", "not (a = 1 and b = 2)", "a != 1 or b != 2"),
+		Entry("de morgan or", "not (a = 1 or b > 2)", "a != 1 and not (b > 2)"),
+		Entry("not like", "not (a not like 'x%')", "a like 'x%'"),
+		Entry("is not null", "not (a is not null)", "a is null"),
+		Entry("negated constant", "not (2 > 1) or x = 1", "x = 1"),
+
+		// Contradictions and tautologies
+		Entry("complement", "a = 1 and b = 2 and not (a = 1)", "false"),
+		Entry("excluded middle", "a ~= 'x' or not (a ~= 'x')", "true"),
+		Entry("empty range", "a > 5 and a < 3", "false"),
+		Entry("single point", "a >= 5 and a <= 5", "a >= 5 and a <= 5"),
+		Entry("strict single point", "a > 5 and a <= 5", "false"),
+		Entry("different values", "a = 'x' and a = 'y'", "false"),
+		Entry("value outside range", "a = 1 and b = 2 and a > 3", "false"),
+		Entry("disjoint sets", "a in ['x', 'y'] and a in ['z', 1]", "false"),
+		Entry("excluded value", "a in [1, 2] and a != 1 and a != 2", "false"),
+		Entry("flipped comparison", "5 < a and a < 3", "false"),
+		Entry("strings", "name = 'joe' and name = 'jane'", "false"),
+		Entry("numbers and strings", "a = 'joe' and a = 1", "false"),
+		Entry("null", "a is null and a >= 1", "false"),
+		Entry("null and excluded value", "a is null and a != 1", "a is null and a != 1"),
+		Entry("reversed between", "a between 5 and 3 and a != 4", "false"),
+		Entry("negated contradiction", "not (a != 'x' or a != 'y')", "false"),
+		Entry("or of contradictions", "(a > 5 and a < 3) or b = 1", "b = 1"),
+		Entry("inclusive empty range", "a >= 5 and a <= 3", "false"),
+		Entry("different numbers", "a = 1 and a = 2", "false"),
+		Entry("disjoint numbers", "a in [1, 2] and a = 3", "false"),
+
+		// NaN is not null, and matches negated comparisons in the walker
+		Entry("negated range", "a is not null and not (a >= 5) and not (a < 5)", "a is not null and not (a >= 5) and not (a < 5)"),
+		Entry("not between", "a is not null and a not between 1 and 3 and not (a < 1) and not (a > 3)", "a is not null and not (a between 1 and 3) and not (a < 1) and not (a > 3)"),
+
+		// Strings that may match dates or versions are kept
+		Entry("date strings", "d = '2024-01-01' and d = '2024-01-01T00:00:00Z'", "d = '2024-01-01' and d = '2024-01-01T00:00:00Z'"),
+		Entry("version strings", "v = 'v1.0.0' and v = '1.0.0'", "v = 'v1.0.0' and v = '1.0.0'"),
+
+		// Comparisons of different fields, or in OR expressions, are kept
+		Entry("different fields", "a > 5 and b < 3", "a > 5 and b < 3"),
+		Entry("ranges in or", "a > 5 or a < 3", "a > 5 or a < 3"),
+	)
+
+	DescribeTable("reports constant sub-expressions",
+		func(input string, expected []string, values []bool) {
+			_, constants := Simplify(mustParse(input))
+			Expect(constants).To(HaveLen(len(expected)))
+			for i, c := range constants {
+				Expect(nodeKey(c.Node.Node)).To(Equal(nodeKey(mustParse(expected[i]).Node)))
+				Expect(c.Value).To(Equal(values[i]))
+			}
+		},
+		Entry("none", "a = 1 and b > 2", []string{}, []bool{}),
+		Entry("literals are not reported", "a = 1 and true", []string{}, []bool{}),
+		Entry("contradiction", "b = 1 or (a > 5 and a < 3)", []string{"a > 5 and a < 3"}, []bool{false}),
+		Entry("tautology", "b = 1 and (c = 2 or c != 2)", []string{"c = 2 or c != 2"}, []bool{true}),
+		Entry("outermost only", "not (a > 5 and a < 3)", []string{"not (a > 5 and a < 3)"}, []bool{true}),
+		Entry("root", "a = 1 and 1 > 2", []string{"a = 1 and 1 > 2"}, []bool{false}),
+		Entry("several", "(1 = 1 or a = 1) and (a = 'x' and a = 'y' or b = 1)",
+			[]string{"1 = 1 or a = 1", "a = 'x' and a = 'y'"}, []bool{true, false}),
+	)
+
+	It("does not modify the tree", func() {
+		tree := mustParse("not (a = 1 and (b = 2 and 1 + 1 = 2))")
+		key := nodeKey(tree.Node)
+
+		simplified, _ := Simplify(tree)
+		Expect(nodeKey(tree.Node)).To(Equal(key))
+		Expect(nodeKey(simplified.Node)).To(Equal(nodeKey(mustParse("a != 1 or b != 2").Node)))
+	})
+
+	It("folds negative numbers into literals", func() {
+		simplified, _ := Simplify(mustParse("x > -(2 + 3)"))
+		Expect(simplified.Node.Right.Kind).To(Equal(KindNumericLiteral))
+		Expect(simplified.Node.Right.Value).To(Equal(int64(-5)))
+	})
+
+	It("simplifies an empty tree", func() {
+		simplified, constants := Simplify(nil)
+		Expect(simplified).To(BeNil())
+		Expect(constants).To(BeEmpty())
+	})
+})
@@ -16,6 +16,8 @@
 package tsl
 
 import (
+	"math/big"
+
 	"github.com/yaacov/tree-search-language/v6/pkg/parser"
 )
 
@@ -36,6 +38,31 @@ type TSLArrayLiteral struct {
 	Values []*TSLNode
 }
 
+// Decimal is an exact, arbitrary-precision decimal number.
+//
+// Numeric literals are stored as int64 when they are integers that fit in an
+// int64, and as Decimal otherwise.
+type Decimal = parser.Decimal
+
+// NewDecimal creates a decimal from a big.Rat value
+func NewDecimal(r *big.Rat) Decimal {
+	return parser.NewDecimal(r)
+}
+
+// ParseDecimal parses a decimal string, e.g. "0.1", "-12.5" or "1.23e-4"
+func ParseDecimal(s string) (Decimal, error) {
+	return parser.ParseDecimal(s)
+}
+
+// Version is a semantic version, version literals (e.g. v1.28.0) are stored
+// as Version values and compare using semantic version precedence.
+type Version = parser.Version
+
+// ParseVersion parses a semantic version string, e.g. "v1.2.3" or "1.2.3-rc.1"
+func ParseVersion(s string) (Version, error) {
+	return parser.ParseVersion(s)
+}
+
 // ParseTSL parses a TSL expression and returns the AST root node
 func ParseTSL(input string) (*TSLNode, error) {
 	parserNode, err := parser.Parse(input)
@@ -81,7 +108,8 @@ func (n *TSLNode) Value() interface{} {
 
 	switch n.Node.Kind {
 	case KindBooleanLiteral, KindNumericLiteral, KindStringLiteral,
-		KindIdentifier, KindDateLiteral, KindTimestampLiteral:
+		KindIdentifier, KindDateLiteral, KindTimestampLiteral,
+		KindIPLiteral, KindCIDRLiteral, KindVersionLiteral:
 		return n.Node.Value
 	case KindBinaryExpr:
 		var left, right *TSLNode
@@ -0,0 +1,122 @@
+package tsltest
+
+// Corpus returns the conformance suite, the People and Products datasets and
+// the cases running over them
+func Corpus() Suite {
+	return Suite{
+		Datasets: []Dataset{People(), Products()},
+		Cases:    append(peopleCases(), productCases()...),
+	}
+}
+
+// peopleCases are the cases of the People dataset
+func peopleCases() []Case {
+	cases := []Case{
+		// Comparisons
+		{Name: "equal string", Filter: "name = 'Alice'", Want: []int64{1}},
+		{Name: "equality is case sensitive", Filter: "name = 'alice'", Want: []int64{}},
+		{Name: "not equal", Filter: "name != 'Alice'", Want: []int64{2, 3, 4, 5, 6}},
+		{Name: "greater than", Filter: "age > 30", Want: []int64{3, 4, 6}},
+		{Name: "less or equal", Filter: "age <= 25", Want: []int64{2, 5}},
+		{Name: "decimal", Filter: "score >= 4.5", Want: []int64{1, 3}},
+		{Name: "real equals integer", Filter: "score = 5", Want: []int64{3}},
+		{Name: "between", Filter: "age between 25 and 31", Want: []int64{1, 2, 6}},
+		{Name: "not between", Filter: "age not between 25 and 31", Want: []int64{3, 4, 5}},
+		{Name: "in", Filter: "age in [22, 40, 99]", Want: []int64{4, 5}},
+		{Name: "not in", Filter: "age not in [22, 40]", Want: []int64{1, 2, 3, 6}},
+		{Name: "in strings", Filter: "name in ['bob', 'Eve']", Want: []int64{2, 5}},
+		{Name: "non ascii string", Filter: "city = 'Zürich'", Want: []int64{6}},
+
+		// Arithmetic
+		{Name: "addition", Filter: "age + 5 > 40", Want: []int64{4}},
+		{Name: "subtraction", Filter: "age - 30 = 1", Want: []int64{6}},
+		{Name: "multiplication", Filter: "age * 2 = 60", Want: []int64{1}},
+		{Name: "modulo", Filter: "age % 2 = 1", Want: []int64{2, 3, 6}},
+		{Name: "unary minus", Filter: "-age < -35", Want: []int64{4}},
+		{Name: "real arithmetic", Filter: "score * 2 = 9", Want: []int64{1}},
+		{Name: "division", Filter: "age / 2 > 17", Want: []int64{3, 4}, Features: []Feature{ExactDivision}},
+
+		// Booleans
+		{Name: "true", Filter: "active = true", Want: []int64{1, 3, 5, 6}},
+		{Name: "false", Filter: "active = false", Want: []int64{2, 4}},
+		{Name: "not equal true", Filter: "active != true", Want: []int64{2, 4}},
+		{Name: "boolean column", Filter: "active and age > 30", Want: []int64{3, 6}},
+		{Name: "negated boolean column", Filter: "not active", Want: []int64{2, 4}},
+		{Name: "double negation", Filter: "not not active", Want: []int64{1, 3, 5, 6}},
+
+		// Patterns
+		{Name: "like prefix", Filter: "name like 'A%'", Want: []int64{1}},
+		{Name: "like suffix", Filter: "name like '%e'", Want: []int64{1, 4, 5}},
+		{Name: "like single character", Filter: "name like '_ob'", Want: []int64{2}},
+		{Name: "like is case sensitive", Filter: "name like 'a%'", Want: []int64{}, Features: []Feature{CaseSensitiveLike}},
+		{Name: "not like", Filter: "name not like '%e'", Want: []int64{2, 3, 6}},
+		{Name: "ilike", Filter: "name ilike 'a%'", Want: []int64{1}},
+		{Name: "ilike upper case pattern", Filter: "name ilike 'B%'", Want: []int64{2}},
+		{Name: "ilike non ascii", Filter: "name ilike 'ZOË'", Want: []int64{6}, Features: []Feature{UnicodeCaseFolding}},
+		{Name: "regular expression", Filter: "name ~= '^[A-C]'", Want: []int64{1, 3}},
+		{Name: "negated regular expression", Filter: "name ~! '^[a-z]'", Want: []int64{1, 3, 5, 6}},
+
+		// NULL values
+		{Name: "is null", Filter: "email is null", Want: []int64{2, 5}},
+		{Name: "is not null", Filter: "email is not null", Want: []int64{1, 3, 4, 6}},
+		{Name: "equal skips null", Filter: "city = 'Paris'", Want: []int64{1, 4}},
+		{Name: "like skips null", Filter: "email like '%.com'", Want: []int64{1, 3, 6}},
+		{Name: "null or", Filter: "city is null or age > 35", Want: []int64{3, 4, 5}},
+		{Name: "not equal matches null", Filter: "city != 'Paris'", Want: []int64{2, 3, 5, 6}, Features: []Feature{NullNegation}},
+		{Name: "negated equal matches null", Filter: "not (city = 'Paris')", Want: []int64{2, 3, 5, 6}, Features: []Feature{NullNegation}},
+		{Name: "not in matches null", Filter: "city not in ['Paris']", Want: []int64{2, 3, 5, 6}, Features: []Feature{NullNegation}},
+		{Name: "not like matches null", Filter: "email not like '%.com'", Want: []int64{2, 4, 5}, Features: []Feature{NullNegation}},
+
+		// Dates are midnight UTC
+		{Name: "after date", Filter: "joined > 2024-01-31", Want: []int64{2, 3, 5}},
+		{Name: "from date", Filter: "joined >= 2024-01-31", Want: []int64{2, 3, 5, 6}},
+		{Name: "equal date", Filter: "joined = 2024-02-01", Want: []int64{3}},
+		{Name: "before date", Filter: "joined < 2024-01-01", Want: []int64{4}},
+		{Name: "between dates", Filter: "joined between 2024-01-01 and 2024-01-31", Want: []int64{1, 6}},
+		{Name: "after timestamp", Filter: "joined > 2024-01-31T12:00:00Z", Want: []int64{2, 3, 5}},
+		{Name: "equal fractional timestamp", Filter: "joined = 2024-02-15T08:30:00.5Z", Want: []int64{5}},
+		{Name: "before fractional timestamp", Filter: "joined < 2024-01-15T10:00:00.001Z", Want: []int64{1, 4}},
+		{Name: "timestamp with offset", Filter: "joined = 2024-01-15T12:00:00+02:00", Want: []int64{1}},
+
+		// Logical operators
+		{Name: "and binds tighter than or", Filter: "name = 'Alice' or age > 35 and active = true", Want: []int64{1}},
+		{Name: "parentheses", Filter: "(name = 'Alice' or age > 35) and active = false", Want: []int64{4}},
+		{Name: "negated or", Filter: "not (age > 30 or active = false)", Want: []int64{1, 5}},
+	}
+	for i := range cases {
+		cases[i].Name = "people/" + cases[i].Name
+		cases[i].Dataset = "people"
+	}
+	return cases
+}
+
+// productCases are the cases of the Products dataset
+func productCases() []Case {
+	cases := []Case{
+		// LIKE wildcards are escaped by a backslash, other characters match
+		// themselves
+		{Name: "underscore wildcard", Filter: "sku like 'A_1%'", Want: []int64{1, 2}},
+		{Name: "escaped underscore", Filter: `sku like 'A\\_%'`, Want: []int64{2}},
+		{Name: "escaped percent", Filter: `label like '%\\%%'`, Want: []int64{1, 4}},
+		{Name: "escaped backslash", Filter: `label like '%\\\\%'`, Want: []int64{3}},
+		{Name: "dot is not a wildcard", Filter: "sku like 'A.1%'", Want: []int64{}},
+		{Name: "dot", Filter: "sku like 'B.2%'", Want: []int64{3}},
+		{Name: "parenthesis", Filter: "sku like 'C(%)'", Want: []int64{5}},
+		{Name: "ilike", Filter: "label ilike 'cotton%'", Want: []int64{2, 5}},
+		{Name: "regular expression", Filter: "sku ~= '^[ab]-'", Want: []int64{4}},
+
+		// Numbers
+		{Name: "real", Filter: "price < 1", Want: []int64{4}},
+		{Name: "real equals decimal", Filter: "price = 19.99", Want: []int64{1}},
+		{Name: "real between decimals", Filter: "price between 5.5 and 19.99", Want: []int64{1, 2, 5}},
+		{Name: "real times integer", Filter: "price * stock > 50", Want: []int64{1, 3, 5}},
+		{Name: "negative", Filter: "stock < 0", Want: []int64{4}},
+		{Name: "negated column", Filter: "-stock > 0", Want: []int64{4}},
+		{Name: "modulo", Filter: "stock % 5 = 0", Want: []int64{1, 2}},
+	}
+	for i := range cases {
+		cases[i].Name = "products/" + cases[i].Name
+		cases[i].Dataset = "products"
+	}
+	return cases
+}
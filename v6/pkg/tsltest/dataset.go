@@ -0,0 +1,135 @@
+package tsltest
+
+import "time"
+
+// ColumnType is the type of the values of a column
+type ColumnType int
+
+const (
+	// Integer values are int64
+	Integer ColumnType = iota
+
+	// Real values are float64
+	Real
+
+	// Text values are strings
+	Text
+
+	// Boolean values are bools
+	Boolean
+
+	// Timestamp values are time.Time values in UTC
+	Timestamp
+)
+
+// String returns the name of the column type
+func (c ColumnType) String() string {
+	switch c {
+	case Integer:
+		return "integer"
+	case Real:
+		return "real"
+	case Text:
+		return "text"
+	case Boolean:
+		return "boolean"
+	case Timestamp:
+		return "timestamp"
+	}
+	return "unknown"
+}
+
+// Column is a named and typed column of a dataset
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Row holds the values of a dataset row by column name, the id column holds
+// the int64 ID of the row, and nil values are NULL
+type Row map[string]interface{}
+
+// ID returns the ID of the row
+func (r Row) ID() int64 {
+	return r["id"].(int64)
+}
+
+// Dataset is a table of rows filters run over
+type Dataset struct {
+	Name    string
+	Columns []Column
+	Rows    []Row
+}
+
+// Nullable reports if a column has NULL values
+func (ds Dataset) Nullable(column string) bool {
+	for _, row := range ds.Rows {
+		if row[column] == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// at returns a UTC time
+func at(value string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		panic(err)
+	}
+	return t.UTC()
+}
+
+// People is a dataset of people, with NULL emails and cities, non ASCII
+// names and timestamps around the end of January 2024
+func People() Dataset {
+	return Dataset{
+		Name: "people",
+		Columns: []Column{
+			{Name: "id", Type: Integer},
+			{Name: "name", Type: Text},
+			{Name: "age", Type: Integer},
+			{Name: "score", Type: Real},
+			{Name: "active", Type: Boolean},
+			{Name: "joined", Type: Timestamp},
+			{Name: "email", Type: Text},
+			{Name: "city", Type: Text},
+		},
+		Rows: []Row{
+			{"id": int64(1), "name": "Alice", "age": int64(30), "score": 4.5, "active": true,
+				"joined": at("2024-01-15T10:00:00Z"), "email": "alice@example.com", "city": "Paris"},
+			{"id": int64(2), "name": "bob", "age": int64(25), "score": 3.25, "active": false,
+				"joined": at("2024-01-31T23:59:59Z"), "email": nil, "city": "Rome"},
+			{"id": int64(3), "name": "Carol", "age": int64(35), "score": 5.0, "active": true,
+				"joined": at("2024-02-01T00:00:00Z"), "email": "carol@example.com", "city": nil},
+			{"id": int64(4), "name": "dave", "age": int64(40), "score": 2.75, "active": false,
+				"joined": at("2023-12-31T12:00:00Z"), "email": "dave@example.org", "city": "Paris"},
+			{"id": int64(5), "name": "Eve", "age": int64(22), "score": 4.0, "active": true,
+				"joined": at("2024-02-15T08:30:00.5Z"), "email": nil, "city": nil},
+			{"id": int64(6), "name": "Zoë", "age": int64(31), "score": 3.5, "active": true,
+				"joined": at("2024-01-31T00:00:00Z"), "email": "zoe@example.com", "city": "Zürich"},
+		},
+	}
+}
+
+// Products is a dataset of products, whose codes and labels hold the LIKE
+// wildcards, regular expression symbols and backslashes
+func Products() Dataset {
+	return Dataset{
+		Name: "products",
+		Columns: []Column{
+			{Name: "id", Type: Integer},
+			{Name: "sku", Type: Text},
+			{Name: "label", Type: Text},
+			{Name: "price", Type: Real},
+			{Name: "stock", Type: Integer},
+		},
+		Rows: []Row{
+			{"id": int64(1), "sku": "A-100", "label": "100% cotton", "price": 19.99, "stock": int64(10)},
+			{"id": int64(2), "sku": "A_101", "label": "cotton_blend", "price": 5.5, "stock": int64(0)},
+			{"id": int64(3), "sku": "B.200", "label": `path\to`, "price": 100.0, "stock": int64(3)},
+			{"id": int64(4), "sku": "b-201", "label": "50%_off", "price": 0.99, "stock": int64(-2)},
+			{"id": int64(5), "sku": "C(300)", "label": "Cotton", "price": 12.0, "stock": int64(7)},
+		},
+	}
+}
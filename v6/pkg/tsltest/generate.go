@@ -0,0 +1,227 @@
+package tsltest
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Generate returns n random filters over the columns of a dataset, for
+// differential tests (see Compare), the same seed returns the same filters.
+//
+// Literals are taken from the dataset values, so filters match some of the
+// rows. Filters do not depend on the skipped features, e.g. with NullNegation
+// negated predicates only use columns without NULL values.
+func Generate(ds Dataset, n int, seed int64, skip ...Feature) []string {
+	g := &generator{ds: ds, rand: rand.New(rand.NewSource(seed)), skip: skip}
+	filters := make([]string, n)
+	for i := range filters {
+		filters[i] = g.expression(3, false)
+	}
+	return filters
+}
+
+// generator builds random filters over a dataset
+type generator struct {
+	ds   Dataset
+	rand *rand.Rand
+	skip []Feature
+}
+
+// expression returns a random logical expression, negated is set inside NOT
+func (g *generator) expression(depth int, negated bool) string {
+	if depth == 0 || g.rand.Intn(3) == 0 {
+		return g.predicate(negated)
+	}
+	switch g.rand.Intn(3) {
+	case 0:
+		return "(" + g.expression(depth-1, negated) + " and " + g.expression(depth-1, negated) + ")"
+	case 1:
+		return "(" + g.expression(depth-1, negated) + " or " + g.expression(depth-1, negated) + ")"
+	default:
+		return "not " + "(" + g.expression(depth-1, true) + ")"
+	}
+}
+
+// negatable reports if negated predicates may use a column
+func (g *generator) negatable(c Column) bool {
+	return !slices.Contains(g.skip, NullNegation) || !g.ds.Nullable(c.Name)
+}
+
+// predicate returns a random predicate on a column, negated is set inside
+// NOT
+func (g *generator) predicate(negated bool) string {
+	var columns []Column
+	for _, c := range g.ds.Columns {
+		if !negated || g.negatable(c) {
+			columns = append(columns, c)
+		}
+	}
+	c := columns[g.rand.Intn(len(columns))]
+	canNegate := g.negatable(c)
+
+	if g.ds.Nullable(c.Name) && g.rand.Intn(5) == 0 {
+		if g.rand.Intn(2) == 0 {
+			return c.Name + " is null"
+		}
+		return c.Name + " is not null"
+	}
+
+	value := g.value(c)
+	switch c.Type {
+	case Integer, Real:
+		return g.numeric(c, value, canNegate)
+	case Text:
+		return g.text(c, value.(string), canNegate)
+	case Boolean:
+		switch g.rand.Intn(3) {
+		case 0:
+			return c.Name
+		case 1:
+			return fmt.Sprintf("%s = %v", c.Name, value)
+		}
+	case Timestamp:
+		t := value.(time.Time)
+		literal := t.Format(time.RFC3339Nano)
+		if g.rand.Intn(2) == 0 {
+			literal = t.Format("2006-01-02")
+		}
+		return c.Name + " " + g.comparison(canNegate) + " " + literal
+	}
+	return fmt.Sprintf("%s = %v", c.Name, value)
+}
+
+// value returns a random non NULL value of a column
+func (g *generator) value(c Column) interface{} {
+	var values []interface{}
+	for _, row := range g.ds.Rows {
+		if row[c.Name] != nil {
+			values = append(values, row[c.Name])
+		}
+	}
+	switch {
+	case len(values) > 0:
+		return values[g.rand.Intn(len(values))]
+	case c.Type == Text:
+		return ""
+	case c.Type == Boolean:
+		return true
+	case c.Type == Timestamp:
+		return time.Time{}
+	}
+	return int64(0)
+}
+
+// comparison returns a random comparison operator
+func (g *generator) comparison(canNegate bool) string {
+	operators := []string{"=", "<", "<=", ">", ">="}
+	if canNegate {
+		operators = append(operators, "!=")
+	}
+	return operators[g.rand.Intn(len(operators))]
+}
+
+// numeric returns a random predicate on a number column
+func (g *generator) numeric(c Column, value interface{}, canNegate bool) string {
+	literal := numberLiteral(value)
+	not := ""
+	if canNegate && g.rand.Intn(3) == 0 {
+		not = "not "
+	}
+
+	switch g.rand.Intn(5) {
+	case 0:
+		other := numberLiteral(g.value(c))
+		if numberValue(other) < numberValue(literal) {
+			literal, other = other, literal
+		}
+		return fmt.Sprintf("%s %sbetween %s and %s", c.Name, not, literal, other)
+	case 1:
+		return fmt.Sprintf("%s %sin [%s, %s]", c.Name, not, literal, numberLiteral(g.value(c)))
+	case 2:
+		operators := []string{"+", "-", "*"}
+		if c.Type == Integer {
+			operators = append(operators, "%")
+		}
+		if !slices.Contains(g.skip, ExactDivision) {
+			operators = append(operators, "/")
+		}
+		operator := operators[g.rand.Intn(len(operators))]
+		operand := strconv.Itoa(g.rand.Intn(4) + 1)
+		return fmt.Sprintf("%s %s %s %s %s", c.Name, operator, operand, g.comparison(canNegate), literal)
+	case 3:
+		return fmt.Sprintf("-%s %s -%s", c.Name, g.comparison(canNegate), literal)
+	}
+	return fmt.Sprintf("%s %s %s", c.Name, g.comparison(canNegate), literal)
+}
+
+// text returns a random predicate on a text column
+func (g *generator) text(c Column, value string, canNegate bool) string {
+	not := ""
+	if canNegate && g.rand.Intn(3) == 0 {
+		not = "not "
+	}
+	runes := []rune(value)
+	cut := 0
+	if len(runes) > 0 {
+		cut = g.rand.Intn(len(runes) + 1)
+	}
+
+	switch g.rand.Intn(5) {
+	case 0:
+		return fmt.Sprintf("%s %slike %s", c.Name, not, stringLiteral(likeLiteral(string(runes[:cut]))+"%"))
+	case 1:
+		return fmt.Sprintf("%s %slike %s", c.Name, not, stringLiteral("%"+likeLiteral(string(runes[cut:]))))
+	case 2:
+		if isASCII(value) || !slices.Contains(g.skip, UnicodeCaseFolding) {
+			pattern := strings.ToUpper(likeLiteral(string(runes[:cut]))) + "%"
+			return fmt.Sprintf("%s %silike %s", c.Name, not, stringLiteral(pattern))
+		}
+	case 3:
+		return fmt.Sprintf("%s %sin [%s, %s]", c.Name, not, stringLiteral(value), stringLiteral(g.value(c).(string)))
+	}
+	operator := "="
+	if canNegate && g.rand.Intn(2) == 0 {
+		operator = "!="
+	}
+	return fmt.Sprintf("%s %s %s", c.Name, operator, stringLiteral(value))
+}
+
+// numberLiteral returns the TSL literal of a number
+func numberLiteral(value interface{}) string {
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// numberValue returns the value of a number literal
+func numberValue(literal string) float64 {
+	f, _ := strconv.ParseFloat(literal, 64)
+	return f
+}
+
+// stringLiteral returns the TSL literal of a string
+func stringLiteral(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// likeLiteral escapes the wildcards of a LIKE pattern
+func likeLiteral(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// isASCII reports if a string holds only ASCII characters
+func isASCII(s string) bool {
+	for _, c := range s {
+		if c > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+package tsltest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+	"github.com/yaacov/tree-search-language/v6/pkg/walkers/semantics"
+)
+
+// Semantics returns the backend of the semantics walker, the reference
+// implementation of the corpus
+func Semantics() Backend {
+	return BackendFunc(func(ctx context.Context, ds Dataset, tree *tsl.TSLNode) ([]int64, error) {
+		var ids []int64
+		for _, row := range ds.Rows {
+			eval := func(_ context.Context, name string) (interface{}, bool, error) {
+				value, ok := row[name]
+				return value, ok, nil
+			}
+			result, err := semantics.WalkContext(ctx, tree, eval)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", row.ID(), err)
+			}
+			if matched, _ := result.(bool); matched {
+				ids = append(ids, row.ID())
+			}
+		}
+		return ids, nil
+	})
+}
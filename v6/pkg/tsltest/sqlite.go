@@ -0,0 +1,155 @@
+package tsltest
+
+import (
+	"context"
+	dbsql "database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"modernc.org/sqlite"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+	"github.com/yaacov/tree-search-language/v6/pkg/walkers/sql"
+)
+
+// registerRegexp registers the regexp() function of the REGEXP operator
+var registerRegexp sync.Once
+
+// SQLite is a backend running the SQL of the sql walker on an embedded,
+// in memory, SQLite database
+type SQLite struct {
+	db   *dbsql.DB
+	opts []sql.Option
+
+	mu     sync.Mutex
+	loaded map[string]bool
+}
+
+// NewSQLite returns a SQLite backend, the walker options are added to the
+// SQLite dialect, the TimeUTC time mode and the dataset columns.
+//
+// Timestamps are stored as UTC text, LIKE is case sensitive, and REGEXP uses
+// Go regular expressions, like in the semantics walker.
+func NewSQLite(opts ...sql.Option) (*SQLite, error) {
+	registerRegexp.Do(func() {
+		// The function may already be registered by the application
+		_ = sqlite.RegisterDeterministicScalarFunction("regexp", 2, regexpFunction)
+	})
+
+	db, err := dbsql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+
+	// Each connection has its own in memory database
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA case_sensitive_like = ON"); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLite{
+		db:     db,
+		opts:   append([]sql.Option{sql.WithDialect(sql.SQLite), sql.WithTimeMode(sql.TimeUTC)}, opts...),
+		loaded: map[string]bool{},
+	}, nil
+}
+
+// regexpFunction matches a value with a regular expression, X REGEXP Y calls
+// regexp(Y, X)
+func regexpFunction(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	pattern, ok := args[0].(string)
+	value, valueOK := args[1].(string)
+	if !ok || !valueOK {
+		return nil, nil
+	}
+	matched, err := regexp.MatchString(pattern, value)
+	if err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
+
+// DB returns the database of the backend
+func (s *SQLite) DB() *dbsql.DB {
+	return s.db
+}
+
+// Close closes the database
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// Match runs the SQL of the tree on the dataset table, the dataset is loaded
+// on its first use
+func (s *SQLite) Match(ctx context.Context, ds Dataset, tree *tsl.TSLNode) ([]int64, error) {
+	if err := s.load(ctx, ds); err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(ds.Columns))
+	for _, c := range ds.Columns {
+		columns = append(columns, c.Name)
+	}
+	opts := append([]sql.Option{sql.WithAllowedColumns(columns...)}, s.opts...)
+
+	query, args, err := sql.SelectQuery(`"id"`, sql.SQLite.QuoteIdentifier(ds.Name), tree, opts...)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query, err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// load creates the table of a dataset and inserts its rows
+func (s *SQLite) load(ctx context.Context, ds Dataset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded[ds.Name] {
+		return nil
+	}
+
+	types := map[ColumnType]string{
+		Integer: "INTEGER", Real: "REAL", Text: "TEXT", Boolean: "INTEGER", Timestamp: "TEXT",
+	}
+	table := sql.SQLite.QuoteIdentifier(ds.Name)
+	definitions := make([]string, 0, len(ds.Columns))
+	names := make([]string, 0, len(ds.Columns))
+	for _, c := range ds.Columns {
+		definitions = append(definitions, sql.SQLite.QuoteIdentifier(c.Name)+" "+types[c.Type])
+		names = append(names, sql.SQLite.QuoteIdentifier(c.Name))
+	}
+	if _, err := s.db.ExecContext(ctx, "CREATE TABLE "+table+" ("+strings.Join(definitions, ", ")+")"); err != nil {
+		return err
+	}
+
+	insert := "INSERT INTO " + table + " (" + strings.Join(names, ", ") + ") VALUES (" +
+		strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ") + ")"
+	for _, row := range ds.Rows {
+		values := make([]interface{}, 0, len(ds.Columns))
+		for _, c := range ds.Columns {
+			values = append(values, row[c.Name])
+		}
+		if _, err := s.db.ExecContext(ctx, insert, values...); err != nil {
+			return err
+		}
+	}
+	s.loaded[ds.Name] = true
+	return nil
+}
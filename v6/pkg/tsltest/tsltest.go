@@ -0,0 +1,212 @@
+// Package tsltest is a conformance test kit for TSL backends, a shared corpus
+// of filters over small datasets, with the rows each filter must match.
+//
+// A backend runs a TSL tree over a dataset, e.g. by converting it to SQL and
+// querying a database loaded with the dataset rows, and returns the IDs of the
+// matching rows. Backends are certified by running the corpus:
+//
+//	func TestConformance(t *testing.T) {
+//		tsltest.Run(t, myBackend, tsltest.NullNegation)
+//	}
+//
+// Cases that depend on a behavior a backend does not share with the TSL
+// semantics, e.g. the matching of NULL values by negated predicates, are
+// marked by features, and skipped when the feature is passed to Run.
+//
+// Differential tests compare the results of two backends on the corpus and on
+// generated filters (see Compare and Generate), e.g. the SQLite backend, that
+// runs the SQL of the sql walker on an embedded database, with the semantics
+// walker.
+package tsltest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// Feature is a behavior of the TSL semantics some backends do not share
+type Feature string
+
+const (
+	// NullNegation is the matching of rows with NULL values by negated
+	// predicates, e.g. city != 'Paris' or not (age > 30). TSL predicates on
+	// NULL values are false, and their negations true, SQL predicates on NULL
+	// values are unknown, and their negations unknown.
+	NullNegation Feature = "null-negation"
+
+	// UnicodeCaseFolding is the case insensitive matching of non ASCII
+	// letters by ILIKE, e.g. 'ZOË' ilike 'zoë'.
+	UnicodeCaseFolding Feature = "unicode-case-folding"
+
+	// CaseSensitiveLike is the case sensitive matching of LIKE, e.g. 'Alice'
+	// like 'a%' is false, the LIKE of MySQL collations, and of SQLite unless
+	// the case_sensitive_like pragma is set, ignores the case.
+	CaseSensitiveLike Feature = "case-sensitive-like"
+
+	// ExactDivision is the exact division of integers, e.g. 7 / 2 = 3.5, most
+	// SQL databases truncate the quotient of integers.
+	ExactDivision Feature = "exact-division"
+)
+
+// Backend runs TSL filters over datasets
+type Backend interface {
+	// Match returns the IDs of the dataset rows matching the tree
+	Match(ctx context.Context, ds Dataset, tree *tsl.TSLNode) ([]int64, error)
+}
+
+// BackendFunc is a function implementing Backend
+type BackendFunc func(ctx context.Context, ds Dataset, tree *tsl.TSLNode) ([]int64, error)
+
+// Match calls the function
+func (f BackendFunc) Match(ctx context.Context, ds Dataset, tree *tsl.TSLNode) ([]int64, error) {
+	return f(ctx, ds, tree)
+}
+
+// Case is a filter of the corpus and the IDs of the rows it matches
+type Case struct {
+	// Name describes the case
+	Name string
+
+	// Dataset is the name of the dataset the filter runs over
+	Dataset string
+
+	// Filter is the TSL filter
+	Filter string
+
+	// Want are the IDs of the matching rows, in ascending order
+	Want []int64
+
+	// Features are the behaviors the case depends on
+	Features []Feature
+}
+
+// Suite is a set of datasets and the cases running over them
+type Suite struct {
+	Datasets []Dataset
+	Cases    []Case
+}
+
+// Dataset returns the dataset of a suite by name
+func (s Suite) Dataset(name string) (Dataset, bool) {
+	for _, ds := range s.Datasets {
+		if ds.Name == name {
+			return ds, true
+		}
+	}
+	return Dataset{}, false
+}
+
+// Mismatch is a filter two backends, or a backend and a case, disagree on
+type Mismatch struct {
+	// Case is the name of the case, empty for generated filters
+	Case string
+
+	Dataset string
+	Filter  string
+
+	// Want are the expected IDs, and Got the IDs the backend returned
+	Want []int64
+	Got  []int64
+
+	// Err is the error of the backend, if it failed
+	Err error
+}
+
+// Error describes the mismatch
+func (m Mismatch) Error() string {
+	prefix := fmt.Sprintf("%s: %q", m.Dataset, m.Filter)
+	if m.Case != "" {
+		prefix = m.Case + ": " + prefix
+	}
+	if m.Err != nil {
+		return fmt.Sprintf("%s failed: %v", prefix, m.Err)
+	}
+	return fmt.Sprintf("%s matched %v, want %v", prefix, m.Got, m.Want)
+}
+
+// Check runs a case on a backend, it returns a Mismatch when the backend
+// fails or matches other rows
+func (s Suite) Check(ctx context.Context, b Backend, c Case) error {
+	ds, ok := s.Dataset(c.Dataset)
+	if !ok {
+		return fmt.Errorf("%s: unknown dataset %q", c.Name, c.Dataset)
+	}
+	tree, err := tsl.ParseTSL(c.Filter)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.Name, err)
+	}
+
+	m := Mismatch{Case: c.Name, Dataset: c.Dataset, Filter: c.Filter, Want: c.Want}
+	m.Got, m.Err = match(ctx, b, ds, tree)
+	if m.Err != nil || !slices.Equal(m.Got, m.Want) {
+		return m
+	}
+	return nil
+}
+
+// Run runs the cases of the suite as subtests, cases depending on a skipped
+// feature are skipped
+func (s Suite) Run(t *testing.T, b Backend, skip ...Feature) {
+	t.Helper()
+	for _, c := range s.Cases {
+		t.Run(c.Name, func(t *testing.T) {
+			for _, f := range c.Features {
+				if slices.Contains(skip, f) {
+					t.Skipf("backend does not support %s", f)
+				}
+			}
+			if err := s.Check(context.Background(), b, c); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Run runs the corpus on a backend as subtests, cases depending on a skipped
+// feature are skipped
+func Run(t *testing.T, b Backend, skip ...Feature) {
+	t.Helper()
+	Corpus().Run(t, b, skip...)
+}
+
+// Compare runs filters on a reference and a candidate backend, and returns
+// the filters the backends disagree on. Filters the reference fails on are
+// returned as an error.
+func Compare(ctx context.Context, reference, candidate Backend, ds Dataset, filters []string) ([]Mismatch, error) {
+	var mismatches []Mismatch
+	for _, filter := range filters {
+		tree, err := tsl.ParseTSL(filter)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", filter, err)
+		}
+		want, err := match(ctx, reference, ds, tree)
+		if err != nil {
+			return nil, fmt.Errorf("%q: reference backend: %w", filter, err)
+		}
+
+		m := Mismatch{Dataset: ds.Name, Filter: filter, Want: want}
+		m.Got, m.Err = match(ctx, candidate, ds, tree)
+		if m.Err != nil || !slices.Equal(m.Got, m.Want) {
+			mismatches = append(mismatches, m)
+		}
+	}
+	return mismatches, nil
+}
+
+// match runs a tree on a backend and returns the sorted IDs
+func match(ctx context.Context, b Backend, ds Dataset, tree *tsl.TSLNode) ([]int64, error) {
+	ids, err := b.Match(ctx, ds, tree)
+	if err != nil {
+		return nil, err
+	}
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	if ids == nil {
+		ids = []int64{}
+	}
+	return ids, nil
+}
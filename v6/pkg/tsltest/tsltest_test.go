@@ -0,0 +1,135 @@
+package tsltest
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+func TestTSLTest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Conformance kit")
+}
+
+// sqliteSkips are the features of the TSL semantics SQLite does not share
+var sqliteSkips = []Feature{NullNegation, UnicodeCaseFolding, ExactDivision}
+
+var _ = Describe("Conformance kit", func() {
+	var db *SQLite
+
+	BeforeEach(func() {
+		var err error
+		db, err = NewSQLite()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(db.Close)
+	})
+
+	It("has ordered case results over known datasets", func() {
+		corpus := Corpus()
+		for _, c := range corpus.Cases {
+			_, ok := corpus.Dataset(c.Dataset)
+			Expect(ok).To(BeTrue(), c.Name)
+			Expect(c.Want).ToNot(BeNil(), c.Name)
+			Expect(c.Want).To(BeEquivalentTo(sorted(c.Want)), c.Name)
+		}
+	})
+
+	It("passes the corpus using the semantics walker", func() {
+		corpus := Corpus()
+		for _, c := range corpus.Cases {
+			Expect(corpus.Check(context.Background(), Semantics(), c)).To(Succeed())
+		}
+	})
+
+	It("passes the corpus using the sql walker and SQLite", func() {
+		corpus := Corpus()
+		for _, c := range corpus.Cases {
+			if !skipped(c, sqliteSkips) {
+				Expect(corpus.Check(context.Background(), db, c)).To(Succeed())
+			}
+		}
+	})
+
+	It("reports the cases a backend fails", func() {
+		corpus := Corpus()
+		var failed []string
+		for _, c := range corpus.Cases {
+			if err := corpus.Check(context.Background(), db, c); err != nil {
+				Expect(err).To(BeAssignableToTypeOf(Mismatch{}))
+				failed = append(failed, c.Name)
+			}
+		}
+		Expect(failed).To(ContainElements(
+			"people/not equal matches null",
+			"people/ilike non ascii",
+			"people/division",
+		))
+	})
+
+	It("compares backends", func() {
+		mismatches, err := Compare(context.Background(), Semantics(), db, People(), []string{
+			"city = 'Paris'",
+			"city != 'Paris'",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mismatches).To(HaveLen(1))
+		Expect(mismatches[0].Filter).To(Equal("city != 'Paris'"))
+		Expect(mismatches[0].Want).To(Equal([]int64{2, 3, 5, 6}))
+		Expect(mismatches[0].Got).To(Equal([]int64{2, 6}))
+		Expect(mismatches[0].Error()).To(ContainSubstring("matched [2 6], want [2 3 5 6]"))
+	})
+
+	It("returns backend errors as mismatches", func() {
+		failing := BackendFunc(func(ctx context.Context, ds Dataset, tree *tsl.TSLNode) ([]int64, error) {
+			return nil, tsl.UnexpectedLiteralError{Literal: "x"}
+		})
+		mismatches, err := Compare(context.Background(), Semantics(), failing, People(), []string{"age > 1"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mismatches).To(HaveLen(1))
+		Expect(mismatches[0].Err).To(HaveOccurred())
+	})
+
+	It("generates the same filters for a seed", func() {
+		Expect(Generate(People(), 20, 7)).To(Equal(Generate(People(), 20, 7)))
+		Expect(Generate(People(), 20, 7)).ToNot(Equal(Generate(People(), 20, 8)))
+
+		for _, filter := range Generate(Products(), 100, 1) {
+			_, err := tsl.ParseTSL(filter)
+			Expect(err).ToNot(HaveOccurred(), filter)
+		}
+	})
+
+	DescribeTable("agrees with the semantics walker on generated filters",
+		func(ds Dataset, seed int64) {
+			filters := Generate(ds, 500, seed, sqliteSkips...)
+			mismatches, err := Compare(context.Background(), Semantics(), db, ds, filters)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mismatches).To(BeEmpty())
+		},
+		Entry("people", People(), int64(1)),
+		Entry("people, another seed", People(), int64(2)),
+		Entry("products", Products(), int64(1)),
+	)
+})
+
+// sorted returns a sorted copy of IDs
+func sorted(ids []int64) []int64 {
+	out := slices.Clone(ids)
+	slices.Sort(out)
+	return out
+}
+
+// skipped reports if a case depends on a skipped feature
+func skipped(c Case, skip []Feature) bool {
+	for _, f := range c.Features {
+		if slices.Contains(skip, f) {
+			return true
+		}
+	}
+	return false
+}
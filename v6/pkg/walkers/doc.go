@@ -14,13 +14,13 @@
 // limitations under the License.
 
 // Package walkers examples TSL tree walking, and helps to generate SQL
-// query filters. sql.Walk method can be used to create such filters.
+// query filters. sql.WalkWithOptions method can be used to create such filters.
 //
 // Squirrel walk code:  https://github.com/yaacov/tree-search-language/blob/master/v6/pkg/walkers/sql/walk.go
 //
 // Usage:
 //
-//	filter, err := sql.Walk(tree)
+//	filter, err := sql.WalkWithOptions(tree, sql.WithAllowedColumns("name", "city"))
 //
 //	sql, args, err := sq.Select("name, city, state").
 //	     From("users").
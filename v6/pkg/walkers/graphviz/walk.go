@@ -35,6 +35,9 @@ const stringStyle = baseRecordStyle + " color=blue"
 const booleanStyle = baseRecordStyle + " color=purple"
 const dateStyle = baseRecordStyle + " color=orange"
 const timestampStyle = baseRecordStyle + " color=orange"
+const ipStyle = baseRecordStyle + " color=brown"
+const cidrStyle = baseRecordStyle + " color=brown"
+const versionStyle = baseRecordStyle + " color=darkgreen"
 const opStyle = baseBoxStyle + " color=black"
 const arrayStyle = baseBoxStyle + " color=green"
 
@@ -123,6 +126,12 @@ func Walk(in string, n *tsl.TSLNode, nodeID string) (out string, err error) {
 		out = formatLeafNodeWithInput(in, nodeID, dateStyle, n.Type(), n.Value())
 	case tsl.KindTimestampLiteral:
 		out = formatLeafNodeWithInput(in, nodeID, timestampStyle, n.Type(), n.Value())
+	case tsl.KindIPLiteral:
+		out = formatLeafNodeWithInput(in, nodeID, ipStyle, n.Type(), n.Value())
+	case tsl.KindCIDRLiteral:
+		out = formatLeafNodeWithInput(in, nodeID, cidrStyle, n.Type(), n.Value())
+	case tsl.KindVersionLiteral:
+		out = formatLeafNodeWithInput(in, nodeID, versionStyle, n.Type(), n.Value())
 	case tsl.KindBinaryExpr:
 		expr := n.Value().(tsl.TSLExpressionOp)
 		st := formatOperatorNode(nodeID, expr.Operator.String())
@@ -138,6 +147,11 @@ func Walk(in string, n *tsl.TSLNode, nodeID string) (out string, err error) {
 		expr := n.Value().(tsl.TSLExpressionOp)
 		st := formatOperatorNode(nodeID, expr.Operator.String())
 
+		// COUNT(*) has no operand
+		if expr.Right == nil {
+			return fmt.Sprintf("%s%s", in, st), nil
+		}
+
 		childrenStr, childrenIDs, err := handleChildren(in, []*tsl.TSLNode{expr.Right})
 		if err != nil {
 			return "", err
@@ -72,6 +72,19 @@ var _ = Describe("Walk", func() {
 				"[shape=record color=red label=\"IDENTIFIER | 'updated_at'\" ]",
 				"[shape=record color=orange label=\"TIMESTAMP | 2023-01-01 15:04:05 +0000 UTC\" ]",
 			}),
+		Entry("ip within cidr",
+			"ip within 10.0.0.0/8 or ip = ::1",
+			[]string{
+				"[shape=box color=black label=\"WITHIN\"]",
+				"[shape=record color=brown label=\"CIDR | 10.0.0.0/8\" ]",
+				"[shape=record color=brown label=\"IP | ::1\" ]",
+			}),
+		Entry("version comparison",
+			"version >= v1.28.0-rc.1",
+			[]string{
+				"[shape=box color=black label=\"GE\"]",
+				"[shape=record color=darkgreen label=\"VERSION | v1.28.0-rc.1\" ]",
+			}),
 		Entry("complex nested expression",
 			"(age > 18 and status in ['active', 'pending']) or (created_at > 2023-01-01 and not is_deleted)",
 			[]string{
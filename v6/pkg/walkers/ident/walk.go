@@ -129,6 +129,11 @@ func walkAndReplace(n *tsl.TSLNode, check func(s string) (string, error)) (*tsl.
 	case tsl.KindUnaryExpr:
 		op := n.Value().(tsl.TSLExpressionOp)
 
+		// COUNT(*) has no operand
+		if op.Right == nil {
+			return n, nil
+		}
+
 		// Process the operand
 		var processedRight *tsl.TSLNode
 		var err error
@@ -0,0 +1,228 @@
+package mongo
+
+import (
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// WithDocumentArrays marks field paths as arrays of documents, the operands
+// of the ANY and ALL operators test the fields of one element, e.g. with the
+// items array, any (items.price > 100 and items.qty < 3) is
+//
+//	{"items": {"$elemMatch": {"$and": [{"price": {"$gt": 100}}, {"qty": {"$lt": 3}}]}}}
+//
+// Other arrays are arrays of values, e.g. any (tags = 'urgent') is
+//
+//	{"tags": {"$elemMatch": {"$eq": "urgent"}}}
+func WithDocumentArrays(fields ...string) Option {
+	return func(o *options) {
+		if o.documentArrays == nil {
+			o.documentArrays = map[string]bool{}
+		}
+		for _, field := range fields {
+			o.documentArrays[field] = true
+		}
+	}
+}
+
+// arrayOf returns the field path of the array the operand of an array
+// operator uses, the operand must use one array. Operands of document arrays
+// may also use the fields of the document, other operands use one field.
+func (w *walker) arrayOf(operator tsl.Operator, n *tsl.TSLNode) (field string, document bool, err error) {
+	var fields, arrays []string
+	seen := map[string]bool{}
+	for _, name := range identifiers(n) {
+		field, err := w.field(name)
+		if err != nil {
+			return "", false, err
+		}
+		array, ok := w.documentArray(field)
+		switch {
+		case ok && !seen[array]:
+			arrays = append(arrays, array)
+			seen[array] = true
+		case !ok && !seen[field]:
+			fields = append(fields, field)
+			seen[field] = true
+		}
+	}
+
+	switch {
+	case len(arrays) == 1:
+		return arrays[0], true, nil
+	case len(arrays) == 0 && len(fields) == 1:
+		return fields[0], false, nil
+	}
+	return "", false, tsl.ArrayOperandError{Operator: operator, Arrays: append(arrays, fields...)}
+}
+
+// documentArray returns the document array holding a field, the innermost
+// one for nested arrays
+func (w *walker) documentArray(field string) (string, bool) {
+	found := ""
+	for array := range w.opts.documentArrays {
+		if strings.HasPrefix(field, array+".") && len(array) > len(found) {
+			found = array
+		}
+	}
+	return found, found != ""
+}
+
+// elemMatch converts the ANY and ALL operators to $elemMatch filters, ok is
+// false when the operand needs an aggregation expression
+func (w *walker) elemMatch(op tsl.TSLExpressionOp) (d bson.D, ok bool, err error) {
+	if op.Right.Type() == tsl.KindArrayLiteral || len(identifiers(op.Right)) == 0 {
+		return nil, false, nil
+	}
+	array, document, err := w.arrayOf(op.Operator, op.Right)
+	if err != nil {
+		return nil, false, err
+	}
+
+	inner := *w
+	inner.element, inner.document, inner.elementMatch = array, document, true
+	match, err := inner.filter(op.Right)
+	if err == errNeedsExpression {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Arrays of values match the operators of the array field
+	negated := negate(match)
+	if !document {
+		if len(match) != 1 || match[0].Key != array {
+			return nil, false, nil
+		}
+		cond := match[0].Value.(bson.D)
+		match, negated = cond, negateCondition(cond)
+	}
+
+	if op.Operator == tsl.OpAny {
+		return condition(array, bson.D{{Key: "$elemMatch", Value: match}}), true, nil
+	}
+
+	// ALL is false for empty arrays, and for elements the operand is not true
+	// for
+	return bson.D{
+		{Key: array + ".0", Value: bson.D{{Key: "$exists", Value: true}}},
+		{Key: array, Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$elemMatch", Value: negated}}}}},
+	}, true, nil
+}
+
+// arrayExpression converts the LEN, ANY, ALL and SUM array operators to
+// aggregation expressions
+func (w *walker) arrayExpression(op tsl.TSLExpressionOp) (interface{}, error) {
+	if op.Right.Type() == tsl.KindArrayLiteral {
+		return w.arrayLiteralExpression(op)
+	}
+
+	switch op.Operator {
+	case tsl.OpLen, tsl.OpSum:
+		r, err := w.expression(op.Right)
+		if err != nil {
+			return nil, err
+		}
+		if op.Operator == tsl.OpSum {
+			return bson.D{{Key: "$sum", Value: r}}, nil
+		}
+		return bson.D{{Key: "$size", Value: bson.D{{Key: "$ifNull", Value: bson.A{r, bson.A{}}}}}}, nil
+	}
+
+	array, document, err := w.arrayOf(op.Operator, op.Right)
+	if err != nil {
+		return nil, err
+	}
+	elements := bson.D{{Key: "$ifNull", Value: bson.A{"$" + array, bson.A{}}}}
+
+	// Walk the operand with the array field naming one element
+	inner := *w
+	inner.element, inner.document, inner.elementMatch = array, document, false
+	cond, err := inner.condition(op.Right)
+	if err != nil {
+		return nil, err
+	}
+	results := bson.D{{Key: "$map", Value: bson.D{
+		{Key: "input", Value: elements},
+		{Key: "as", Value: "element"},
+		{Key: "in", Value: cond},
+	}}}
+
+	if op.Operator == tsl.OpAny {
+		return bson.D{{Key: "$anyElementTrue", Value: bson.A{results}}}, nil
+	}
+
+	// ALL is false for empty arrays
+	return bson.D{{Key: "$and", Value: bson.A{
+		bson.D{{Key: "$gt", Value: bson.A{bson.D{{Key: "$size", Value: elements}}, int64(0)}}},
+		bson.D{{Key: "$allElementsTrue", Value: bson.A{results}}},
+	}}}, nil
+}
+
+// arrayLiteralExpression converts the array operators of array literals
+func (w *walker) arrayLiteralExpression(op tsl.TSLExpressionOp) (interface{}, error) {
+	values := op.Right.Value().(tsl.TSLArrayLiteral).Values
+	elements := make(bson.A, len(values))
+	for i, value := range values {
+		e, err := w.condition(value)
+		if op.Operator == tsl.OpLen || op.Operator == tsl.OpSum {
+			e, err = w.expression(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = e
+	}
+
+	switch op.Operator {
+	case tsl.OpLen:
+		return int64(len(elements)), nil
+	case tsl.OpSum:
+		return bson.D{{Key: "$add", Value: append(bson.A{int64(0)}, elements...)}}, nil
+	}
+
+	// ANY and ALL are false for empty arrays
+	switch {
+	case len(elements) == 0:
+		return false, nil
+	case op.Operator == tsl.OpAny:
+		return bson.D{{Key: "$or", Value: elements}}, nil
+	}
+	return bson.D{{Key: "$and", Value: elements}}, nil
+}
+
+// identifiers returns the sorted distinct identifiers of a node
+func identifiers(n *tsl.TSLNode) []string {
+	seen := map[string]bool{}
+	var collect func(n *tsl.TSLNode)
+	collect = func(n *tsl.TSLNode) {
+		if n == nil {
+			return
+		}
+		switch n.Type() {
+		case tsl.KindIdentifier:
+			seen[n.Value().(string)] = true
+		case tsl.KindBinaryExpr, tsl.KindUnaryExpr:
+			op := n.Value().(tsl.TSLExpressionOp)
+			collect(op.Left)
+			collect(op.Right)
+		case tsl.KindArrayLiteral:
+			for _, value := range n.Value().(tsl.TSLArrayLiteral).Values {
+				collect(value)
+			}
+		}
+	}
+	collect(n)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
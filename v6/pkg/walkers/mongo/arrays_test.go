@@ -0,0 +1,87 @@
+package mongo
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("Array operators", func() {
+	documents := []Option{WithPlainFields(), WithDocumentArrays("items", "order.lines")}
+
+	DescribeTable("Generates the expected filter documents",
+		func(input string, expected string) {
+			actual, err := extJSON(input, documents...)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(expected))
+		},
+
+		// LEN and SUM
+		Entry("len equal", "len tags = 3",
+			`{"tags":{"$size":3}}`),
+		Entry("len equal flipped", "0 = len tags",
+			`{"tags":{"$size":0}}`),
+		Entry("len greater than", "len tags > 2",
+			`{"$expr":{"$gt":[{"$size":{"$ifNull":["$tags",[]]}},2]}}`),
+		Entry("not len equal", "not (len tags = 3)",
+			`{"tags":{"$not":{"$size":3}}}`),
+		Entry("sum", "sum scores >= 100",
+			`{"$expr":{"$gte":[{"$sum":"$scores"},100]}}`),
+		Entry("sum of document fields", "sum items.price < 50",
+			`{"$expr":{"$and":[{"$ne":[{"$ifNull":[{"$sum":"$items.price"},null]},null]},{"$lt":[{"$sum":"$items.price"},50]}]}}`),
+
+		// ANY and ALL of values
+		Entry("any equal", "any (tags = 'urgent')",
+			`{"tags":{"$elemMatch":{"$eq":"urgent"}}}`),
+		Entry("any greater than", "any (scores > 90)",
+			`{"scores":{"$elemMatch":{"$gt":90}}}`),
+		Entry("any like", "any (tags ilike 'urg%')",
+			`{"tags":{"$elemMatch":{"$regex":{"$regularExpression":{"pattern":"^urg.*$","options":"is"}}}}}`),
+		Entry("any in", "any (tags in ['a', 'b'])",
+			`{"tags":{"$elemMatch":{"$in":["a","b"]}}}`),
+		Entry("any between", "any (scores between 10 and 20)",
+			`{"scores":{"$elemMatch":{"$gte":10,"$lte":20}}}`),
+		Entry("not any", "not any (tags = 'urgent')",
+			`{"tags":{"$not":{"$elemMatch":{"$eq":"urgent"}}}}`),
+		Entry("all greater than", "all (scores > 50)",
+			`{"scores.0":{"$exists":true},"scores":{"$not":{"$elemMatch":{"$not":{"$gt":50}}}}}`),
+		Entry("all equal", "all (tags = 'ok')",
+			`{"tags.0":{"$exists":true},"tags":{"$not":{"$elemMatch":{"$ne":"ok"}}}}`),
+		Entry("all like", "all (tags like 'a%')",
+			`{"tags.0":{"$exists":true},"tags":{"$not":{"$elemMatch":{"$not":{"$regularExpression":{"pattern":"^a.*$","options":"s"}}}}}}`),
+		Entry("not all", "not all (scores > 50)",
+			`{"$nor":[{"scores.0":{"$exists":true},"scores":{"$not":{"$elemMatch":{"$not":{"$gt":50}}}}}]}`),
+
+		// ANY and ALL of documents
+		Entry("any document", "any (items.price > 100 and items.qty < 3)",
+			`{"items":{"$elemMatch":{"$and":[{"price":{"$gt":100}},{"qty":{"$lt":3}}]}}}`),
+		Entry("any nested document array", "any (order.lines.sku = 'A-1')",
+			`{"order.lines":{"$elemMatch":{"sku":{"$eq":"A-1"}}}}`),
+		Entry("all documents", "all (items.qty > 0 or items.backorder)",
+			`{"items.0":{"$exists":true},"items":{"$not":{"$elemMatch":{"$nor":[{"$or":[{"qty":{"$gt":0}},{"backorder":{"$eq":true}}]}]}}}}`),
+
+		// Operands needing aggregation expressions
+		Entry("any of an arithmetic", "any (scores * 2 > 150)",
+			`{"$expr":{"$anyElementTrue":[{"$map":{"input":{"$ifNull":["$scores",[]]},"as":"element","in":{"$gt":[{"$multiply":["$$element",2]},150]}}}]}}`),
+		Entry("any of values or", "any (tags = 'a' or tags = 'b')",
+			`{"$expr":{"$anyElementTrue":[{"$map":{"input":{"$ifNull":["$tags",[]]},"as":"element","in":{"$or":[{"$eq":["$$element","a"]},{"$eq":["$$element","b"]}]}}}]}}`),
+		Entry("all of document fields", "all (items.price * items.qty < budget)",
+			`{"$expr":{"$and":[{"$gt":[{"$size":{"$ifNull":["$items",[]]}},0]},{"$allElementsTrue":[{"$map":{"input":{"$ifNull":["$items",[]]},"as":"element","in":{"$and":[{"$ne":[{"$ifNull":[{"$multiply":["$$element.price","$$element.qty"]},null]},null]},{"$lt":[{"$multiply":["$$element.price","$$element.qty"]},"$budget"]}]}}}]}]}}`),
+
+		// Array literals
+		Entry("len of a literal", "len [1, 2, 3] = 3",
+			`{"$expr":{"$eq":[3,3]}}`),
+		Entry("sum of a literal", "sum [a, b] > 10",
+			`{"$expr":{"$gt":[{"$add":[0,"$a","$b"]},10]}}`),
+		Entry("any of a literal", "any [a > 1, active]",
+			`{"$expr":{"$or":[{"$gt":["$a",1]},{"$eq":["$active",true]}]}}`),
+		Entry("all of an empty literal", "all []",
+			`{"$expr":false}`),
+	)
+
+	It("rejects operands using more than one array", func() {
+		_, err := extJSON("any (tags = labels)", documents...)
+		Expect(err).To(MatchError(tsl.ArrayOperandError{Operator: tsl.OpAny, Arrays: []string{"labels", "tags"}}))
+	})
+})
@@ -0,0 +1,207 @@
+package mongo
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// arithmeticOperators are the aggregation operators of the arithmetic
+var arithmeticOperators = map[tsl.Operator]string{
+	tsl.OpPlus: "$add", tsl.OpMinus: "$subtract",
+	tsl.OpStar: "$multiply", tsl.OpSlash: "$divide", tsl.OpPercent: "$mod",
+}
+
+// condition converts a node to a boolean aggregation expression, identifiers
+// are boolean fields
+func (w *walker) condition(n *tsl.TSLNode) (interface{}, error) {
+	e, err := w.expression(n)
+	if err != nil || n.Type() != tsl.KindIdentifier {
+		return e, err
+	}
+	return bson.D{{Key: "$eq", Value: bson.A{e, true}}}, nil
+}
+
+// expression converts a node to an aggregation expression, used in $expr
+func (w *walker) expression(n *tsl.TSLNode) (interface{}, error) {
+	switch n.Type() {
+	case tsl.KindIdentifier:
+		return w.fieldPath(n.Value().(string))
+	case tsl.KindBinaryExpr:
+		return w.binaryExpression(n.Value().(tsl.TSLExpressionOp))
+	case tsl.KindUnaryExpr:
+		return w.unaryExpression(n.Value().(tsl.TSLExpressionOp))
+	case tsl.KindArrayLiteral:
+		array := n.Value().(tsl.TSLArrayLiteral)
+		values := make(bson.A, len(array.Values))
+		for i, node := range array.Values {
+			value, err := w.expression(node)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+
+	value, ok := w.literal(n)
+	if !ok {
+		return nil, tsl.UnexpectedLiteralError{Literal: n.Type()}
+	}
+
+	// Strings starting with $ are field paths in expressions
+	if s, ok := value.(string); ok && strings.HasPrefix(s, "$") {
+		return bson.D{{Key: "$literal", Value: s}}, nil
+	}
+	return value, nil
+}
+
+// fieldPath returns the field path expression of an identifier, e.g.
+// "$spec.pages", the element of an ANY or ALL operand is "$$element"
+func (w *walker) fieldPath(identifier string) (interface{}, error) {
+	field, err := w.field(identifier)
+	if err != nil {
+		return nil, err
+	}
+	if w.element != "" {
+		if field == w.element {
+			return "$$element", nil
+		}
+		if rest, ok := strings.CutPrefix(field, w.element+"."); ok {
+			return "$$element." + rest, nil
+		}
+	}
+	return "$" + field, nil
+}
+
+// binaryExpression converts a binary expression to an aggregation expression
+func (w *walker) binaryExpression(op tsl.TSLExpressionOp) (interface{}, error) {
+	switch op.Operator {
+	case tsl.OpAnd, tsl.OpOr:
+		l, err := w.condition(op.Left)
+		if err != nil {
+			return nil, err
+		}
+		r, err := w.condition(op.Right)
+		if err != nil {
+			return nil, err
+		}
+		key := "$and"
+		if op.Operator == tsl.OpOr {
+			key = "$or"
+		}
+		return bson.D{{Key: key, Value: bson.A{l, r}}}, nil
+	case tsl.OpWithin:
+		return nil, tsl.UnsupportedOperatorError{Operator: op.Operator, Dialect: "mongo"}
+	}
+	if orderedVersions(op) {
+		return nil, tsl.UnsupportedOperatorError{Operator: op.Operator, Dialect: "mongo"}
+	}
+
+	l, err := w.expression(op.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Operator {
+	case tsl.OpIs:
+		return isNull(l), nil
+	case tsl.OpLike, tsl.OpILike, tsl.OpREQ, tsl.OpRNE:
+		value, _ := w.literal(op.Right)
+		regex, err := patternRegex(op.Operator, value)
+		if err != nil {
+			return nil, err
+		}
+		match := bson.D{{Key: "$regexMatch", Value: bson.D{
+			{Key: "input", Value: l},
+			{Key: "regex", Value: regex.Pattern},
+			{Key: "options", Value: regex.Options},
+		}}}
+		if op.Operator == tsl.OpRNE {
+			return bson.D{{Key: "$not", Value: bson.A{match}}}, nil
+		}
+		return match, nil
+	}
+
+	r, err := w.expression(op.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	if operator, ok := arithmeticOperators[op.Operator]; ok {
+		return bson.D{{Key: operator, Value: bson.A{l, r}}}, nil
+	}
+
+	switch op.Operator {
+	case tsl.OpEQ, tsl.OpNE:
+		return bson.D{{Key: comparisonOperators[op.Operator], Value: bson.A{l, r}}}, nil
+	case tsl.OpLT, tsl.OpLE:
+		return notNullAnd(op.Left, l, bson.D{{Key: comparisonOperators[op.Operator], Value: bson.A{l, r}}}), nil
+	case tsl.OpGT, tsl.OpGE:
+		return notNullAnd(op.Right, r, bson.D{{Key: comparisonOperators[op.Operator], Value: bson.A{l, r}}}), nil
+	case tsl.OpIn:
+		if op.Right.Type() != tsl.KindArrayLiteral {
+			r = bson.D{{Key: "$ifNull", Value: bson.A{r, bson.A{}}}}
+		}
+		return bson.D{{Key: "$in", Value: bson.A{l, r}}}, nil
+	case tsl.OpBetween:
+		values, ok := r.(bson.A)
+		if !ok || len(values) != 2 {
+			return nil, tsl.BetweenOperatorError{Message: "BETWEEN requires exactly two values"}
+		}
+		return notNullAnd(op.Left, l,
+			bson.D{{Key: "$gte", Value: bson.A{l, values[0]}}},
+			bson.D{{Key: "$lte", Value: bson.A{l, values[1]}}},
+		), nil
+	}
+	return nil, tsl.UnexpectedOperatorError{Operator: op.Operator}
+}
+
+// unaryExpression converts a unary expression to an aggregation expression
+func (w *walker) unaryExpression(op tsl.TSLExpressionOp) (interface{}, error) {
+	switch op.Operator {
+	case tsl.OpLen, tsl.OpAny, tsl.OpAll, tsl.OpSum:
+		return w.arrayExpression(op)
+	case tsl.OpNot:
+		r, err := w.condition(op.Right)
+		if err != nil {
+			return nil, err
+		}
+		return bson.D{{Key: "$not", Value: bson.A{r}}}, nil
+	case tsl.OpUMinus:
+		r, err := w.expression(op.Right)
+		if err != nil {
+			return nil, err
+		}
+		return bson.D{{Key: "$multiply", Value: bson.A{int64(-1), r}}}, nil
+	}
+
+	// Aggregate functions have no meaning in a filter
+	if tsl.IsAggregate(op.Operator) {
+		return nil, tsl.UnsupportedOperatorError{Operator: op.Operator, Dialect: "mongo"}
+	}
+	return nil, tsl.UnexpectedOperatorError{Operator: op.Operator}
+}
+
+// isNull returns the expression testing if a value is null or missing
+func isNull(e interface{}) bson.D {
+	return bson.D{{Key: "$eq", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{e, nil}}}, nil}}}
+}
+
+// notNullAnd returns the conjunction of ordering comparisons, with a test
+// that the operand is not null when it is not a literal. Null values and
+// missing fields order before other values in expressions, so "$age" < 5 is
+// true when age is missing, in TSL comparisons with null are false.
+func notNullAnd(operand *tsl.TSLNode, e interface{}, comparisons ...interface{}) interface{} {
+	switch operand.Type() {
+	case tsl.KindIdentifier, tsl.KindBinaryExpr, tsl.KindUnaryExpr:
+		notNull := bson.D{{Key: "$ne", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{e, nil}}}, nil}}}
+		return bson.D{{Key: "$and", Value: append(bson.A{notNull}, comparisons...)}}
+	}
+	if len(comparisons) == 1 {
+		return comparisons[0]
+	}
+	return bson.D{{Key: "$and", Value: bson.A(comparisons)}}
+}
@@ -0,0 +1,533 @@
+// Copyright 2018 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mongo helps to create MongoDB filter documents using the TSL
+// package.
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// Walk travel the TSL tree to create a MongoDB filter document.
+//
+// Users can pass the filter to a collection Find.
+//
+//	filter, _ := mongo.Walk(tree)
+//	cursor, _ := collection.Find(ctx, filter)
+//
+// mongo-go-driver: https://go.mongodb.org/mongo-driver
+//
+// Identifiers are used as field paths, e.g. spec.pages or pods[0].status,
+// use WalkWithOptions to map identifiers to fields.
+func Walk(n *tsl.TSLNode) (bson.D, error) {
+	return WalkWithOptions(n, WithPlainFields())
+}
+
+// WalkWithOptions travel the TSL tree to create a MongoDB filter document.
+//
+// Identifiers must be mapped to fields using WithFields or
+// WithAllowedFields, other identifiers return a tsl.UnmappedIdentifierError.
+//
+// Comparisons of a field with literals use query operators, e.g.
+// {"age": {"$gt": 20}}, other comparisons, e.g. arithmetic or comparisons of
+// two fields, use aggregation expressions in $expr.
+//
+//	filter, _ := mongo.WalkWithOptions(tree,
+//	  mongo.WithFields(map[string]string{"pages": "spec.pages"}),
+//	  mongo.WithAllowedFields("title", "author"),
+//	)
+func WalkWithOptions(n *tsl.TSLNode, opts ...Option) (bson.D, error) {
+	w := &walker{}
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+	return w.filter(n)
+}
+
+// ToMap returns a filter document as a map, for APIs that take
+// map[string]interface{} documents, nested documents are maps too.
+func ToMap(d bson.D) map[string]interface{} {
+	m := make(map[string]interface{}, len(d))
+	for _, e := range d {
+		m[e.Key] = toMapValue(e.Value)
+	}
+	return m
+}
+
+// toMapValue converts the documents of a value to maps
+func toMapValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case bson.D:
+		return ToMap(v)
+	case bson.A:
+		values := make([]interface{}, len(v))
+		for i, value := range v {
+			values[i] = toMapValue(value)
+		}
+		return values
+	}
+	return v
+}
+
+// Option sets an option of the conversion
+type Option func(*options)
+
+// options holds the options of a conversion
+type options struct {
+	// fields maps TSL identifiers to field paths, identifiers that are not
+	// mapped are rejected
+	fields map[string]string
+
+	// plainFields passes identifiers that are valid field paths as is
+	plainFields bool
+
+	// documentArrays holds the field paths of arrays of documents
+	documentArrays map[string]bool
+}
+
+// WithFields maps TSL identifiers to field paths, e.g. "pages" to
+// "spec.pages". Identifiers that are not mapped, or allowed using
+// WithAllowedFields, are rejected.
+func WithFields(fields map[string]string) Option {
+	return func(o *options) {
+		if o.fields == nil {
+			o.fields = map[string]string{}
+		}
+		for identifier, field := range fields {
+			o.fields[identifier] = field
+		}
+	}
+}
+
+// WithAllowedFields allows TSL identifiers used as field paths as is.
+func WithAllowedFields(names ...string) Option {
+	return func(o *options) {
+		if o.fields == nil {
+			o.fields = map[string]string{}
+		}
+		for _, name := range names {
+			o.fields[name] = name
+		}
+	}
+}
+
+// WithPlainFields allows the identifiers that are valid field paths as is,
+// as Walk does, mapped identifiers still use their fields.
+func WithPlainFields() Option {
+	return func(o *options) {
+		o.plainFields = true
+	}
+}
+
+// walker holds the options of a conversion
+type walker struct {
+	opts options
+
+	// element is the field path of the array whose elements the operand of
+	// an ANY or ALL operator tests, and document is set when the elements
+	// are documents
+	element  string
+	document bool
+
+	// elementMatch is set when walking the filter of an $elemMatch, that
+	// can not use $expr
+	elementMatch bool
+}
+
+// errNeedsExpression is returned by the walker of an $elemMatch filter that
+// needs an aggregation expression
+var errNeedsExpression = errors.New("filter needs an aggregation expression")
+
+// filter converts a node to a filter document
+func (w *walker) filter(n *tsl.TSLNode) (bson.D, error) {
+	switch n.Type() {
+	case tsl.KindIdentifier:
+		// Identifiers are boolean fields
+		field, err := w.queryField(n.Value().(string))
+		if err != nil {
+			return nil, err
+		}
+		return condition(field, bson.D{{Key: "$eq", Value: true}}), nil
+
+	case tsl.KindBinaryExpr:
+		op := n.Value().(tsl.TSLExpressionOp)
+		if op.Operator == tsl.OpAnd || op.Operator == tsl.OpOr {
+			return w.logical(op)
+		}
+		if d, ok, err := w.comparison(op); err != nil || ok {
+			return d, err
+		}
+
+	case tsl.KindUnaryExpr:
+		op := n.Value().(tsl.TSLExpressionOp)
+		switch op.Operator {
+		case tsl.OpNot:
+			d, err := w.filter(op.Right)
+			if err != nil {
+				return nil, err
+			}
+			return negate(d), nil
+		case tsl.OpAny, tsl.OpAll:
+			if d, ok, err := w.elemMatch(op); err != nil || ok {
+				return d, err
+			}
+		}
+	}
+
+	// Other filters use aggregation expressions
+	if w.elementMatch {
+		return nil, errNeedsExpression
+	}
+	e, err := w.condition(n)
+	if err != nil {
+		return nil, err
+	}
+	return bson.D{{Key: "$expr", Value: e}}, nil
+}
+
+// logical converts the AND and OR operators, nested operators of the same
+// kind are flattened, e.g. a and (b and c) is one $and
+func (w *walker) logical(op tsl.TSLExpressionOp) (bson.D, error) {
+	key := "$and"
+	if op.Operator == tsl.OpOr {
+		key = "$or"
+	}
+
+	filters := bson.A{}
+	for _, n := range []*tsl.TSLNode{op.Left, op.Right} {
+		d, err := w.filter(n)
+		if err != nil {
+			return nil, err
+		}
+		if len(d) == 1 && d[0].Key == key {
+			filters = append(filters, d[0].Value.(bson.A)...)
+			continue
+		}
+		filters = append(filters, d)
+	}
+	return bson.D{{Key: key, Value: filters}}, nil
+}
+
+// comparisonOperators are the query operators of the comparisons
+var comparisonOperators = map[tsl.Operator]string{
+	tsl.OpEQ: "$eq", tsl.OpNE: "$ne",
+	tsl.OpLT: "$lt", tsl.OpLE: "$lte",
+	tsl.OpGT: "$gt", tsl.OpGE: "$gte",
+}
+
+// flippedComparisons are the comparisons with swapped operands
+var flippedComparisons = map[tsl.Operator]tsl.Operator{
+	tsl.OpEQ: tsl.OpEQ, tsl.OpNE: tsl.OpNE,
+	tsl.OpLT: tsl.OpGT, tsl.OpLE: tsl.OpGE,
+	tsl.OpGT: tsl.OpLT, tsl.OpGE: tsl.OpLE,
+}
+
+// comparison converts the comparison of a field with literals to query
+// operators, ok is false when the comparison needs an aggregation expression
+func (w *walker) comparison(op tsl.TSLExpressionOp) (d bson.D, ok bool, err error) {
+	operator, left, right := op.Operator, op.Left, op.Right
+	if orderedVersions(op) {
+		return nil, false, tsl.UnsupportedOperatorError{Operator: operator, Dialect: "mongo"}
+	}
+
+	// Literals on the left are flipped, 5 < age is age > 5
+	if flipped, ok := flippedComparisons[operator]; ok && isField(right) && !isField(left) {
+		operator, left, right = flipped, right, left
+	}
+	if !isField(left) {
+		return nil, false, nil
+	}
+	value, ok := w.literal(right)
+	if !ok {
+		return nil, false, nil
+	}
+
+	// len tags = 3 is the $size of the array
+	if left.Type() == tsl.KindUnaryExpr {
+		size, isInt := value.(int64)
+		if operator != tsl.OpEQ || !isInt || size < 0 {
+			return nil, false, nil
+		}
+		field, err := w.queryField(left.Value().(tsl.TSLExpressionOp).Right.Value().(string))
+		if err != nil {
+			return nil, false, err
+		}
+		return condition(field, bson.D{{Key: "$size", Value: size}}), true, nil
+	}
+
+	field, err := w.queryField(left.Value().(string))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var cond bson.D
+	switch operator {
+	case tsl.OpEQ, tsl.OpNE, tsl.OpLT, tsl.OpLE, tsl.OpGT, tsl.OpGE:
+		cond = bson.D{{Key: comparisonOperators[operator], Value: value}}
+	case tsl.OpIn:
+		values, ok := value.(bson.A)
+		if !ok {
+			return nil, false, tsl.UnexpectedTypeError{Type: right.Type()}
+		}
+		cond = bson.D{{Key: "$in", Value: values}}
+	case tsl.OpBetween:
+		values, ok := value.(bson.A)
+		if !ok || len(values) != 2 {
+			return nil, false, tsl.BetweenOperatorError{Message: "BETWEEN requires exactly two values"}
+		}
+		cond = bson.D{{Key: "$gte", Value: values[0]}, {Key: "$lte", Value: values[1]}}
+	case tsl.OpLike, tsl.OpILike, tsl.OpREQ, tsl.OpRNE:
+		regex, err := patternRegex(operator, value)
+		if err != nil {
+			return nil, false, err
+		}
+		cond = bson.D{{Key: "$regex", Value: regex}}
+		if operator == tsl.OpRNE {
+			cond = bson.D{{Key: "$not", Value: regex}}
+		}
+	case tsl.OpIs:
+		// $eq null matches null values and missing fields
+		cond = bson.D{{Key: "$eq", Value: nil}}
+	case tsl.OpWithin:
+		return nil, false, tsl.UnsupportedOperatorError{Operator: operator, Dialect: "mongo"}
+	default:
+		return nil, false, nil
+	}
+	return condition(field, cond), true, nil
+}
+
+// orderedVersions reports whether an ordering comparison or BETWEEN uses a
+// version literal, MongoDB compares strings by their bytes, so v1.10.0 would
+// sort before v1.9.0
+func orderedVersions(op tsl.TSLExpressionOp) bool {
+	switch op.Operator {
+	case tsl.OpLT, tsl.OpLE, tsl.OpGT, tsl.OpGE, tsl.OpBetween:
+	default:
+		return false
+	}
+
+	nodes := []*tsl.TSLNode{op.Left, op.Right}
+	if op.Right.Type() == tsl.KindArrayLiteral {
+		nodes = append(nodes, op.Right.Value().(tsl.TSLArrayLiteral).Values...)
+	}
+	for _, n := range nodes {
+		if n.Type() == tsl.KindVersionLiteral {
+			return true
+		}
+	}
+	return false
+}
+
+// condition returns the filter document of the operators of a field
+func condition(field string, cond bson.D) bson.D {
+	return bson.D{{Key: field, Value: cond}}
+}
+
+// isField reports if a node is an identifier, or the LEN of an identifier
+func isField(n *tsl.TSLNode) bool {
+	switch n.Type() {
+	case tsl.KindIdentifier:
+		return true
+	case tsl.KindUnaryExpr:
+		op := n.Value().(tsl.TSLExpressionOp)
+		return op.Operator == tsl.OpLen && op.Right.Type() == tsl.KindIdentifier
+	}
+	return false
+}
+
+// negate returns the negation of a filter document, the operators of one
+// field are negated using $not, other filters using $nor
+func negate(d bson.D) bson.D {
+	if len(d) == 1 {
+		switch e := d[0]; {
+		case e.Key == "$nor" && len(e.Value.(bson.A)) == 1:
+			return e.Value.(bson.A)[0].(bson.D)
+		case !strings.HasPrefix(e.Key, "$"):
+			return condition(e.Key, negateCondition(e.Value.(bson.D)))
+		}
+	}
+	return bson.D{{Key: "$nor", Value: bson.A{d}}}
+}
+
+// negatedOperators are the query operators with a negated operator
+var negatedOperators = map[string]string{
+	"$eq": "$ne", "$ne": "$eq", "$in": "$nin", "$nin": "$in",
+}
+
+// negateCondition returns the negation of the operators of a field
+func negateCondition(cond bson.D) bson.D {
+	if len(cond) == 1 {
+		e := cond[0]
+		if negated, ok := negatedOperators[e.Key]; ok {
+			return bson.D{{Key: negated, Value: e.Value}}
+		}
+		switch e.Key {
+		case "$regex":
+			return bson.D{{Key: "$not", Value: e.Value}}
+		case "$not":
+			if regex, ok := e.Value.(bson.Regex); ok {
+				return bson.D{{Key: "$regex", Value: regex}}
+			}
+			return e.Value.(bson.D)
+		}
+	}
+	return bson.D{{Key: "$not", Value: cond}}
+}
+
+// patternRegex returns the regular expression of a LIKE, ILIKE or regular
+// expression operator, LIKE patterns match whole values, and ILIKE ignores
+// case
+func patternRegex(operator tsl.Operator, value interface{}) (bson.Regex, error) {
+	pattern, ok := value.(string)
+	if !ok {
+		return bson.Regex{}, tsl.TypeMismatchError{Expected: "string pattern", Got: value}
+	}
+	switch operator {
+	case tsl.OpLike:
+		return bson.Regex{Pattern: likePattern(pattern), Options: "s"}, nil
+	case tsl.OpILike:
+		return bson.Regex{Pattern: likePattern(pattern), Options: "is"}, nil
+	}
+	return bson.Regex{Pattern: pattern}, nil
+}
+
+// likePattern converts an SQL LIKE pattern to a regular expression, % and _
+// are wildcards, a backslash escapes the next character, and other
+// characters match themselves
+func likePattern(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '%':
+			b.WriteString(".*")
+		case c == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if escaped {
+		// A trailing backslash matches itself
+		b.WriteString(`\\`)
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// literal returns the value of a literal node, ok is false for other nodes.
+// Decimals are Decimal128 values, dates are midnight UTC, and IP addresses,
+// CIDR prefixes and versions are passed in their text form, versions are
+// only compared for equality (see orderedVersions).
+func (w *walker) literal(n *tsl.TSLNode) (interface{}, bool) {
+	switch n.Type() {
+	case tsl.KindNumericLiteral:
+		if d, ok := n.Value().(tsl.Decimal); ok {
+			return decimal(d), true
+		}
+		return n.Value(), true
+	case tsl.KindStringLiteral, tsl.KindBooleanLiteral, tsl.KindTimestampLiteral:
+		return n.Value(), true
+	case tsl.KindDateLiteral:
+		if t, err := time.Parse("2006-01-02", n.Value().(string)); err == nil {
+			return t, true
+		}
+		return n.Value(), true
+	case tsl.KindIPLiteral, tsl.KindCIDRLiteral, tsl.KindVersionLiteral:
+		return fmt.Sprintf("%v", n.Value()), true
+	case tsl.KindNullLiteral:
+		return nil, true
+	case tsl.KindArrayLiteral:
+		array := n.Value().(tsl.TSLArrayLiteral)
+		values := make(bson.A, len(array.Values))
+		for i, node := range array.Values {
+			value, ok := w.literal(node)
+			if !ok {
+				return nil, false
+			}
+			values[i] = value
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// decimal returns the Decimal128 value of a decimal, or its nearest float64
+// value when it does not fit
+func decimal(d tsl.Decimal) interface{} {
+	if value, err := bson.ParseDecimal128(d.String()); err == nil {
+		return value
+	}
+	return d.Float64()
+}
+
+// field returns the field path of an identifier
+func (w *walker) field(identifier string) (string, error) {
+	if field, ok := w.opts.fields[identifier]; ok {
+		return field, nil
+	}
+	if !w.opts.plainFields {
+		return "", tsl.UnmappedIdentifierError{Identifier: identifier}
+	}
+	field := arraySuffixes.Replace(identifier)
+	if !isFieldPath(field) {
+		return "", tsl.InvalidIdentifierError{Identifier: identifier}
+	}
+	return field, nil
+}
+
+// arraySuffixes converts the array suffixes of identifiers to field path
+// parts, e.g. pods[0].status is pods.0.status, [*] is dropped as field paths
+// match any element of an array
+var arraySuffixes = strings.NewReplacer("[*]", "", "[", ".", "]", "")
+
+// queryField returns the field path of an identifier in a filter document,
+// relative to the element in the filter of an $elemMatch of documents
+func (w *walker) queryField(identifier string) (string, error) {
+	field, err := w.field(identifier)
+	if err != nil || !w.elementMatch || !w.document {
+		return field, err
+	}
+	if rest, ok := strings.CutPrefix(field, w.element+"."); ok {
+		return rest, nil
+	}
+	return "", errNeedsExpression
+}
+
+// isFieldPath reports if a name is made of dot separated field names, that
+// are not empty and do not start with $
+func isFieldPath(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if part == "" || strings.HasPrefix(part, "$") || strings.ContainsRune(part, 0) {
+			return false
+		}
+	}
+	return true
+}
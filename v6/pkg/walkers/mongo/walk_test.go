@@ -0,0 +1,225 @@
+// Copyright 2018 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+func TestMongoWalker(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Mongo walker")
+}
+
+// extJSON returns the relaxed extended JSON of the filter of a TSL input
+func extJSON(input string, opts ...Option) (string, error) {
+	tree, err := tsl.ParseTSL(input)
+	Expect(err).ToNot(HaveOccurred())
+
+	filter, err := Walk(tree)
+	if len(opts) > 0 {
+		filter, err = WalkWithOptions(tree, opts...)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	b, err := bson.MarshalExtJSON(filter, false, false)
+	Expect(err).ToNot(HaveOccurred())
+	return string(b), nil
+}
+
+var _ = Describe("Walk", func() {
+	DescribeTable("Generates the expected filter documents",
+		func(input string, expected string) {
+			actual, err := extJSON(input)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(expected))
+		},
+
+		// Comparisons
+		Entry("equal", "name = 'joe'",
+			`{"name":{"$eq":"joe"}}`),
+		Entry("not equal", "name != 'joe'",
+			`{"name":{"$ne":"joe"}}`),
+		Entry("less than", "age < 20",
+			`{"age":{"$lt":20}}`),
+		Entry("greater or equal", "age >= 20",
+			`{"age":{"$gte":20}}`),
+		Entry("literal on the left", "20 < age",
+			`{"age":{"$gt":20}}`),
+		Entry("decimal", "rating >= 4.5",
+			`{"rating":{"$gte":{"$numberDecimal":"4.5"}}}`),
+		Entry("boolean", "active = true",
+			`{"active":{"$eq":true}}`),
+		Entry("boolean field", "active",
+			`{"active":{"$eq":true}}`),
+		Entry("date", "joined > 2024-01-31",
+			`{"joined":{"$gt":{"$date":"2024-01-31T00:00:00Z"}}}`),
+		Entry("timestamp", "joined <= 2024-01-31T10:00:00Z",
+			`{"joined":{"$lte":{"$date":"2024-01-31T10:00:00Z"}}}`),
+		Entry("version", "version = v1.28.0",
+			`{"version":{"$eq":"v1.28.0"}}`),
+		Entry("nested field", "spec.pages > 100",
+			`{"spec.pages":{"$gt":100}}`),
+		Entry("array index", "pods[0].status = 'Running'",
+			`{"pods.0.status":{"$eq":"Running"}}`),
+		Entry("any array element", "spec.tags[*] = 'go'",
+			`{"spec.tags":{"$eq":"go"}}`),
+
+		// Sets and ranges
+		Entry("in", "city in ['rome', 'paris']",
+			`{"city":{"$in":["rome","paris"]}}`),
+		Entry("not in", "city not in ['rome', 'paris']",
+			`{"city":{"$nin":["rome","paris"]}}`),
+		Entry("between", "age between 20 and 30",
+			`{"age":{"$gte":20,"$lte":30}}`),
+		Entry("not between", "age not between 20 and 30",
+			`{"age":{"$not":{"$gte":20,"$lte":30}}}`),
+
+		// Patterns
+		Entry("like", "name like 'jo%'",
+			`{"name":{"$regex":{"$regularExpression":{"pattern":"^jo.*$","options":"s"}}}}`),
+		Entry("like quotes other characters", `name like 'a.b_\\%'`,
+			`{"name":{"$regex":{"$regularExpression":{"pattern":"^a\\.b.%$","options":"s"}}}}`),
+		Entry("ilike", "name ilike 'jo%'",
+			`{"name":{"$regex":{"$regularExpression":{"pattern":"^jo.*$","options":"is"}}}}`),
+		Entry("not like", "name not like 'jo%'",
+			`{"name":{"$not":{"$regularExpression":{"pattern":"^jo.*$","options":"s"}}}}`),
+		Entry("regular expression", `email ~= '.*@gmail\\.com'`,
+			`{"email":{"$regex":{"$regularExpression":{"pattern":".*@gmail\\.com","options":""}}}}`),
+		Entry("negated regular expression", "email ~! '^admin'",
+			`{"email":{"$not":{"$regularExpression":{"pattern":"^admin","options":""}}}}`),
+		Entry("double negated regular expression", "not (email ~! '^admin')",
+			`{"email":{"$regex":{"$regularExpression":{"pattern":"^admin","options":""}}}}`),
+
+		// Null values
+		Entry("is null", "email is null",
+			`{"email":{"$eq":null}}`),
+		Entry("is not null", "email is not null",
+			`{"email":{"$ne":null}}`),
+
+		// Logical operators
+		Entry("and", "name = 'joe' and age > 20",
+			`{"$and":[{"name":{"$eq":"joe"}},{"age":{"$gt":20}}]}`),
+		Entry("flattened and", "a = 1 and b = 2 and c = 3",
+			`{"$and":[{"a":{"$eq":1}},{"b":{"$eq":2}},{"c":{"$eq":3}}]}`),
+		Entry("or", "name = 'joe' or name = 'jane'",
+			`{"$or":[{"name":{"$eq":"joe"}},{"name":{"$eq":"jane"}}]}`),
+		Entry("not field", "not active",
+			`{"active":{"$ne":true}}`),
+		Entry("not greater than", "not (age > 20)",
+			`{"age":{"$not":{"$gt":20}}}`),
+		Entry("not or", "not (name = 'joe' or age > 20)",
+			`{"$nor":[{"$or":[{"name":{"$eq":"joe"}},{"age":{"$gt":20}}]}]}`),
+		Entry("double not or", "not not (name = 'joe' or age > 20)",
+			`{"$or":[{"name":{"$eq":"joe"}},{"age":{"$gt":20}}]}`),
+
+		// Aggregation expressions
+		Entry("arithmetic", "price * quantity > 100",
+			`{"$expr":{"$gt":[{"$multiply":["$price","$quantity"]},100]}}`),
+		Entry("two fields", "updated > created",
+			`{"$expr":{"$and":[{"$ne":[{"$ifNull":["$created",null]},null]},{"$gt":["$updated","$created"]}]}}`),
+		Entry("less than a field", "price + 5 < budget",
+			`{"$expr":{"$and":[{"$ne":[{"$ifNull":[{"$add":["$price",5]},null]},null]},{"$lt":[{"$add":["$price",5]},"$budget"]}]}}`),
+		Entry("equal fields", "a = b",
+			`{"$expr":{"$eq":["$a","$b"]}}`),
+		Entry("modulo", "id % 2 = 0",
+			`{"$expr":{"$eq":[{"$mod":["$id",2]},0]}}`),
+		Entry("unary minus", "-balance > 10",
+			`{"$expr":{"$gt":[{"$multiply":[-1,"$balance"]},10]}}`),
+		Entry("in a field", "'admin' in roles",
+			`{"$expr":{"$in":["admin",{"$ifNull":["$roles",[]]}]}}`),
+		Entry("string starting with $", "a + 1 = 2 or name = b + '$x'",
+			`{"$or":[{"$expr":{"$eq":[{"$add":["$a",1]},2]}},{"$expr":{"$eq":["$name",{"$add":["$b",{"$literal":"$x"}]}]}}]}`),
+		Entry("mixed", "name = 'joe' and price * 2 >= 10",
+			`{"$and":[{"name":{"$eq":"joe"}},{"$expr":{"$gte":[{"$multiply":["$price",2]},10]}}]}`),
+		Entry("not expression", "not (a = b)",
+			`{"$nor":[{"$expr":{"$eq":["$a","$b"]}}]}`),
+	)
+
+	DescribeTable("Rejects unsupported filters",
+		func(input string, expected error) {
+			_, err := extJSON(input)
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("within", "ip within 10.0.0.0/8",
+			tsl.UnsupportedOperatorError{Operator: tsl.OpWithin, Dialect: "mongo"}),
+		Entry("operator in a field name", "a[$where] = 1",
+			tsl.InvalidIdentifierError{Identifier: "a[$where]"}),
+		Entry("empty field name", "a..b = 1",
+			tsl.InvalidIdentifierError{Identifier: "a..b"}),
+		Entry("version ordering", "version >= v1.28.0",
+			tsl.UnsupportedOperatorError{Operator: tsl.OpGE, Dialect: "mongo"}),
+		Entry("version on the left", "v1.9.0 < version",
+			tsl.UnsupportedOperatorError{Operator: tsl.OpLT, Dialect: "mongo"}),
+		Entry("version between", "version between v1.2.0 and v1.10.0",
+			tsl.UnsupportedOperatorError{Operator: tsl.OpBetween, Dialect: "mongo"}),
+		Entry("version in an expression", "any [version > v1.2.0, active]",
+			tsl.UnsupportedOperatorError{Operator: tsl.OpGT, Dialect: "mongo"}),
+		Entry("like a field", "name like pattern",
+			tsl.TypeMismatchError{Expected: "string pattern", Got: nil}),
+	)
+
+	It("maps identifiers to fields", func() {
+		opts := []Option{
+			WithFields(map[string]string{"pages": "spec.pages"}),
+			WithAllowedFields("title"),
+		}
+		actual, err := extJSON("pages > 100 and title = 'Dune'", opts...)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual).To(Equal(`{"$and":[{"spec.pages":{"$gt":100}},{"title":{"$eq":"Dune"}}]}`))
+
+		_, err = extJSON("author = 'Herbert'", opts...)
+		Expect(err).To(MatchError(tsl.UnmappedIdentifierError{Identifier: "author"}))
+
+		actual, err = extJSON("pages > 100 and author = 'Herbert'", append(opts, WithPlainFields())...)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual).To(Equal(`{"$and":[{"spec.pages":{"$gt":100}},{"author":{"$eq":"Herbert"}}]}`))
+	})
+
+	It("returns bson documents and maps", func() {
+		tree, err := tsl.ParseTSL("name = 'joe' and (joined < 2024-01-31 or tags in ['a', 'b'])")
+		Expect(err).ToNot(HaveOccurred())
+
+		filter, err := Walk(tree)
+		Expect(err).ToNot(HaveOccurred())
+		joined := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+		Expect(filter).To(Equal(bson.D{{Key: "$and", Value: bson.A{
+			bson.D{{Key: "name", Value: bson.D{{Key: "$eq", Value: "joe"}}}},
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "joined", Value: bson.D{{Key: "$lt", Value: joined}}}},
+				bson.D{{Key: "tags", Value: bson.D{{Key: "$in", Value: bson.A{"a", "b"}}}}},
+			}}},
+		}}}))
+
+		Expect(ToMap(filter)).To(Equal(map[string]interface{}{"$and": []interface{}{
+			map[string]interface{}{"name": map[string]interface{}{"$eq": "joe"}},
+			map[string]interface{}{"$or": []interface{}{
+				map[string]interface{}{"joined": map[string]interface{}{"$lt": joined}},
+				map[string]interface{}{"tags": map[string]interface{}{"$in": []interface{}{"a", "b"}}},
+			}},
+		}}))
+	})
+})
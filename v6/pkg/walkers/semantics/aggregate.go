@@ -0,0 +1,307 @@
+package semantics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// Aggregate evaluates a grouped query, and returns one output record per group
+// keyed by the SELECT item names (see tsl.SelectItem.Name).
+//
+// Records matching the filter are grouped by the GROUP BY keys, groups are
+// kept in order of first appearance, without GROUP BY all the records form a
+// single group. HAVING filters the groups, and ORDER BY, LIMIT and OFFSET
+// apply to the groups. Aggregate functions (COUNT, SUM, AVG, MIN, MAX) skip
+// null values, other identifiers evaluate using the first record of a group.
+//
+// Example:
+//
+//	query, err := tsl.ParseQuery("SELECT author, COUNT(*) AS books, AVG(pages) GROUP BY author HAVING COUNT(*) > 1")
+//	rows, err := semantics.Aggregate(ctx, query, books, get)
+func Aggregate[T any](ctx context.Context, query *tsl.Query, records []T, get Accessor[T], opts ...Option) ([]map[string]interface{}, error) {
+	items := query.Select
+	if len(items) == 0 {
+		for _, key := range query.GroupBy {
+			items = append(items, tsl.SelectItem{Expr: key})
+		}
+	}
+	for _, item := range items {
+		if item.Star {
+			return nil, tsl.TypeMismatchError{Expected: "grouped expression", Got: "SELECT * in a grouped query"}
+		}
+	}
+
+	matches := records
+	var err error
+	if query.Filter != nil {
+		matches, err = Filter(ctx, query.Filter, records, get, opts...)
+		if err != nil && matches == nil {
+			return nil, err
+		}
+	}
+
+	groups, groupErr := groupRecords(ctx, query.GroupBy, matches, get, opts...)
+	if groupErr != nil {
+		return nil, errors.Join(err, groupErr)
+	}
+
+	// Filter the groups
+	if query.Having != nil {
+		var kept []recordGroup
+		for _, group := range groups {
+			result, err := group.walk(ctx, query.Having, opts...)
+			if err != nil {
+				return nil, err
+			}
+			matched, ok := result.(bool)
+			if !ok {
+				return nil, tsl.TypeMismatchError{Expected: "boolean", Got: result}
+			}
+			if matched {
+				kept = append(kept, group)
+			}
+		}
+		groups = kept
+	}
+
+	// Sort the groups
+	if len(query.OrderBy) > 0 {
+		var sortErr error
+		if groups, sortErr = sortGroups(ctx, groups, resolveAliases(query), opts...); sortErr != nil {
+			return nil, errors.Join(err, sortErr)
+		}
+	}
+
+	// Paging
+	if query.Offset > 0 {
+		groups = groups[min(query.Offset, len(groups)):]
+	}
+	if query.Limit != nil {
+		groups = groups[:min(*query.Limit, len(groups))]
+	}
+
+	rows := make([]map[string]interface{}, 0, len(groups))
+	for _, group := range groups {
+		row := map[string]interface{}{}
+		for position, item := range items {
+			value, err := group.walk(ctx, item.Expr, opts...)
+			if err != nil {
+				return nil, err
+			}
+			row[item.Name(position)] = value
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, err
+}
+
+// recordGroup is a group of records, non aggregated identifiers evaluate using
+// the first record
+type recordGroup struct {
+	first   EvalContextFunc
+	records []EvalContextFunc
+}
+
+// walk evaluates a tree for a group
+func (g recordGroup) walk(ctx context.Context, n *tsl.TSLNode, opts ...Option) (interface{}, error) {
+	return walkGroup(ctx, n, g.first, g.records, opts...)
+}
+
+// groupRecords groups records by the values of the group keys, in order of
+// first appearance
+func groupRecords[T any](ctx context.Context, keys []*tsl.TSLNode, records []T, get Accessor[T], opts ...Option) ([]recordGroup, error) {
+	if len(keys) == 0 {
+		// A single group, fields of an empty group evaluate as nulls
+		group := recordGroup{first: nullEval, records: []EvalContextFunc{}}
+		for _, record := range records {
+			group.records = append(group.records, nullableEval(record, get))
+		}
+		if len(group.records) > 0 {
+			group.first = group.records[0]
+		}
+		return []recordGroup{group}, nil
+	}
+
+	var groups []recordGroup
+	index := map[string]int{}
+	for i, record := range records {
+		eval := nullableEval(record, get)
+
+		groupKey := ""
+		for _, key := range keys {
+			value, err := WalkContext(ctx, key, eval, opts...)
+			if err != nil {
+				return nil, tsl.RecordError{Index: i, Err: err}
+			}
+			groupKey += groupValueKey(value) + "\x00"
+		}
+
+		if j, ok := index[groupKey]; ok {
+			groups[j].records = append(groups[j].records, eval)
+			continue
+		}
+		index[groupKey] = len(groups)
+		groups = append(groups, recordGroup{first: eval, records: []EvalContextFunc{eval}})
+	}
+	return groups, nil
+}
+
+// groupValueKey returns a string key for a group value, numbers with the same
+// value have the same key regardless of their type, NaN values share a group
+func groupValueKey(value interface{}) string {
+	if num, ok := toNumber(value); ok {
+		if f, ok := num.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			return fmt.Sprintf("float:%v", f)
+		}
+		return "number:" + toRat(num).RatString()
+	}
+	return fmt.Sprintf("%T:%v", value, value)
+}
+
+// nullEval evaluates all fields as nulls
+func nullEval(_ context.Context, _ string) (interface{}, bool, error) {
+	return nil, true, nil
+}
+
+// sortGroups sorts groups by sort keys, the sort is stable
+func sortGroups(ctx context.Context, groups []recordGroup, keys []tsl.SortKey, opts ...Option) ([]recordGroup, error) {
+	w := &walker{ctx: ctx}
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+
+	type sortable struct {
+		group  recordGroup
+		values []interface{}
+	}
+	items := make([]sortable, len(groups))
+	for i, group := range groups {
+		items[i] = sortable{group: group, values: make([]interface{}, len(keys))}
+		for j, key := range keys {
+			value, err := group.walk(ctx, key.Expr, opts...)
+			if err != nil {
+				return nil, err
+			}
+			items[i].values[j] = value
+		}
+	}
+
+	var sortErr error
+	slices.SortStableFunc(items, func(a, b sortable) int {
+		for j, key := range keys {
+			cmp, err := w.compareSortValues(a.values[j], b.values[j])
+			if err != nil {
+				sortErr = err
+				return 0
+			}
+			if key.Desc {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp
+			}
+		}
+		return 0
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+
+	sorted := make([]recordGroup, len(items))
+	for i, item := range items {
+		sorted[i] = item.group
+	}
+	return sorted, nil
+}
+
+// evaluateAggregate evaluates an aggregate function over the records of the
+// group, null values are skipped
+func (w *walker) evaluateAggregate(operator tsl.Operator, operand *tsl.TSLNode) (interface{}, error) {
+	if w.group == nil {
+		return nil, tsl.UnexpectedOperatorError{Operator: operator}
+	}
+
+	// COUNT(*) counts the group records
+	if operand == nil {
+		if operator != tsl.OpCount {
+			return nil, tsl.UnexpectedOperatorError{Operator: operator}
+		}
+		return int64(len(w.group)), nil
+	}
+
+	// Evaluate the operand for each record, aggregates can not be nested
+	eval, group := w.eval, w.group
+	defer func() {
+		w.eval, w.group = eval, group
+	}()
+	w.group = nil
+
+	var values []interface{}
+	for _, recordEval := range group {
+		w.eval = recordEval
+		value, err := w.walk(operand)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			values = append(values, value)
+		}
+	}
+
+	switch operator {
+	case tsl.OpCount:
+		return int64(len(values)), nil
+	case tsl.OpAggSum:
+		return sumValues(values)
+	case tsl.OpAvg:
+		sum, err := sumValues(values)
+		if err != nil || sum == nil {
+			return nil, err
+		}
+		return evaluateArithmetic(tsl.OpSlash, sum, int64(len(values)))
+	case tsl.OpMin, tsl.OpMax:
+		var result interface{}
+		for _, value := range values {
+			if result == nil {
+				result = value
+				continue
+			}
+			cmp, err := w.compareSortValues(value, result)
+			if err != nil {
+				return nil, err
+			}
+			if (operator == tsl.OpMin && cmp < 0) || (operator == tsl.OpMax && cmp > 0) {
+				result = value
+			}
+		}
+		return result, nil
+	default:
+		return nil, tsl.UnexpectedOperatorError{Operator: operator}
+	}
+}
+
+// sumValues adds numeric values, the sum of no values is null
+func sumValues(values []interface{}) (interface{}, error) {
+	var sum interface{}
+	for _, value := range values {
+		if sum == nil {
+			if _, ok := toNumber(value); !ok {
+				return nil, tsl.TypeMismatchError{Expected: "number", Got: fmt.Sprintf("%T", value)}
+			}
+			sum = value
+			continue
+		}
+
+		var err error
+		if sum, err = evaluateArithmetic(tsl.OpPlus, sum, value); err != nil {
+			return nil, err
+		}
+	}
+	return sum, nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2019 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("Aggregate", func() {
+	books := []map[string]interface{}{
+		{"title": "Book A", "author": "Joe", "pages": 100, "year": 2001, "price": 10},
+		{"title": "Book B", "author": "Jane", "pages": 550, "year": 2010},
+		{"title": "Book C", "author": "Joe", "pages": 320, "year": 2015, "price": 25},
+		{"title": "Book D", "author": "Ann", "pages": 200, "year": 1999, "price": 8},
+		{"title": "Book E", "author": "Joe", "pages": 90, "year": 2020, "price": 12},
+	}
+
+	get := func(record map[string]interface{}, name string) (interface{}, bool) {
+		value, ok := record[name]
+		return value, ok
+	}
+
+	run := func(text string) ([]map[string]interface{}, error) {
+		query, err := tsl.ParseQuery(text)
+		Expect(err).ToNot(HaveOccurred())
+
+		return Aggregate(context.Background(), query, books, get)
+	}
+
+	DescribeTable("Returns one output record per group",
+		func(text string, expected []map[string]interface{}) {
+			rows, err := run(text)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rows).To(Equal(expected))
+		},
+
+		Entry("group by with aggregates",
+			"SELECT author, COUNT(*) AS books, SUM(pages) AS pages GROUP BY author",
+			[]map[string]interface{}{
+				{"author": "Joe", "books": int64(3), "pages": int64(510)},
+				{"author": "Jane", "books": int64(1), "pages": int64(550)},
+				{"author": "Ann", "books": int64(1), "pages": int64(200)},
+			}),
+		Entry("nulls are skipped",
+			"SELECT author, COUNT(price) AS priced, MIN(price) AS low, MAX(price) AS high GROUP BY author",
+			[]map[string]interface{}{
+				{"author": "Joe", "priced": int64(3), "low": int64(10), "high": int64(25)},
+				{"author": "Jane", "priced": int64(0), "low": nil, "high": nil},
+				{"author": "Ann", "priced": int64(1), "low": int64(8), "high": int64(8)},
+			}),
+		Entry("average",
+			"SELECT author, AVG(pages) AS pages, AVG(price) AS price WHERE year > 2000 GROUP BY author HAVING COUNT(*) > 1",
+			[]map[string]interface{}{
+				{"author": "Joe", "pages": int64(170), "price": tsl.NewDecimal(big.NewRat(47, 3))},
+			}),
+		Entry("having with order by and limit",
+			"SELECT author, MAX(year) AS latest GROUP BY author HAVING MIN(pages) >= 90 ORDER BY latest DESC LIMIT 2",
+			[]map[string]interface{}{
+				{"author": "Joe", "latest": int64(2020)},
+				{"author": "Jane", "latest": int64(2010)},
+			}),
+		Entry("order by aggregate and offset",
+			"SELECT author GROUP BY author ORDER BY SUM(pages), author OFFSET 1",
+			[]map[string]interface{}{
+				{"author": "Joe"},
+				{"author": "Jane"},
+			}),
+		Entry("whole input group",
+			"SELECT COUNT(*) AS books, MIN(year) AS first WHERE pages > 150",
+			[]map[string]interface{}{
+				{"books": int64(3), "first": int64(1999)},
+			}),
+		Entry("empty input group",
+			"SELECT COUNT(*) AS books, SUM(pages) AS pages WHERE pages > 1000",
+			[]map[string]interface{}{
+				{"books": int64(0), "pages": nil},
+			}),
+		Entry("computed group key",
+			"SELECT year >= 2010 AS recent, COUNT(*) AS books GROUP BY year >= 2010",
+			[]map[string]interface{}{
+				{"recent": false, "books": int64(2)},
+				{"recent": true, "books": int64(3)},
+			}),
+	)
+
+	DescribeTable("Reports errors",
+		func(text string, target error) {
+			_, err := run(text)
+			Expect(err).To(BeAssignableToTypeOf(target))
+		},
+
+		Entry("select star", "SELECT * GROUP BY author", tsl.TypeMismatchError{}),
+		Entry("sum of strings", "SELECT SUM(title) GROUP BY author", tsl.TypeMismatchError{}),
+		Entry("non boolean having", "SELECT author GROUP BY author HAVING COUNT(*)", tsl.TypeMismatchError{}),
+	)
+
+	It("returns the group keys when the query has no SELECT items", func() {
+		query, err := tsl.ParseQuery("SELECT author GROUP BY author HAVING COUNT(*) = 1")
+		Expect(err).ToNot(HaveOccurred())
+		query.Select = nil
+
+		rows, err := Aggregate(context.Background(), query, books, get)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rows).To(Equal([]map[string]interface{}{{"author": "Jane"}, {"author": "Ann"}}))
+	})
+
+	It("groups float keys of JSON records by value", func() {
+		var reviews []map[string]interface{}
+		err := json.Unmarshal([]byte(`[
+			{"rating": 4.5, "votes": 10},
+			{"rating": 3.25, "votes": 4},
+			{"rating": 4.5, "votes": 2},
+			{"rating": 5, "votes": 1}
+		]`), &reviews)
+		Expect(err).ToNot(HaveOccurred())
+		reviews = append(reviews, map[string]interface{}{"rating": int64(5), "votes": int64(3)})
+
+		query, err := tsl.ParseQuery("SELECT rating, COUNT(*) AS n, SUM(votes) AS votes GROUP BY rating")
+		Expect(err).ToNot(HaveOccurred())
+		rows, err := Aggregate(context.Background(), query, reviews, get)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rows).To(Equal([]map[string]interface{}{
+			{"rating": 4.5, "n": int64(2), "votes": 12.0},
+			{"rating": 3.25, "n": int64(1), "votes": 4.0},
+			{"rating": 5.0, "n": int64(2), "votes": 4.0},
+		}))
+	})
+
+	It("groups NaN and infinite keys apart from numbers", func() {
+		records := []map[string]interface{}{
+			{"x": math.NaN()}, {"x": math.Inf(1)}, {"x": math.NaN()}, {"x": math.Inf(-1)}, {"x": 0.0},
+		}
+
+		query, err := tsl.ParseQuery("SELECT COUNT(*) AS n GROUP BY x")
+		Expect(err).ToNot(HaveOccurred())
+		rows, err := Aggregate(context.Background(), query, records, get)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rows).To(Equal([]map[string]interface{}{
+			{"n": int64(2)}, {"n": int64(1)}, {"n": int64(1)}, {"n": int64(1)},
+		}))
+	})
+
+	It("rejects aggregate functions outside a group", func() {
+		query, err := tsl.ParseQuery("SELECT COUNT(*) AS books")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = Project(context.Background(), query.Select, books, get)
+		Expect(errors.As(err, &tsl.UnexpectedOperatorError{})).To(BeTrue())
+	})
+})
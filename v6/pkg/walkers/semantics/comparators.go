@@ -0,0 +1,169 @@
+package semantics
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// Comparator describes how to compare and coerce values of a domain type T,
+// e.g. semantic versions, netip.Addr, durations or custom IDs.
+type Comparator[T any] struct {
+	// Compare returns -1, 0 or +1, it is required for the ordering operators
+	// (<, <=, >, >=, BETWEEN), types without ordering can leave it nil.
+	Compare func(a, b T) int
+
+	// Equal reports if two values are equal, when nil Compare is used.
+	Equal func(a, b T) bool
+
+	// Coerce converts a value of another type, usually a string literal,
+	// into a T, e.g. '1.10.0' into a semantic version.
+	Coerce func(v interface{}) (T, bool)
+}
+
+// comparator is the type erased form of a Comparator
+type comparator struct {
+	compare func(a, b interface{}) int
+	equal   func(a, b interface{}) bool
+	coerce  func(v interface{}) (interface{}, bool)
+}
+
+// Registry holds comparators for domain value types, it is safe for concurrent use
+type Registry struct {
+	mu          sync.RWMutex
+	comparators map[reflect.Type]comparator
+}
+
+// NewRegistry creates an empty comparators registry
+func NewRegistry() *Registry {
+	return &Registry{
+		comparators: map[reflect.Type]comparator{},
+	}
+}
+
+// DefaultRegistry is the registry used by Walk, and by WalkContext unless
+// WithRegistry is used.
+var DefaultRegistry = NewRegistry()
+
+// Register adds, or replaces, the comparator of type T in a registry.
+//
+// Example:
+//
+//	semantics.Register(semantics.DefaultRegistry, semantics.Comparator[netip.Addr]{
+//		Compare: func(a, b netip.Addr) int { return a.Compare(b) },
+//		Coerce: func(v interface{}) (netip.Addr, bool) {
+//			s, ok := v.(string)
+//			if !ok {
+//				return netip.Addr{}, false
+//			}
+//			addr, err := netip.ParseAddr(s)
+//			return addr, err == nil
+//		},
+//	})
+func Register[T any](r *Registry, c Comparator[T]) {
+	var erased comparator
+
+	if c.Compare != nil {
+		erased.compare = func(a, b interface{}) int {
+			return c.Compare(a.(T), b.(T))
+		}
+	}
+
+	switch {
+	case c.Equal != nil:
+		erased.equal = func(a, b interface{}) bool {
+			return c.Equal(a.(T), b.(T))
+		}
+	case c.Compare != nil:
+		erased.equal = func(a, b interface{}) bool {
+			return c.Compare(a.(T), b.(T)) == 0
+		}
+	default:
+		erased.equal = func(a, b interface{}) bool {
+			return reflect.DeepEqual(a, b)
+		}
+	}
+
+	if c.Coerce != nil {
+		erased.coerce = func(v interface{}) (interface{}, bool) {
+			return c.Coerce(v)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.comparators[reflect.TypeOf((*T)(nil)).Elem()] = erased
+}
+
+// lookup returns the comparator registered for the type of a value
+func (r *Registry) lookup(v interface{}) (comparator, bool) {
+	if v == nil {
+		return comparator{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.comparators) == 0 {
+		return comparator{}, false
+	}
+	c, ok := r.comparators[reflect.TypeOf(v)]
+	return c, ok
+}
+
+// WithRegistry sets the comparators registry used by the evaluation.
+func WithRegistry(r *Registry) Option {
+	return func(o *options) {
+		o.registry = r
+	}
+}
+
+// registry returns the comparators registry of the evaluation
+func (w *walker) registry() *Registry {
+	if w.opts.registry != nil {
+		return w.opts.registry
+	}
+	return DefaultRegistry
+}
+
+// registeredOperands checks if one of the operands has a registered type, and
+// if so, coerces the other operand into that type.
+//
+// ok is false when neither operand has a registered type.
+func (w *walker) registeredOperands(leftVal, rightVal interface{}) (c comparator, l, r interface{}, ok bool, err error) {
+	registry := w.registry()
+
+	if c, ok = registry.lookup(leftVal); ok {
+		r, err = coerceOperand(c, leftVal, rightVal)
+		return c, leftVal, r, true, err
+	}
+	if c, ok = registry.lookup(rightVal); ok {
+		l, err = coerceOperand(c, rightVal, leftVal)
+		return c, l, rightVal, true, err
+	}
+
+	return comparator{}, nil, nil, false, nil
+}
+
+// coerceOperand converts an operand into the type of a registered value
+func coerceOperand(c comparator, registered, other interface{}) (interface{}, error) {
+	if reflect.TypeOf(other) == reflect.TypeOf(registered) {
+		return other, nil
+	}
+	if c.coerce != nil {
+		if v, ok := c.coerce(other); ok {
+			return v, nil
+		}
+	}
+	return nil, tsl.TypeMismatchError{Expected: fmt.Sprintf("%T", registered), Got: fmt.Sprintf("%T", other)}
+}
+
+// compareRegistered compares operands using a registered comparator
+func compareRegistered(c comparator, l, r interface{}) (int, error) {
+	if c.compare == nil {
+		return 0, tsl.TypeMismatchError{Expected: "ordered type", Got: fmt.Sprintf("%T", l)}
+	}
+	return c.compare(l, r), nil
+}
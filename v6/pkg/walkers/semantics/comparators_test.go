@@ -0,0 +1,170 @@
+// Copyright 2019 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantics
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// testVersion is a minimal major.minor.patch version type
+type testVersion [3]int
+
+func parseTestVersion(v interface{}) (testVersion, bool) {
+	var ver testVersion
+	s, ok := v.(string)
+	if !ok {
+		return ver, false
+	}
+	_, err := fmt.Sscanf(s, "%d.%d.%d", &ver[0], &ver[1], &ver[2])
+	return ver, err == nil
+}
+
+// testID is a custom ID type without ordering
+type testID string
+
+var _ = Describe("Registered comparators", func() {
+	registry := NewRegistry()
+
+	Register(registry, Comparator[testVersion]{
+		Compare: func(a, b testVersion) int {
+			for i := range a {
+				if c := cmp.Compare(a[i], b[i]); c != 0 {
+					return c
+				}
+			}
+			return 0
+		},
+		Coerce: parseTestVersion,
+	})
+
+	Register(registry, Comparator[netip.Addr]{
+		Compare: func(a, b netip.Addr) int { return a.Compare(b) },
+		Coerce: func(v interface{}) (netip.Addr, bool) {
+			s, ok := v.(string)
+			if !ok {
+				return netip.Addr{}, false
+			}
+			addr, err := netip.ParseAddr(s)
+			return addr, err == nil
+		},
+	})
+
+	Register(registry, Comparator[time.Duration]{
+		Compare: cmp.Compare[time.Duration],
+		Coerce: func(v interface{}) (time.Duration, bool) {
+			s, ok := v.(string)
+			if !ok {
+				return 0, false
+			}
+			d, err := time.ParseDuration(s)
+			return d, err == nil
+		},
+	})
+
+	Register(registry, Comparator[testID]{
+		Coerce: func(v interface{}) (testID, bool) {
+			s, ok := v.(string)
+			return testID(s), ok
+		},
+	})
+
+	record := map[string]interface{}{
+		"version":  testVersion{1, 10, 0},
+		"versions": []interface{}{testVersion{1, 2, 0}, testVersion{1, 10, 0}},
+		"ip":       netip.MustParseAddr("10.0.0.5"),
+		"timeout":  30 * time.Second,
+		"owner":    testID("u-42"),
+	}
+
+	eval := func(_ context.Context, name string) (interface{}, bool, error) {
+		value, ok := record[name]
+		return value, ok, nil
+	}
+
+	walk := func(text string) (interface{}, error) {
+		tree, err := tsl.ParseTSL(text)
+		Expect(err).ToNot(HaveOccurred())
+
+		return WalkContext(context.Background(), tree, eval, WithRegistry(registry))
+	}
+
+	DescribeTable("Returns the expected result",
+		func(text string, expected interface{}) {
+			actual, err := walk(text)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(expected))
+		},
+
+		Entry("version greater or equal", "version >= '1.10.0'", true),
+		Entry("version compares semantically", "version > '1.9.0'", true),
+		Entry("version less than", "version < '1.2.0'", false),
+		Entry("version equals", "version = '1.10.0'", true),
+		Entry("version literal on the left", "'1.9.0' < version", true),
+		Entry("version between", "version between '1.2.0' and '1.12.0'", true),
+		Entry("version in", "version in ['1.9.0', '1.10.0']", true),
+		Entry("version not in", "version not in ['1.9.0', '1.11.0']", true),
+		Entry("string in registered array", "any (versions = '1.2.0')", true),
+		Entry("ip equals", "ip = '10.0.0.5'", true),
+		Entry("ip greater than", "ip > '10.0.0.1'", true),
+		Entry("duration less than", "timeout < '1m'", true),
+		Entry("duration equals", "timeout = '30s'", true),
+		Entry("custom id equals", "owner = 'u-42'", true),
+		Entry("custom id not equals", "owner != 'u-43'", true),
+	)
+
+	DescribeTable("Returns appropriate errors",
+		func(text string, expectedError interface{}) {
+			_, err := walk(text)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(expectedError))
+		},
+
+		Entry("invalid version literal", "version > 'latest'", tsl.TypeMismatchError{}),
+		Entry("unordered type", "owner > 'u-1'", tsl.TypeMismatchError{}),
+	)
+
+	It("uses the default registry in Walk", func() {
+		type priority string
+		order := map[priority]int{"low": 0, "medium": 1, "high": 2}
+
+		Register(DefaultRegistry, Comparator[priority]{
+			Compare: func(a, b priority) int { return cmp.Compare(order[a], order[b]) },
+			Coerce: func(v interface{}) (priority, bool) {
+				s, ok := v.(string)
+				_, known := order[priority(s)]
+				return priority(s), ok && known
+			},
+		})
+
+		tree, err := tsl.ParseTSL("level >= 'medium'")
+		Expect(err).ToNot(HaveOccurred())
+
+		actual, err := Walk(tree, func(string) (interface{}, bool) {
+			return priority("high"), true
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual).To(Equal(true))
+	})
+})
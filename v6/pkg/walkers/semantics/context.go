@@ -0,0 +1,137 @@
+package semantics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// EvalContextFunc is a context aware key evaluation function type.
+//
+// Unlike EvalFunc it receives the evaluation context, and may return an error,
+// for example when a cache or a remote store lookup fails.
+type EvalContextFunc = func(context.Context, string) (interface{}, bool, error)
+
+// Option configures a WalkContext evaluation
+type Option func(*options)
+
+// options holds the evaluation options, zero values mean no limit
+type options struct {
+	maxSteps         int
+	timeout          time.Duration
+	maxPatternLength int
+	maxInputLength   int
+	registry         *Registry
+	workers          int
+	errorPolicy      ErrorPolicy
+}
+
+// WithMaxSteps limits the number of evaluation steps, a step is one tree node
+// or one array element operation.
+func WithMaxSteps(n int) Option {
+	return func(o *options) {
+		o.maxSteps = n
+	}
+}
+
+// WithTimeout limits the time an evaluation may take.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithMaxPatternLength limits the length of regex and LIKE patterns.
+func WithMaxPatternLength(n int) Option {
+	return func(o *options) {
+		o.maxPatternLength = n
+	}
+}
+
+// WithMaxInputLength limits the length of strings matched by regex and LIKE patterns.
+func WithMaxInputLength(n int) Option {
+	return func(o *options) {
+		o.maxInputLength = n
+	}
+}
+
+// WalkContext traverses the TSL tree and implements search semantics, like Walk,
+// but can be cancelled using the context and bounded using options.
+//
+// Evaluation stops with a tsl.CanceledError or tsl.DeadlineExceededError when the
+// context is done, and with a tsl.BudgetExceededError when a limit is exceeded.
+//
+// Example:
+//
+//	eval := func(ctx context.Context, k string) (interface{}, bool, error) {
+//		return cache.Get(ctx, k)
+//	}
+//
+//	compliance, err := semantics.WalkContext(ctx, tree, eval,
+//		semantics.WithTimeout(50*time.Millisecond),
+//		semantics.WithMaxSteps(1000),
+//		semantics.WithMaxPatternLength(256),
+//	)
+func WalkContext(ctx context.Context, n *tsl.TSLNode, eval EvalContextFunc, opts ...Option) (interface{}, error) {
+	return walkGroup(ctx, n, eval, nil, opts...)
+}
+
+// walkGroup evaluates a tree, aggregate functions are evaluated over the group
+// records, a nil group means the tree is evaluated for a single record
+func walkGroup(ctx context.Context, n *tsl.TSLNode, eval EvalContextFunc, group []EvalContextFunc, opts ...Option) (interface{}, error) {
+	w := &walker{
+		ctx:   ctx,
+		eval:  eval,
+		group: group,
+	}
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+
+	if w.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		w.ctx, cancel = context.WithTimeout(ctx, w.opts.timeout)
+		defer cancel()
+	}
+
+	return w.walk(n)
+}
+
+// step checks the context and charges one step against the steps budget
+func (w *walker) step() error {
+	if err := w.ctx.Err(); err != nil {
+		return contextError(err)
+	}
+
+	w.steps++
+	if w.opts.maxSteps > 0 && w.steps > w.opts.maxSteps {
+		return tsl.BudgetExceededError{Budget: "steps", Limit: w.opts.maxSteps}
+	}
+
+	return nil
+}
+
+// checkPatternLimits checks the size caps of a pattern operator's input and pattern
+func (w *walker) checkPatternLimits(value, pattern interface{}) error {
+	if s, ok := value.(string); ok && w.opts.maxInputLength > 0 && len(s) > w.opts.maxInputLength {
+		return tsl.BudgetExceededError{Budget: "input length", Limit: w.opts.maxInputLength}
+	}
+	if s, ok := pattern.(string); ok && w.opts.maxPatternLength > 0 && len(s) > w.opts.maxPatternLength {
+		return tsl.BudgetExceededError{Budget: "pattern length", Limit: w.opts.maxPatternLength}
+	}
+	return nil
+}
+
+// contextError converts context errors into typed TSL errors
+func contextError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return tsl.DeadlineExceededError{}
+	case errors.Is(err, context.Canceled):
+		return tsl.CanceledError{}
+	default:
+		return err
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("WalkContext", func() {
+	record := map[string]interface{}{
+		"title":   "A good book",
+		"pages":   14.0,
+		"numbers": []interface{}{1.0, 2.0, 3.0},
+		"long":    strings.Repeat("a", 100),
+	}
+
+	eval := func(_ context.Context, name string) (interface{}, bool, error) {
+		value, ok := record[name]
+		return value, ok, nil
+	}
+
+	walk := func(text string, opts ...Option) (interface{}, error) {
+		tree, err := tsl.ParseTSL(text)
+		Expect(err).ToNot(HaveOccurred())
+
+		return WalkContext(context.Background(), tree, eval, opts...)
+	}
+
+	It("evaluates like Walk when no limits are set", func() {
+		actual, err := walk("title like '%good%' and pages > 10")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual).To(Equal(true))
+	})
+
+	It("returns a CanceledError when the context is canceled", func() {
+		tree, err := tsl.ParseTSL("pages > 10")
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = WalkContext(ctx, tree, eval)
+		Expect(err).To(BeAssignableToTypeOf(tsl.CanceledError{}))
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+	})
+
+	It("returns a DeadlineExceededError when the eval function is too slow", func() {
+		tree, err := tsl.ParseTSL("pages > 10 and title = 'A good book'")
+		Expect(err).ToNot(HaveOccurred())
+
+		slowEval := func(ctx context.Context, name string) (interface{}, bool, error) {
+			select {
+			case <-time.After(time.Second):
+				return eval(ctx, name)
+			case <-ctx.Done():
+				return nil, false, ctx.Err()
+			}
+		}
+
+		_, err = WalkContext(context.Background(), tree, slowEval, WithTimeout(10*time.Millisecond))
+		Expect(err).To(BeAssignableToTypeOf(tsl.DeadlineExceededError{}))
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	})
+
+	It("returns eval function errors", func() {
+		tree, err := tsl.ParseTSL("pages > 10")
+		Expect(err).ToNot(HaveOccurred())
+
+		failingEval := func(_ context.Context, _ string) (interface{}, bool, error) {
+			return nil, false, fmt.Errorf("store unavailable")
+		}
+
+		_, err = WalkContext(context.Background(), tree, failingEval)
+		Expect(err).To(MatchError("store unavailable"))
+	})
+
+	DescribeTable("Enforces evaluation budgets",
+		func(text string, opt Option, budget string) {
+			_, err := walk(text, opt)
+			Expect(err).To(BeAssignableToTypeOf(tsl.BudgetExceededError{}))
+			Expect(err.(tsl.BudgetExceededError).Budget).To(Equal(budget))
+		},
+
+		Entry("steps", "pages > 10 and pages < 20", WithMaxSteps(3), "steps"),
+		Entry("array element steps", "any (numbers > 1)", WithMaxSteps(5), "steps"),
+		Entry("regex pattern length", "title ~= '.*good.*'", WithMaxPatternLength(4), "pattern length"),
+		Entry("like pattern length", "title like '%good%'", WithMaxPatternLength(4), "pattern length"),
+		Entry("regex input length", "long ~= 'a+'", WithMaxInputLength(10), "input length"),
+		Entry("ilike input length", "long ilike 'A%'", WithMaxInputLength(10), "input length"),
+	)
+
+	DescribeTable("Evaluates within budgets",
+		func(text string, opt Option) {
+			actual, err := walk(text, opt)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(true))
+		},
+
+		Entry("steps", "pages > 10 and pages < 20", WithMaxSteps(7)),
+		Entry("regex pattern length", "title ~= 'good'", WithMaxPatternLength(4)),
+		Entry("like input length", "long like 'a%'", WithMaxInputLength(100)),
+	)
+})
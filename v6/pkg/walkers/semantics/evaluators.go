@@ -26,21 +26,21 @@ func processValue(value interface{}) (interface{}, error) {
 	if date, ok := toDate(value); ok {
 		return date, nil
 	}
-	if num, ok := toFloat64(value); ok {
+	if num, ok := toNumber(value); ok {
 		return num, nil
 	}
 	return value, nil
 }
 
-// handleIdentifier evaluates an identifier node using the provided eval function
-func handleIdentifier(n *tsl.TSLNode, eval EvalFunc) (interface{}, error) {
+// handleIdentifier evaluates an identifier node using the walker's eval function
+func (w *walker) handleIdentifier(n *tsl.TSLNode) (interface{}, error) {
 	if n == nil {
 		return nil, nil
 	}
 
 	// If not an identifier, just return the node
 	if n.Type() != tsl.KindIdentifier {
-		return Walk(n, eval)
+		return w.walk(n)
 	}
 
 	// Get identifier name
@@ -50,7 +50,10 @@ func handleIdentifier(n *tsl.TSLNode, eval EvalFunc) (interface{}, error) {
 	}
 
 	// Get value using eval function
-	value, exists := eval(identName)
+	value, exists, err := w.eval(w.ctx, identName)
+	if err != nil {
+		return nil, contextError(err)
+	}
 	if !exists {
 		return nil, tsl.KeyNotFoundError{Key: identName}
 	}
@@ -59,90 +62,83 @@ func handleIdentifier(n *tsl.TSLNode, eval EvalFunc) (interface{}, error) {
 }
 
 // isValueInArray checks if a value is in a list of values
-// Supports string, float64, time.Time, and bool values
-func isValueInArray(value interface{}, arr []interface{}) (bool, error) {
+// Values are matched using the equality operator semantics
+func (w *walker) isValueInArray(value interface{}, arr []interface{}) (bool, error) {
 	if value == nil || arr == nil {
 		return false, nil
 	}
 
-	switch value.(type) {
-	case string, float64, time.Time, bool:
-		// Continue with existing type-specific checks
-		switch v := value.(type) {
-		case string:
-			for _, item := range arr {
-				if str, ok := item.(string); ok && str == v {
-					return true, nil
-				}
-			}
-		case float64:
-			for _, item := range arr {
-				switch i := item.(type) {
-				case float64:
-					if i == v {
-						return true, nil
-					}
-				case int:
-					if float64(i) == v {
-						return true, nil
-					}
-				}
-			}
-		case time.Time:
-			for _, item := range arr {
-				if t, ok := item.(time.Time); ok && t.Equal(v) {
-					return true, nil
-				}
-			}
-		case bool:
-			for _, item := range arr {
-				if b, ok := item.(bool); ok && b == v {
-					return true, nil
-				}
-			}
+	for _, item := range arr {
+		if item == nil {
+			continue
 		}
-		return false, nil
-	default:
-		return false, &tsl.TypeMismatchError{
-			Expected: "string, float64, time.Time, or bool",
-			Got:      value,
+		matched, err := w.evaluateEquality(value, item)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
 		}
 	}
+	return false, nil
 }
 
 // isValueInRange checks if a value is within a range (inclusive)
-// Supports both numeric values and time.Time comparisons
-func isValueInRange(value, min, max interface{}) (bool, error) {
-	switch v := value.(type) {
-	case float64:
-		minVal, okMin := toFloat64(min)
-		maxVal, okMax := toFloat64(max)
-		if okMin && okMax {
-			return v >= minVal && v <= maxVal, nil
-		}
-		// Try int conversion if float64 conversion failed
-		if !okMin {
-			if minInt, ok := min.(int); ok {
-				minVal = float64(minInt)
-				okMin = true
+// Supports numeric values, time.Time and registered type comparisons
+func (w *walker) isValueInRange(value, min, max interface{}) (bool, error) {
+	if value != nil && min != nil && max != nil {
+		if c, v, minVal, ok, err := w.registeredOperands(value, min); ok {
+			if err != nil {
+				return false, err
+			}
+			_, v, maxVal, _, err := w.registeredOperands(v, max)
+			if err != nil {
+				return false, err
+			}
+			cmpMin, err := compareRegistered(c, v, minVal)
+			if err != nil {
+				return false, err
+			}
+			cmpMax, err := compareRegistered(c, v, maxVal)
+			if err != nil {
+				return false, err
 			}
+			return cmpMin >= 0 && cmpMax <= 0, nil
 		}
-		if !okMax {
-			if maxInt, ok := max.(int); ok {
-				maxVal = float64(maxInt)
-				okMax = true
+	}
+
+	if isVersion(value) || isVersion(min) || isVersion(max) {
+		v, okValue := toVersion(value)
+		minVersion, okMin := toVersion(min)
+		maxVersion, okMax := toVersion(max)
+		if !okValue || !okMin || !okMax {
+			return false, tsl.TypeMismatchError{
+				Expected: "version values",
+				Got:      value,
 			}
 		}
+		return v.Compare(minVersion) >= 0 && v.Compare(maxVersion) <= 0, nil
+	}
+
+	if _, ok := toNumber(value); ok {
+		if isNaN(value) || isNaN(min) || isNaN(max) {
+			return false, nil
+		}
+		cmpMin, okMin := compareNumbers(value, min)
+		cmpMax, okMax := compareNumbers(value, max)
 		if !okMin || !okMax {
 			return false, &tsl.TypeMismatchError{
 				Expected: "numeric values",
 				Got:      value,
 			}
 		}
-		return v >= minVal && v <= maxVal, nil
-	case time.Time:
-		minTime, okMin := min.(time.Time)
-		maxTime, okMax := max.(time.Time)
+		return cmpMin >= 0 && cmpMax <= 0, nil
+	}
+
+	if v, ok := value.(time.Time); ok {
+		// Date literals are strings, as in comparisons
+		minTime, okMin := toDate(min)
+		maxTime, okMax := toDate(max)
 		if !okMin || !okMax {
 			return false, &tsl.TypeMismatchError{
 				Expected: "time values",
@@ -151,6 +147,7 @@ func isValueInRange(value, min, max interface{}) (bool, error) {
 		}
 		return !v.Before(minTime) && !v.After(maxTime), nil
 	}
+
 	return false, &tsl.TypeMismatchError{
 		Expected: "numeric or time.Time values",
 		Got:      value,
@@ -158,7 +155,8 @@ func isValueInRange(value, min, max interface{}) (bool, error) {
 }
 
 // evaluateLikePattern performs pattern matching with SQL LIKE semantics
-// Supports % for any sequence of characters and _ for single character
+// Supports % for any sequence of characters and _ for single character, a
+// backslash escapes the next character
 func evaluateLikePattern(value interface{}, pattern interface{}) (bool, error) {
 	if value == nil || pattern == nil {
 		return false, nil
@@ -180,13 +178,39 @@ func evaluateLikePattern(value interface{}, pattern interface{}) (bool, error) {
 		}
 	}
 
-	// Convert SQL LIKE pattern to regex pattern
-	patternStr = strings.ReplaceAll(patternStr, "%", ".*")
-	patternStr = strings.ReplaceAll(patternStr, "_", ".")
-	matched, _ := regexp.MatchString("^"+patternStr+"$", valueStr)
+	matched, _ := regexp.MatchString(likeRegexp(patternStr), valueStr)
 	return matched, nil
 }
 
+// likeRegexp converts an SQL LIKE pattern to a regular expression, other
+// characters than the wildcards match themselves, including new lines
+func likeRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString("(?s)^")
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '%':
+			b.WriteString(".*")
+		case c == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if escaped {
+		// A trailing backslash matches itself
+		b.WriteString(`\\`)
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
 // evaluateIlikePattern performs case-insensitive pattern matching with SQL LIKE semantics
 func evaluateIlikePattern(value interface{}, pattern interface{}) (bool, error) {
 	if value == nil || pattern == nil {
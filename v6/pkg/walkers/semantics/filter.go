@@ -0,0 +1,260 @@
+package semantics
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"sync"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// Accessor returns the value of a field of a record, and false if the record
+// has no such field.
+type Accessor[T any] func(record T, name string) (interface{}, bool)
+
+// ErrorPolicy sets how filtering handles records that fail to evaluate
+type ErrorPolicy int
+
+const (
+	// FailFast stops filtering at the first record that fails to evaluate
+	FailFast ErrorPolicy = iota
+
+	// CollectErrors skips records that fail to evaluate, and reports all errors
+	CollectErrors
+)
+
+// WithWorkers evaluates records using a pool of n workers, matches are still
+// returned in input order.
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		o.workers = n
+	}
+}
+
+// WithErrorPolicy sets how filtering handles records that fail to evaluate,
+// the default is FailFast.
+func WithErrorPolicy(p ErrorPolicy) Option {
+	return func(o *options) {
+		o.errorPolicy = p
+	}
+}
+
+// Filter returns the records matching the TSL tree, in input order.
+//
+// Evaluation options (e.g. WithMaxSteps or WithTimeout) apply to each record.
+// Record errors are returned as tsl.RecordError, with FailFast the first error
+// is returned, with CollectErrors the matches are returned along with all the
+// errors joined.
+//
+// Example:
+//
+//	get := func(b Book, name string) (interface{}, bool) {
+//		switch name {
+//		case "title":
+//			return b.Title, true
+//		case "pages":
+//			return b.Pages, true
+//		}
+//		return nil, false
+//	}
+//
+//	matches, err := semantics.Filter(ctx, tree, books, get, semantics.WithWorkers(8))
+func Filter[T any](ctx context.Context, tree *tsl.TSLNode, records []T, get Accessor[T], opts ...Option) ([]T, error) {
+	var matches []T
+	var errs []error
+
+	for record, err := range FilterSeq(ctx, tree, slices.Values(records), get, opts...) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		matches = append(matches, record)
+	}
+
+	if len(errs) > 0 {
+		var o options
+		for _, opt := range opts {
+			opt(&o)
+		}
+		if o.errorPolicy == FailFast {
+			return nil, errs[0]
+		}
+		return matches, errors.Join(errs...)
+	}
+	return matches, nil
+}
+
+// FilterSeq returns an iterator over the records matching the TSL tree, in
+// input order.
+//
+// Each match is yielded with a nil error, a record that fails to evaluate is
+// yielded as a zero record with a tsl.RecordError. With FailFast the iteration
+// stops after the first error, with CollectErrors it continues. When the
+// context is done, its error is yielded and the iteration stops.
+//
+// Example:
+//
+//	for book, err := range semantics.FilterSeq(ctx, tree, slices.Values(books), get) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(book.Title)
+//	}
+func FilterSeq[T any](ctx context.Context, tree *tsl.TSLNode, records iter.Seq[T], get Accessor[T], opts ...Option) iter.Seq2[T, error] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	match := func(ctx context.Context, index int, record T) (bool, error) {
+		eval := func(_ context.Context, name string) (interface{}, bool, error) {
+			value, ok := get(record, name)
+			return value, ok, nil
+		}
+
+		result, err := WalkContext(ctx, tree, eval, opts...)
+		if err != nil {
+			return false, tsl.RecordError{Index: index, Err: err}
+		}
+
+		matched, ok := result.(bool)
+		if !ok {
+			return false, tsl.RecordError{Index: index, Err: tsl.TypeMismatchError{Expected: "boolean", Got: result}}
+		}
+		return matched, nil
+	}
+
+	if o.workers > 1 {
+		return func(yield func(T, error) bool) {
+			filterParallel(ctx, records, match, o.workers, o.errorPolicy, yield)
+		}
+	}
+
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		index := 0
+		for record := range records {
+			if err := ctx.Err(); err != nil {
+				yield(zero, contextError(err))
+				return
+			}
+
+			matched, err := match(ctx, index, record)
+			index++
+
+			if err != nil {
+				if !yield(zero, err) || o.errorPolicy == FailFast {
+					return
+				}
+				continue
+			}
+			if matched && !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
+
+// filterResult is the evaluation result of one record
+type filterResult[T any] struct {
+	record  T
+	matched bool
+	err     error
+}
+
+// filterJob is one record waiting for evaluation
+type filterJob[T any] struct {
+	index  int
+	record T
+	result chan filterResult[T]
+}
+
+// filterParallel evaluates records using a pool of workers, and yields the
+// results in input order.
+//
+// A producer sends each record to the workers, and queues its result channel,
+// the queue is bounded so at most a few records per worker are in flight.
+func filterParallel[T any](
+	ctx context.Context,
+	records iter.Seq[T],
+	match func(context.Context, int, T) (bool, error),
+	workers int,
+	policy ErrorPolicy,
+	yield func(T, error) bool,
+) {
+	var zero T
+	var wg sync.WaitGroup
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer wg.Wait()
+	defer cancel()
+
+	jobs := make(chan filterJob[T])
+	pending := make(chan chan filterResult[T], workers)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				matched, err := match(ctx, job.index, job.record)
+				job.result <- filterResult[T]{record: job.record, matched: matched, err: err}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		defer close(pending)
+
+		index := 0
+		for record := range records {
+			result := make(chan filterResult[T], 1)
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- filterJob[T]{index: index, record: record, result: result}:
+			case <-ctx.Done():
+				return
+			}
+			index++
+		}
+	}()
+
+	for result := range pending {
+		var r filterResult[T]
+		select {
+		case r = <-result:
+		case <-ctx.Done():
+			yield(zero, contextError(ctx.Err()))
+			return
+		}
+
+		if err := ctx.Err(); err != nil {
+			yield(zero, contextError(err))
+			return
+		}
+
+		if r.err != nil {
+			if !yield(zero, r.err) || policy == FailFast {
+				return
+			}
+			continue
+		}
+		if r.matched && !yield(r.record, nil) {
+			return
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		yield(zero, contextError(err))
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2019 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantics
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// testBook is a record type used to test filtering
+type testBook struct {
+	Title string
+	Pages interface{}
+}
+
+var _ = Describe("Filter", func() {
+	books := []testBook{
+		{Title: "Book A", Pages: 100},
+		{Title: "Book B", Pages: 550},
+		{Title: "Book C", Pages: "many"},
+		{Title: "Book D", Pages: 700},
+		{Title: "Book E", Pages: "few"},
+		{Title: "Book F", Pages: 250},
+	}
+
+	get := func(b testBook, name string) (interface{}, bool) {
+		switch name {
+		case "title":
+			return b.Title, true
+		case "pages":
+			return b.Pages, true
+		}
+		return nil, false
+	}
+
+	titles := func(books []testBook) []string {
+		var titles []string
+		for _, b := range books {
+			titles = append(titles, b.Title)
+		}
+		return titles
+	}
+
+	parse := func(text string) *tsl.TSLNode {
+		tree, err := tsl.ParseTSL(text)
+		Expect(err).ToNot(HaveOccurred())
+		return tree
+	}
+
+	DescribeTable("Returns the matching records in input order",
+		func(text string, opts []Option, expected []string) {
+			matches, err := Filter(context.Background(), parse(text), books, get, opts...)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(titles(matches)).To(Equal(expected))
+		},
+
+		Entry("sequential", "title in ['Book F', 'Book A', 'Book D']", nil,
+			[]string{"Book A", "Book D", "Book F"}),
+		Entry("with workers", "title in ['Book F', 'Book A', 'Book D']", []Option{WithWorkers(4)},
+			[]string{"Book A", "Book D", "Book F"}),
+		Entry("no matches", "title = 'Book Z'", []Option{WithWorkers(2)},
+			nil),
+	)
+
+	It("preserves input order using many workers", func() {
+		var many []testBook
+		for i := 0; i < 1000; i++ {
+			many = append(many, testBook{Title: "Book", Pages: i})
+		}
+
+		matches, err := Filter(context.Background(), parse("pages % 3 = 0"), many, get, WithWorkers(16))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(matches).To(HaveLen(334))
+		for i, b := range matches {
+			Expect(b.Pages).To(Equal(i * 3))
+		}
+	})
+
+	DescribeTable("Fails fast on the first record error",
+		func(opts []Option) {
+			matches, err := Filter(context.Background(), parse("pages > 500"), books, get, opts...)
+			Expect(matches).To(BeNil())
+
+			var recordErr tsl.RecordError
+			Expect(errors.As(err, &recordErr)).To(BeTrue())
+			Expect(recordErr.Index).To(Equal(2))
+			Expect(errors.As(err, &tsl.TypeMismatchError{})).To(BeTrue())
+		},
+
+		Entry("sequential", nil),
+		Entry("with workers", []Option{WithWorkers(3)}),
+	)
+
+	DescribeTable("Collects record errors",
+		func(opts []Option) {
+			opts = append(opts, WithErrorPolicy(CollectErrors))
+			matches, err := Filter(context.Background(), parse("pages > 500"), books, get, opts...)
+			Expect(titles(matches)).To(Equal([]string{"Book B", "Book D"}))
+			Expect(err).To(HaveOccurred())
+
+			var indexes []int
+			for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+				indexes = append(indexes, e.(tsl.RecordError).Index)
+			}
+			Expect(indexes).To(Equal([]int{2, 4}))
+		},
+
+		Entry("sequential", nil),
+		Entry("with workers", []Option{WithWorkers(3)}),
+	)
+
+	It("reports a non boolean filter as a record error", func() {
+		_, err := Filter(context.Background(), parse("pages + 1"), books[:1], get)
+		Expect(errors.As(err, &tsl.TypeMismatchError{})).To(BeTrue())
+	})
+
+	It("applies evaluation options to each record", func() {
+		_, err := Filter(context.Background(), parse("pages > 1 and pages < 1000"), books[:1], get, WithMaxSteps(3))
+		Expect(errors.As(err, &tsl.BudgetExceededError{})).To(BeTrue())
+	})
+
+	DescribeTable("Stops when the context is canceled",
+		func(opts []Option) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := Filter(ctx, parse("pages > 500"), books, get, opts...)
+			Expect(err).To(BeAssignableToTypeOf(tsl.CanceledError{}))
+		},
+
+		Entry("sequential", nil),
+		Entry("with workers", []Option{WithWorkers(3)}),
+	)
+
+	DescribeTable("Iterates over a sequence",
+		func(opts []Option) {
+			var seen []string
+			for b, err := range FilterSeq(context.Background(), parse("title != 'Book B'"), slices.Values(books), get, opts...) {
+				Expect(err).ToNot(HaveOccurred())
+				seen = append(seen, b.Title)
+				if len(seen) == 3 {
+					break
+				}
+			}
+			Expect(seen).To(Equal([]string{"Book A", "Book C", "Book D"}))
+		},
+
+		Entry("sequential", nil),
+		Entry("with workers", []Option{WithWorkers(4)}),
+	)
+})
@@ -0,0 +1,83 @@
+// Copyright 2019 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantics
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("Implies", func() {
+	// Values around the literals of the random filters, and floats
+	values := []interface{}{nil, "x", "y", "~", "~~", 1.0, 2.5, math.NaN()}
+	for i := -12; i <= 20; i++ {
+		values = append(values, tsl.NewDecimal(big.NewRat(int64(i), 4)))
+	}
+
+	It("agrees with the walker", func() {
+		r := rand.New(rand.NewSource(21))
+		answers := map[tsl.Answer]int{}
+
+		for i := 0; i < 300; i++ {
+			textA, textB := randomFilter(r, 2), randomFilter(r, 2)
+			a, err := tsl.ParseTSL(textA)
+			Expect(err).NotTo(HaveOccurred(), textA)
+			b, err := tsl.ParseTSL(textB)
+			Expect(err).NotTo(HaveOccurred(), textB)
+
+			answer := tsl.Implies(a, b)
+			answers[answer]++
+			if answer == tsl.AnswerUnknown {
+				continue
+			}
+
+			// A record matching a and not b exists unless a implies b
+			found := false
+			for _, x := range values {
+				for _, y := range values {
+					eval := func(name string) (interface{}, bool) {
+						if name == "a" {
+							return x, true
+						}
+						return y, true
+					}
+
+					matchA, errA := Walk(a, eval)
+					matchB, errB := Walk(b, eval)
+					if errA == nil && errB == nil && matchA == true && matchB == false {
+						Expect(answer).To(Equal(tsl.AnswerNo),
+							fmt.Sprintf("%s implies %s, with a = %v, b = %v", textA, textB, x, y))
+						found = true
+					}
+				}
+			}
+			if answer == tsl.AnswerNo {
+				Expect(found).To(BeTrue(), fmt.Sprintf("%s does not imply %s", textA, textB))
+			}
+		}
+
+		// The random filters are mostly decided
+		Expect(answers[tsl.AnswerYes]).To(BeNumerically(">", 50))
+		Expect(answers[tsl.AnswerNo]).To(BeNumerically(">", 50))
+	})
+})
@@ -0,0 +1,105 @@
+package semantics
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// toAddr converts IP address values and strings to netip.Addr
+func toAddr(value interface{}) (netip.Addr, bool) {
+	switch v := value.(type) {
+	case netip.Addr:
+		return v.Unmap(), true
+	case net.IP:
+		addr, ok := netip.AddrFromSlice(v)
+		return addr.Unmap(), ok
+	case string:
+		addr, err := netip.ParseAddr(v)
+		return addr.Unmap(), err == nil
+	}
+	return netip.Addr{}, false
+}
+
+// toPrefix converts CIDR prefix values and strings to netip.Prefix
+func toPrefix(value interface{}) (netip.Prefix, bool) {
+	switch v := value.(type) {
+	case netip.Prefix:
+		return v.Masked(), true
+	case *net.IPNet:
+		if v == nil {
+			return netip.Prefix{}, false
+		}
+		addr, ok := netip.AddrFromSlice(v.IP)
+		ones, _ := v.Mask.Size()
+		return netip.PrefixFrom(addr.Unmap(), ones).Masked(), ok
+	case string:
+		prefix, err := netip.ParsePrefix(v)
+		return prefix.Masked(), err == nil
+	}
+	return netip.Prefix{}, false
+}
+
+// isIPValue checks if a value is an IP address or a CIDR prefix value
+func isIPValue(value interface{}) bool {
+	switch value.(type) {
+	case netip.Addr, netip.Prefix, net.IP, *net.IPNet:
+		return true
+	}
+	return false
+}
+
+// compareIPs compares two IP addresses or two CIDR prefixes, ok is false when
+// neither value is an IP value
+func compareIPs(leftVal, rightVal interface{}) (cmp int, ok bool, err error) {
+	if !isIPValue(leftVal) && !isIPValue(rightVal) {
+		return 0, false, nil
+	}
+
+	if leftAddr, leftOk := toAddr(leftVal); leftOk {
+		if rightAddr, rightOk := toAddr(rightVal); rightOk {
+			return leftAddr.Compare(rightAddr), true, nil
+		}
+	}
+	if leftPrefix, leftOk := toPrefix(leftVal); leftOk {
+		if rightPrefix, rightOk := toPrefix(rightVal); rightOk {
+			if c := leftPrefix.Addr().Compare(rightPrefix.Addr()); c != 0 {
+				return c, true, nil
+			}
+			return leftPrefix.Bits() - rightPrefix.Bits(), true, nil
+		}
+	}
+
+	return 0, true, tsl.TypeMismatchError{Expected: "IP address or CIDR", Got: fmt.Sprintf("%T and %T", leftVal, rightVal)}
+}
+
+// evaluateWithin checks if an IP address is within a CIDR prefix, or within
+// any of the CIDR prefixes of an array
+func evaluateWithin(value interface{}, cidr interface{}) (bool, error) {
+	if value == nil || cidr == nil {
+		return false, nil
+	}
+
+	addr, ok := toAddr(value)
+	if !ok {
+		return false, tsl.TypeMismatchError{Expected: "IP address", Got: value}
+	}
+
+	prefixes, ok := cidr.([]interface{})
+	if !ok {
+		prefixes = []interface{}{cidr}
+	}
+
+	for _, p := range prefixes {
+		prefix, ok := toPrefix(p)
+		if !ok {
+			return false, tsl.TypeMismatchError{Expected: "CIDR", Got: p}
+		}
+		if prefix.Contains(addr) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -1,33 +1,340 @@
 package semantics
 
-// toFloat64 attempts to convert various numeric types to float64
-func toFloat64(val interface{}) (float64, bool) {
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// Numbers are evaluated using a numeric tower:
+//
+//   - integers are kept as int64 (or uint64 when too large for an int64),
+//     and use exact integer arithmetic, promoting to decimals on overflow.
+//   - exact numbers (tsl.Decimal, *big.Rat, *big.Int, json.Number) use exact
+//     arbitrary-precision decimal arithmetic.
+//   - floats are kept as float64, any operation involving a float is done in
+//     float64 arithmetic. Comparisons are exact, a float is the shortest
+//     decimal that rounds to it, and NaN is not comparable to any number.
+
+// toNumber normalizes a numeric value to int64, uint64, float64 or tsl.Decimal
+func toNumber(val interface{}) (interface{}, bool) {
 	switch v := val.(type) {
+	case int64:
+		return v, true
 	case float64:
 		return v, true
-	case float32:
-		return float64(v), true
 	case int:
-		return float64(v), true
+		return int64(v), true
 	case int8:
-		return float64(v), true
+		return int64(v), true
 	case int16:
-		return float64(v), true
+		return int64(v), true
 	case int32:
-		return float64(v), true
-	case int64:
-		return float64(v), true
-	case uint:
-		return float64(v), true
+		return int64(v), true
 	case uint8:
-		return float64(v), true
+		return int64(v), true
 	case uint16:
-		return float64(v), true
+		return int64(v), true
 	case uint32:
+		return int64(v), true
+	case uint:
+		return fromUint64(uint64(v)), true
+	case uint64:
+		return fromUint64(v), true
+	case float32:
 		return float64(v), true
+	case tsl.Decimal:
+		return fromRat(v.Rat()), true
+	case *big.Rat:
+		if v == nil {
+			return nil, false
+		}
+		return fromRat(v), true
+	case *big.Int:
+		if v == nil {
+			return nil, false
+		}
+		return fromRat(new(big.Rat).SetInt(v)), true
+	case json.Number:
+		r, ok := new(big.Rat).SetString(string(v))
+		if !ok {
+			return nil, false
+		}
+		return fromRat(r), true
+	default:
+		return nil, false
+	}
+}
+
+// fromUint64 returns an int64 when the value fits in one
+func fromUint64(v uint64) interface{} {
+	if v <= math.MaxInt64 {
+		return int64(v)
+	}
+	return v
+}
+
+// fromRat returns the smallest exact representation of a rational value
+func fromRat(r *big.Rat) interface{} {
+	if r.IsInt() {
+		if r.Num().IsInt64() {
+			return r.Num().Int64()
+		}
+		if r.Num().IsUint64() {
+			return r.Num().Uint64()
+		}
+	}
+	return tsl.NewDecimal(r)
+}
+
+// toRat converts a normalized number to a big.Rat, floats must be finite and
+// are the shortest decimal that rounds to them, e.g. 0.1 and not
+// 0.1000000000000000055511151231257827, the way JSON and databases print them
+func toRat(val interface{}) *big.Rat {
+	switch v := val.(type) {
+	case float64:
+		r, _ := new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+		return r
+	case int64:
+		return new(big.Rat).SetInt64(v)
 	case uint64:
+		return new(big.Rat).SetUint64(v)
+	case tsl.Decimal:
+		return v.Rat()
+	default:
+		return new(big.Rat)
+	}
+}
+
+// toFloat64 attempts to convert various numeric types to float64
+func toFloat64(val interface{}) (float64, bool) {
+	num, ok := toNumber(val)
+	if !ok {
+		return 0, false
+	}
+
+	switch v := num.(type) {
+	case float64:
+		return v, true
+	case int64:
 		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case tsl.Decimal:
+		return v.Float64(), true
 	default:
 		return 0, false
 	}
 }
+
+// isFloat checks if a normalized number is a float
+func isFloat(val interface{}) bool {
+	_, ok := val.(float64)
+	return ok
+}
+
+// isNaN checks if a value is a float NaN
+func isNaN(val interface{}) bool {
+	num, ok := toNumber(val)
+	if !ok {
+		return false
+	}
+	f, ok := num.(float64)
+	return ok && math.IsNaN(f)
+}
+
+// compareNumbers compares two numeric values, returning -1, 0 or +1, NaN is
+// not comparable to any number
+func compareNumbers(leftVal, rightVal interface{}) (int, bool) {
+	left, ok := toNumber(leftVal)
+	if !ok {
+		return 0, false
+	}
+	right, ok := toNumber(rightVal)
+	if !ok {
+		return 0, false
+	}
+
+	// Floats are compared exactly as decimals with other numbers (see toRat),
+	// e.g. 9007199254740993 is larger than the float 9007199254740992,
+	// infinities are larger or smaller than every number
+	if isFloat(left) || isFloat(right) {
+		l, _ := toFloat64(left)
+		r, _ := toFloat64(right)
+		switch {
+		case math.IsNaN(l) || math.IsNaN(r):
+			return 0, false
+		case math.IsInf(l, 0) || math.IsInf(r, 0):
+			switch {
+			case l < r:
+				return -1, true
+			case l > r:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+		return toRat(left).Cmp(toRat(right)), true
+	}
+
+	// Integer arithmetic
+	if l, ok := left.(int64); ok {
+		if r, ok := right.(int64); ok {
+			switch {
+			case l < r:
+				return -1, true
+			case l > r:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+
+	// Decimal arithmetic
+	return toRat(left).Cmp(toRat(right)), true
+}
+
+// evaluateArithmetic applies an arithmetic operator to two numeric values
+func evaluateArithmetic(operator tsl.Operator, leftVal, rightVal interface{}) (interface{}, error) {
+	left, ok := toNumber(leftVal)
+	if !ok {
+		return nil, tsl.TypeMismatchError{Expected: "number", Got: fmt.Sprintf("%T", leftVal)}
+	}
+	right, ok := toNumber(rightVal)
+	if !ok {
+		return nil, tsl.TypeMismatchError{Expected: "number", Got: fmt.Sprintf("%T", rightVal)}
+	}
+
+	if isFloat(left) || isFloat(right) {
+		l, _ := toFloat64(left)
+		r, _ := toFloat64(right)
+		return floatArithmetic(operator, l, r)
+	}
+
+	if l, ok := left.(int64); ok {
+		if r, ok := right.(int64); ok {
+			if result, ok, err := intArithmetic(operator, l, r); ok || err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return ratArithmetic(operator, toRat(left), toRat(right))
+}
+
+// floatArithmetic applies an arithmetic operator using float64 arithmetic
+func floatArithmetic(operator tsl.Operator, l, r float64) (interface{}, error) {
+	switch operator {
+	case tsl.OpPlus:
+		return l + r, nil
+	case tsl.OpMinus:
+		return l - r, nil
+	case tsl.OpStar:
+		return l * r, nil
+	case tsl.OpSlash:
+		if r == 0 {
+			return nil, tsl.DivisionByZeroError{Operation: "division"}
+		}
+		return l / r, nil
+	case tsl.OpPercent:
+		if int64(r) == 0 {
+			return nil, tsl.DivisionByZeroError{Operation: "modulus"}
+		}
+		return float64(int64(l) % int64(r)), nil
+	default:
+		return nil, tsl.UnexpectedOperatorError{Operator: operator}
+	}
+}
+
+// intArithmetic applies an arithmetic operator using int64 arithmetic,
+// it returns ok = false when the result can not be represented as an int64
+func intArithmetic(operator tsl.Operator, l, r int64) (interface{}, bool, error) {
+	switch operator {
+	case tsl.OpPlus:
+		s := l + r
+		if (s > l) != (r > 0) {
+			return nil, false, nil
+		}
+		return s, true, nil
+	case tsl.OpMinus:
+		s := l - r
+		if (s < l) != (r > 0) {
+			return nil, false, nil
+		}
+		return s, true, nil
+	case tsl.OpStar:
+		if l == 0 || r == 0 {
+			return int64(0), true, nil
+		}
+		p := l * r
+		if p/r != l || (l == -1 && r == math.MinInt64) || (r == -1 && l == math.MinInt64) {
+			return nil, false, nil
+		}
+		return p, true, nil
+	case tsl.OpSlash:
+		if r == 0 {
+			return nil, false, tsl.DivisionByZeroError{Operation: "division"}
+		}
+		if l%r != 0 || (l == math.MinInt64 && r == -1) {
+			return nil, false, nil
+		}
+		return l / r, true, nil
+	case tsl.OpPercent:
+		if r == 0 {
+			return nil, false, tsl.DivisionByZeroError{Operation: "modulus"}
+		}
+		return l % r, true, nil
+	default:
+		return nil, false, tsl.UnexpectedOperatorError{Operator: operator}
+	}
+}
+
+// ratArithmetic applies an arithmetic operator using exact decimal arithmetic
+func ratArithmetic(operator tsl.Operator, l, r *big.Rat) (interface{}, error) {
+	switch operator {
+	case tsl.OpPlus:
+		return fromRat(new(big.Rat).Add(l, r)), nil
+	case tsl.OpMinus:
+		return fromRat(new(big.Rat).Sub(l, r)), nil
+	case tsl.OpStar:
+		return fromRat(new(big.Rat).Mul(l, r)), nil
+	case tsl.OpSlash:
+		if r.Sign() == 0 {
+			return nil, tsl.DivisionByZeroError{Operation: "division"}
+		}
+		return fromRat(new(big.Rat).Quo(l, r)), nil
+	case tsl.OpPercent:
+		// Modulus works on the integer parts of the operands
+		li := new(big.Int).Quo(l.Num(), l.Denom())
+		ri := new(big.Int).Quo(r.Num(), r.Denom())
+		if ri.Sign() == 0 {
+			return nil, tsl.DivisionByZeroError{Operation: "modulus"}
+		}
+		return fromRat(new(big.Rat).SetInt(new(big.Int).Rem(li, ri))), nil
+	default:
+		return nil, tsl.UnexpectedOperatorError{Operator: operator}
+	}
+}
+
+// negateNumber returns the negated value of a number
+func negateNumber(val interface{}) (interface{}, bool) {
+	num, ok := toNumber(val)
+	if !ok {
+		return nil, false
+	}
+
+	switch v := num.(type) {
+	case float64:
+		return -v, true
+	case int64:
+		if v != math.MinInt64 {
+			return -v, true
+		}
+	}
+	return fromRat(new(big.Rat).Neg(toRat(num))), true
+}
@@ -0,0 +1,236 @@
+package semantics
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"time"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// PartialEval evaluates a TSL tree against the fields known up front, e.g.
+// the tenant or the region of a request, and returns the residual tree to
+// evaluate on each record.
+//
+// Identifiers known returns a value for are replaced by literals, and the
+// sub-expressions using only literals and known fields are evaluated. AND and
+// OR operands that evaluate to true or false are removed, or decide the
+// expression. The residual tree holds only the fields known did not resolve,
+// and can be used by the other walkers, e.g. to build an SQL query. When the
+// whole tree is decided, the residual tree is a boolean literal.
+//
+// An error is returned when a sub-expression using known fields fails, e.g.
+// comparing a known string with a number, or when a known value has no
+// literal form.
+//
+// Example:
+//
+//	tree, _ := tsl.ParseTSL("tenant = 'acme' and (region = 'eu' or price > 10)")
+//	known := func(name string) (interface{}, bool) {
+//		switch name {
+//		case "tenant":
+//			return "acme", true
+//		case "region":
+//			return "us", true
+//		}
+//		return nil, false
+//	}
+//	residual, err := semantics.PartialEval(tree, known)
+//	// residual is "price > 10"
+func PartialEval(n *tsl.TSLNode, known EvalFunc) (*tsl.TSLNode, error) {
+	if n == nil || n.Node == nil {
+		return nil, nil
+	}
+
+	p := &partialEvaluator{known: known}
+	residual, err := p.eval(n.Node)
+	if err != nil {
+		return nil, err
+	}
+	return &tsl.TSLNode{Node: residual}, nil
+}
+
+// partialEvaluator holds the known fields of a partial evaluation
+type partialEvaluator struct {
+	known EvalFunc
+}
+
+// eval returns the residual of a node
+func (p *partialEvaluator) eval(n *tsl.Node) (*tsl.Node, error) {
+	if n == nil {
+		return nil, nil
+	}
+
+	switch n.Kind {
+	case tsl.KindIdentifier, tsl.KindBinaryExpr, tsl.KindUnaryExpr, tsl.KindArrayLiteral:
+		if p.resolved(n) {
+			v, err := Walk(&tsl.TSLNode{Node: n}, p.known)
+			if err != nil {
+				return nil, err
+			}
+			return literalNode(v, n.Position)
+		}
+	default:
+		// Literals are kept as parsed, e.g. dates keep their date kind
+		return n.Clone(), nil
+	}
+
+	// Aggregate functions are evaluated over the records of a group
+	if n.Kind == tsl.KindUnaryExpr && tsl.IsAggregate(n.Operator) {
+		return n.Clone(), nil
+	}
+
+	left, err := p.eval(n.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := p.eval(n.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.Kind == tsl.KindBinaryExpr && (n.Operator == tsl.OpAnd || n.Operator == tsl.OpOr) {
+		return logicalResidual(n, left, right)
+	}
+
+	residual := &tsl.Node{
+		Kind:     n.Kind,
+		Value:    n.Value,
+		Operator: n.Operator,
+		Position: n.Position,
+		Left:     left,
+		Right:    right,
+	}
+	if n.Children != nil {
+		residual.Children = make([]*tsl.Node, len(n.Children))
+		for i, child := range n.Children {
+			if residual.Children[i], err = p.eval(child); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Operands may be decided by the AND and OR expressions they hold, e.g.
+	// "not (false and a = 1)"
+	if p.resolved(residual) {
+		v, err := Walk(&tsl.TSLNode{Node: residual}, p.known)
+		if err != nil {
+			return nil, err
+		}
+		return literalNode(v, n.Position)
+	}
+	return residual, nil
+}
+
+// resolved reports if all the identifiers of a node are known, and the node
+// can be evaluated without the group records
+func (p *partialEvaluator) resolved(n *tsl.Node) bool {
+	if n == nil {
+		return true
+	}
+
+	switch {
+	case n.Kind == tsl.KindIdentifier:
+		name, ok := n.Value.(string)
+		if !ok {
+			return false
+		}
+		_, ok = p.known(name)
+		return ok
+	case n.Kind == tsl.KindUnaryExpr && tsl.IsAggregate(n.Operator):
+		return false
+	}
+
+	for _, child := range n.Children {
+		if !p.resolved(child) {
+			return false
+		}
+	}
+	return p.resolved(n.Left) && p.resolved(n.Right)
+}
+
+// logicalResidual returns the residual of an AND or OR expression, an operand
+// equal to the identity of the operator is removed, and an operand equal to
+// its absorbing value decides the expression
+func logicalResidual(n, left, right *tsl.Node) (*tsl.Node, error) {
+	absorbing := n.Operator == tsl.OpOr
+
+	residual := []*tsl.Node{}
+	for _, operand := range []*tsl.Node{left, right} {
+		if !isLiteral(operand) {
+			residual = append(residual, operand)
+			continue
+		}
+
+		v, ok := operand.Value.(bool)
+		if !ok {
+			return nil, tsl.TypeMismatchError{Expected: "boolean", Got: fmt.Sprintf("%T", operand.Value)}
+		}
+		if v == absorbing {
+			return &tsl.Node{Kind: tsl.KindBooleanLiteral, Value: v, Position: n.Position}, nil
+		}
+	}
+
+	switch len(residual) {
+	case 0:
+		return &tsl.Node{Kind: tsl.KindBooleanLiteral, Value: !absorbing, Position: n.Position}, nil
+	case 1:
+		return residual[0], nil
+	}
+	return &tsl.Node{Kind: n.Kind, Operator: n.Operator, Position: n.Position, Left: left, Right: right}, nil
+}
+
+// isLiteral reports if a node is a literal value
+func isLiteral(n *tsl.Node) bool {
+	switch n.Kind {
+	case tsl.KindIdentifier, tsl.KindBinaryExpr, tsl.KindUnaryExpr, tsl.KindArrayLiteral:
+		return false
+	}
+	return true
+}
+
+// literalNode returns the literal holding a value returned by the walker
+func literalNode(v interface{}, pos int) (*tsl.Node, error) {
+	n := &tsl.Node{Value: v, Position: pos}
+
+	switch value := v.(type) {
+	case nil:
+		n.Kind = tsl.KindNullLiteral
+	case bool:
+		n.Kind = tsl.KindBooleanLiteral
+	case string:
+		n.Kind = tsl.KindStringLiteral
+	case time.Time:
+		n.Kind = tsl.KindTimestampLiteral
+	case netip.Addr:
+		n.Kind = tsl.KindIPLiteral
+	case netip.Prefix:
+		n.Kind = tsl.KindCIDRLiteral
+	case tsl.Version:
+		n.Kind = tsl.KindVersionLiteral
+	case []interface{}:
+		n.Kind, n.Value = tsl.KindArrayLiteral, nil
+		n.Children = make([]*tsl.Node, len(value))
+		for i, item := range value {
+			child, err := literalNode(item, pos)
+			if err != nil {
+				return nil, err
+			}
+			n.Children[i] = child
+		}
+	default:
+		num, ok := toNumber(v)
+		if !ok {
+			return nil, tsl.UnexpectedLiteralError{Literal: v}
+		}
+
+		// Numbers are int64 or decimals, as parsed, and floats are kept to be
+		// compared using float arithmetic
+		n.Kind, n.Value = tsl.KindNumericLiteral, num
+		if u, ok := num.(uint64); ok {
+			n.Value = tsl.NewDecimal(new(big.Rat).SetUint64(u))
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,131 @@
+package semantics
+
+import (
+	"math/big"
+	"math/rand"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("PartialEval", func() {
+	// withoutPositions returns a copy of a node with zero positions, and no
+	// operators on literals and identifiers
+	var withoutPositions func(n *tsl.Node) *tsl.Node
+	withoutPositions = func(n *tsl.Node) *tsl.Node {
+		if n == nil {
+			return nil
+		}
+		c := n.Clone()
+		c.Position = 0
+		if n.Kind != tsl.KindBinaryExpr && n.Kind != tsl.KindUnaryExpr {
+			c.Operator = 0
+		}
+		c.Left, c.Right = withoutPositions(n.Left), withoutPositions(n.Right)
+		for i, child := range n.Children {
+			c.Children[i] = withoutPositions(child)
+		}
+		return c
+	}
+
+	known := func(name string) (interface{}, bool) {
+		v, ok := map[string]interface{}{
+			"tenant":  "acme",
+			"region":  "eu",
+			"level":   3,
+			"active":  true,
+			"deleted": nil,
+			"tags":    []interface{}{"a", "b"},
+			"since":   "2024-01-01",
+		}[name]
+		return v, ok
+	}
+
+	DescribeTable("returns the residual tree",
+		func(input string, expected string) {
+			residual, err := PartialEval(mustParse(input), known)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutPositions(residual.Node)).To(Equal(withoutPositions(mustParse(expected).Node)))
+		},
+		Entry("unknown fields", "price > 10", "price > 10"),
+		Entry("known comparison", "tenant = 'acme'", "true"),
+		Entry("known comparison is false", "region = 'us'", "false"),
+		Entry("drops true operands", "tenant = 'acme' and price > 10", "price > 10"),
+		Entry("drops false operands", "region = 'us' or price > 10", "price > 10"),
+		Entry("false decides and", "region = 'us' and price > 10", "false"),
+		Entry("true decides or", "price > 10 or level >= 3", "true"),
+		Entry("nested", "tenant = 'acme' and (region = 'us' or (price > 10 and active))", "price > 10"),
+		Entry("replaces identifiers", "price > level * 2", "price > 6"),
+		Entry("folds constants", "price > 2 + 3", "price > 5"),
+		Entry("known arrays", "len tags = 2 and price > 1", "price > 1"),
+		Entry("known values in arrays", "owner in [tenant, 'other']", "owner in ['acme', 'other']"),
+		Entry("not", "not (region = 'eu' and price > 1)", "not (price > 1)"),
+		Entry("decided not", "not (region = 'us' and price > 1)", "true"),
+		Entry("null", "deleted is null and price > 1", "price > 1"),
+		Entry("keeps literals", "created > 2024-01-01", "created > 2024-01-01"),
+	)
+
+	It("replaces known values by literals", func() {
+		residual, err := PartialEval(mustParse("created > since"), known)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(residual.Node.Right.Kind).To(Equal(tsl.KindTimestampLiteral))
+		Expect(residual.Node.Right.Value).To(Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+		residual, err = PartialEval(mustParse("price = level + 0.5"), known)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(residual.Node.Right.Value).To(Equal(tsl.NewDecimal(big.NewRat(7, 2))))
+	})
+
+	It("reports errors of known fields", func() {
+		_, err := PartialEval(mustParse("tenant > 5 and price > 1"), known)
+		Expect(err).To(HaveOccurred())
+
+		_, err = PartialEval(mustParse("level and price > 1"), known)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("keeps aggregate functions", func() {
+		residual, err := PartialEval(mustParse("sum(price) > level"), known)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(withoutPositions(residual.Node)).To(Equal(withoutPositions(mustParse("sum(price) > 3").Node)))
+	})
+
+	It("keeps the results of the walker", func() {
+		r := rand.New(rand.NewSource(17))
+		values := []interface{}{nil, int64(1), int64(2), 2.5, "x", "y"}
+
+		for i := 0; i < 500; i++ {
+			text := randomFilter(r, 3)
+			tree := mustParse(text)
+
+			for _, a := range values {
+				knownA := func(name string) (interface{}, bool) {
+					return a, name == "a"
+				}
+				residual, err := PartialEval(tree, knownA)
+
+				for _, b := range values {
+					eval := func(name string) (interface{}, bool) {
+						if name == "a" {
+							return a, true
+						}
+						return b, true
+					}
+
+					expected, walkErr := Walk(tree, eval)
+					if _, ok := expected.(bool); walkErr != nil || !ok {
+						continue
+					}
+					Expect(err).NotTo(HaveOccurred(), text)
+
+					result, err := Walk(residual, eval)
+					Expect(err).NotTo(HaveOccurred(), text)
+					Expect(result).To(Equal(expected), text)
+				}
+			}
+		}
+	})
+})
@@ -0,0 +1,57 @@
+package semantics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// Project evaluates the SELECT items of a query for each record, and returns
+// the output records, keyed by the item names (see tsl.SelectItem.Name).
+//
+// Missing fields evaluate as nulls. SELECT * copies all the fields of a
+// record, and requires map[string]interface{} records. With no items the
+// records are copied as with SELECT *.
+//
+// Example:
+//
+//	query, err := tsl.ParseQuery("SELECT title, pages * 2 AS double_pages WHERE pages > 100")
+//	books, err = semantics.ApplyQuery(ctx, query, books, get)
+//	rows, err := semantics.Project(ctx, query.Select, books, get)
+func Project[T any](ctx context.Context, items []tsl.SelectItem, records []T, get Accessor[T], opts ...Option) ([]map[string]interface{}, error) {
+	if len(items) == 0 {
+		items = []tsl.SelectItem{{Star: true}}
+	}
+
+	rows := make([]map[string]interface{}, 0, len(records))
+	for i, record := range records {
+		eval := nullableEval(record, get)
+
+		row := map[string]interface{}{}
+		for position, item := range items {
+			if item.Star {
+				fields, ok := any(record).(map[string]interface{})
+				if !ok {
+					return nil, tsl.RecordError{Index: i, Err: tsl.TypeMismatchError{
+						Expected: "map[string]interface{} record for SELECT *",
+						Got:      fmt.Sprintf("%T", record),
+					}}
+				}
+				for name, value := range fields {
+					row[name] = value
+				}
+				continue
+			}
+
+			value, err := WalkContext(ctx, item.Expr, eval, opts...)
+			if err != nil {
+				return nil, tsl.RecordError{Index: i, Err: err}
+			}
+			row[item.Name(position)] = value
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
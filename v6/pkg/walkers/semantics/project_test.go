@@ -0,0 +1,99 @@
+// Copyright 2019 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantics
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("Project", func() {
+	books := []map[string]interface{}{
+		{"title": "Book A", "author": "Joe", "pages": 100, "tags": []interface{}{"a", "b"}},
+		{"title": "Book B", "author": "Jane", "pages": 550, "tags": []interface{}{"c"}},
+		{"title": "Book C", "author": "Joe", "pages": 320, "tags": []interface{}{}},
+	}
+
+	get := func(record map[string]interface{}, name string) (interface{}, bool) {
+		value, ok := record[name]
+		return value, ok
+	}
+
+	run := func(text string) ([]map[string]interface{}, error) {
+		query, err := tsl.ParseQuery(text)
+		Expect(err).ToNot(HaveOccurred())
+
+		results, err := ApplyQuery(context.Background(), query, books, get)
+		Expect(err).ToNot(HaveOccurred())
+
+		return Project(context.Background(), query.Select, results, get)
+	}
+
+	DescribeTable("Returns the expected output records",
+		func(text string, expected []map[string]interface{}) {
+			rows, err := run(text)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rows).To(Equal(expected))
+		},
+
+		Entry("computed fields",
+			"SELECT title, pages * 2 AS double_pages, LEN tags AS tag_count WHERE author = 'Joe'",
+			[]map[string]interface{}{
+				{"title": "Book A", "double_pages": int64(200), "tag_count": int64(2)},
+				{"title": "Book C", "double_pages": int64(640), "tag_count": int64(0)},
+			}),
+		Entry("unnamed expression",
+			"SELECT title, pages > 300 WHERE pages > 200",
+			[]map[string]interface{}{
+				{"title": "Book B", "column2": true},
+				{"title": "Book C", "column2": true},
+			}),
+		Entry("missing fields are nulls",
+			"SELECT title, isbn WHERE pages = 100",
+			[]map[string]interface{}{
+				{"title": "Book A", "isbn": nil},
+			}),
+		Entry("order by alias",
+			"SELECT title, 1000 - pages AS remaining ORDER BY remaining LIMIT 2",
+			[]map[string]interface{}{
+				{"title": "Book B", "remaining": int64(450)},
+				{"title": "Book C", "remaining": int64(680)},
+			}),
+		Entry("star with computed field",
+			"SELECT *, pages / 100 AS hundreds WHERE title = 'Book B'",
+			[]map[string]interface{}{
+				{"title": "Book B", "author": "Jane", "pages": 550, "tags": []interface{}{"c"}, "hundreds": tsl.NewDecimal(big.NewRat(11, 2))},
+			}),
+		Entry("no select clause copies the records",
+			"pages < 200",
+			[]map[string]interface{}{books[0]}),
+	)
+
+	It("requires map records for SELECT *", func() {
+		query, err := tsl.ParseQuery("SELECT *")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = Project(context.Background(), query.Select, []testBook{{Title: "Book"}},
+			func(b testBook, name string) (interface{}, bool) { return nil, false })
+		Expect(errors.As(err, &tsl.TypeMismatchError{})).To(BeTrue())
+	})
+})
@@ -0,0 +1,157 @@
+// Copyright 2019 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantics
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// randomFilter returns a random filter over the fields a and b
+func randomFilter(r *rand.Rand, depth int) string {
+	literal := func() string {
+		return []string{"1", "2", "3", "2.5", "-1", "1 + 1", "6 / 2", "'x'", "'y'"}[r.Intn(9)]
+	}
+	number := func() string {
+		return []string{"1", "2", "3", "2.5", "-1", "1 + 1"}[r.Intn(6)]
+	}
+	field := []string{"a", "b"}[r.Intn(2)]
+
+	if depth > 0 && r.Intn(3) > 0 {
+		switch r.Intn(4) {
+		case 0:
+			return fmt.Sprintf("not (%s)", randomFilter(r, depth-1))
+		case 1:
+			return fmt.Sprintf("(%s or %s)", randomFilter(r, depth-1), randomFilter(r, depth-1))
+		default:
+			return fmt.Sprintf("(%s and %s)", randomFilter(r, depth-1), randomFilter(r, depth-1))
+		}
+	}
+
+	switch r.Intn(9) {
+	case 0:
+		return fmt.Sprintf("%s = %s", field, literal())
+	case 1:
+		return fmt.Sprintf("%s != %s", field, literal())
+	case 2:
+		return fmt.Sprintf("%s %s %s", field, []string{"<", "<=", ">", ">="}[r.Intn(4)], number())
+	case 3:
+		return fmt.Sprintf("%s %s %s", number(), []string{"<", "<=", ">", ">="}[r.Intn(4)], field)
+	case 4:
+		return fmt.Sprintf("%s in [%s, %s]", field, literal(), literal())
+	case 5:
+		return fmt.Sprintf("%s between %s and %s", field, number(), number())
+	case 6:
+		return fmt.Sprintf("%s is %snull", field, []string{"", "not "}[r.Intn(2)])
+	case 7:
+		return fmt.Sprintf("%s %s %s", number(), []string{"<", "=", ">="}[r.Intn(3)], number())
+	default:
+		return []string{"true", "false"}[r.Intn(2)]
+	}
+}
+
+var _ = Describe("Simplify", func() {
+	values := []interface{}{
+		nil, int64(1), int64(2), int64(3), 2.5, -1.0, 1.5, math.NaN(), math.Inf(1), "x", "y", "z",
+		tsl.NewDecimal(big.NewRat(5, 2)),
+	}
+
+	It("keeps the results of the walker", func() {
+		r := rand.New(rand.NewSource(7))
+		constants := 0
+
+		for i := 0; i < 2000; i++ {
+			text := randomFilter(r, 3)
+			tree, err := tsl.ParseTSL(text)
+			Expect(err).NotTo(HaveOccurred(), text)
+			simplified, found := tsl.Simplify(tree)
+			constants += len(found)
+
+			for _, a := range values {
+				for _, b := range values {
+					eval := func(name string) (interface{}, bool) {
+						if name == "a" {
+							return a, true
+						}
+						return b, true
+					}
+
+					expected, err := Walk(tree, eval)
+					if _, ok := expected.(bool); err != nil || !ok {
+						continue
+					}
+
+					result, err := Walk(simplified, eval)
+					Expect(err).NotTo(HaveOccurred(), text)
+					Expect(result).To(Equal(expected), fmt.Sprintf("%s with a = %v, b = %v", text, a, b))
+
+					for _, c := range found {
+						v, err := Walk(c.Node, eval)
+						if _, ok := v.(bool); err == nil && ok {
+							Expect(v).To(Equal(c.Value), text)
+						}
+					}
+				}
+			}
+		}
+
+		// The random filters include constant sub-expressions
+		Expect(constants).To(BeNumerically(">", 100))
+	})
+
+	It("keeps the results of the walker in normal forms", func() {
+		r := rand.New(rand.NewSource(13))
+
+		for i := 0; i < 1000; i++ {
+			text := randomFilter(r, 3)
+			tree, err := tsl.ParseTSL(text)
+			Expect(err).NotTo(HaveOccurred(), text)
+			cnf, err := tsl.ToCNF(tree)
+			Expect(err).NotTo(HaveOccurred(), text)
+			dnf, err := tsl.ToDNF(tree)
+			Expect(err).NotTo(HaveOccurred(), text)
+
+			for _, a := range values {
+				for _, b := range values {
+					eval := func(name string) (interface{}, bool) {
+						if name == "a" {
+							return a, true
+						}
+						return b, true
+					}
+
+					expected, err := Walk(tree, eval)
+					if _, ok := expected.(bool); err != nil || !ok {
+						continue
+					}
+
+					for _, converted := range []*tsl.TSLNode{cnf, dnf} {
+						result, err := Walk(converted, eval)
+						Expect(err).NotTo(HaveOccurred(), text)
+						Expect(result).To(Equal(expected), fmt.Sprintf("%s with a = %v, b = %v", text, a, b))
+					}
+				}
+			}
+		}
+	})
+})
@@ -0,0 +1,232 @@
+package semantics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// Sort sorts records by the ORDER BY keys of a query, the sort is stable.
+//
+// Sort keys are evaluated once per record, and compared using the semantics
+// value comparison, including registered comparators. Missing fields and nil
+// values sort as nulls, after all other values in ascending order and before
+// them in descending order.
+//
+// Example:
+//
+//	query, err := tsl.ParseQuery("status = 'open' ORDER BY priority DESC, created")
+//	err = semantics.Sort(ctx, records, query.OrderBy, get)
+func Sort[T any](ctx context.Context, records []T, keys []tsl.SortKey, get Accessor[T], opts ...Option) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	w := &walker{ctx: ctx}
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+
+	// Evaluate the sort keys of each record
+	type sortable struct {
+		record T
+		values []interface{}
+	}
+	items := make([]sortable, len(records))
+	for i, record := range records {
+		eval := nullableEval(record, get)
+
+		items[i] = sortable{record: record, values: make([]interface{}, len(keys))}
+		for j, key := range keys {
+			value, err := WalkContext(ctx, key.Expr, eval, opts...)
+			if err != nil {
+				return tsl.RecordError{Index: i, Err: err}
+			}
+			items[i].values[j] = value
+		}
+	}
+
+	var sortErr error
+	slices.SortStableFunc(items, func(a, b sortable) int {
+		for j, key := range keys {
+			cmp, err := w.compareSortValues(a.values[j], b.values[j])
+			if err != nil {
+				sortErr = err
+				return 0
+			}
+			if key.Desc {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp
+			}
+		}
+		return 0
+	})
+	if sortErr != nil {
+		return sortErr
+	}
+
+	for i, item := range items {
+		records[i] = item.record
+	}
+	return nil
+}
+
+// ApplyQuery filters, sorts and pages records using a query, the input slice
+// is not modified. Sort keys may name SELECT aliases, use Project to evaluate
+// the SELECT clause on the results.
+//
+// Example:
+//
+//	query, err := tsl.ParseQuery("status = 'open' ORDER BY priority DESC LIMIT 20")
+//	page, err := semantics.ApplyQuery(ctx, query, records, get)
+func ApplyQuery[T any](ctx context.Context, query *tsl.Query, records []T, get Accessor[T], opts ...Option) ([]T, error) {
+	var results []T
+	var err error
+
+	if query.Filter != nil {
+		results, err = Filter(ctx, query.Filter, records, get, opts...)
+		if err != nil && results == nil {
+			return nil, err
+		}
+	} else {
+		results = slices.Clone(records)
+	}
+
+	if sortErr := Sort(ctx, results, resolveAliases(query), get, opts...); sortErr != nil {
+		return nil, errors.Join(err, sortErr)
+	}
+
+	// Paging
+	if query.Offset > 0 {
+		results = results[min(query.Offset, len(results)):]
+	}
+	if query.Limit != nil {
+		results = results[:min(*query.Limit, len(results))]
+	}
+
+	return results, err
+}
+
+// nullableEval returns an evaluation function for a record, missing fields
+// evaluate as nulls
+func nullableEval[T any](record T, get Accessor[T]) EvalContextFunc {
+	return func(_ context.Context, name string) (interface{}, bool, error) {
+		value, ok := get(record, name)
+		if !ok {
+			return nil, true, nil
+		}
+		return value, true, nil
+	}
+}
+
+// resolveAliases returns the sort keys of a query, with sort keys naming a
+// SELECT alias replaced by the aliased expression
+func resolveAliases(query *tsl.Query) []tsl.SortKey {
+	aliases := map[string]*tsl.TSLNode{}
+	for _, item := range query.Select {
+		if item.Alias != "" {
+			aliases[item.Alias] = item.Expr
+		}
+	}
+	if len(aliases) == 0 {
+		return query.OrderBy
+	}
+
+	keys := make([]tsl.SortKey, len(query.OrderBy))
+	for i, key := range query.OrderBy {
+		keys[i] = key
+		if key.Expr.Type() == tsl.KindIdentifier {
+			if expr, ok := aliases[key.Expr.Value().(string)]; ok {
+				keys[i].Expr = expr
+			}
+		}
+	}
+	return keys
+}
+
+// compareSortValues compares two sort key values, nulls sort last
+func (w *walker) compareSortValues(leftVal, rightVal interface{}) (int, error) {
+	switch {
+	case leftVal == nil && rightVal == nil:
+		return 0, nil
+	case leftVal == nil:
+		return 1, nil
+	case rightVal == nil:
+		return -1, nil
+	}
+
+	// Registered type comparison
+	if c, l, r, ok, err := w.registeredOperands(leftVal, rightVal); ok {
+		if err != nil {
+			return 0, err
+		}
+		return compareRegistered(c, l, r)
+	}
+
+	// IP address and CIDR comparison
+	if cmp, ok, err := compareIPs(leftVal, rightVal); ok {
+		return cmp, err
+	}
+
+	// Semantic version comparison
+	if cmp, ok, err := compareVersions(leftVal, rightVal); ok {
+		return cmp, err
+	}
+
+	// Numeric comparison
+	if cmp, ok := compareNumbers(leftVal, rightVal); ok {
+		return cmp, nil
+	}
+
+	// NaN is not comparable to numbers, it sorts after them
+	if isNaN(leftVal) || isNaN(rightVal) {
+		_, leftIsNum := toNumber(leftVal)
+		_, rightIsNum := toNumber(rightVal)
+		if leftIsNum && rightIsNum {
+			switch {
+			case isNaN(leftVal) && isNaN(rightVal):
+				return 0, nil
+			case isNaN(leftVal):
+				return 1, nil
+			default:
+				return -1, nil
+			}
+		}
+	}
+
+	// Date/time comparison
+	if leftDate, leftIsDate := toDate(leftVal); leftIsDate {
+		if rightDate, rightIsDate := toDate(rightVal); rightIsDate {
+			return leftDate.Compare(rightDate), nil
+		}
+	}
+
+	// String comparison
+	if leftString, ok := leftVal.(string); ok {
+		if rightString, ok := rightVal.(string); ok {
+			return strings.Compare(leftString, rightString), nil
+		}
+	}
+
+	// Boolean comparison, false sorts before true
+	if leftBool, ok := leftVal.(bool); ok {
+		if rightBool, ok := rightVal.(bool); ok {
+			switch {
+			case leftBool == rightBool:
+				return 0, nil
+			case rightBool:
+				return -1, nil
+			default:
+				return 1, nil
+			}
+		}
+	}
+
+	return 0, tsl.TypeMismatchError{Expected: "comparable sort values", Got: fmt.Sprintf("%T and %T", leftVal, rightVal)}
+}
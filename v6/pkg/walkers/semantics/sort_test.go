@@ -0,0 +1,151 @@
+// Copyright 2019 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantics
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("Query", func() {
+	tickets := []map[string]interface{}{
+		{"id": "t1", "status": "open", "priority": 2, "created": "2024-03-01", "version": "v1.10.0"},
+		{"id": "t2", "status": "closed", "priority": 3, "created": "2024-01-15", "version": "v1.9.0"},
+		{"id": "t3", "status": "open", "priority": 3, "created": "2024-02-20", "version": "v1.2.0"},
+		{"id": "t4", "status": "open", "created": "2024-01-01", "version": "v1.10.0-rc.1"},
+		{"id": "t5", "status": "open", "priority": 1.5, "created": "2024-04-10", "version": "v2.0.0"},
+		{"id": "t6", "status": "open", "priority": 3, "created": "2024-01-30", "version": "v1.0.0"},
+	}
+
+	get := func(record map[string]interface{}, name string) (interface{}, bool) {
+		value, ok := record[name]
+		return value, ok
+	}
+
+	ids := func(records []map[string]interface{}) []string {
+		ids := []string{}
+		for _, r := range records {
+			ids = append(ids, r["id"].(string))
+		}
+		return ids
+	}
+
+	DescribeTable("Filters, sorts and pages records",
+		func(text string, expected []string) {
+			query, err := tsl.ParseQuery(text)
+			Expect(err).ToNot(HaveOccurred())
+
+			results, err := ApplyQuery(context.Background(), query, tickets, get)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids(results)).To(Equal(expected))
+		},
+
+		Entry("filter only keeps input order", "status = 'open'",
+			[]string{"t1", "t3", "t4", "t5", "t6"}),
+		Entry("order by number, nulls last", "ORDER BY priority",
+			[]string{"t5", "t1", "t2", "t3", "t6", "t4"}),
+		Entry("order by number descending, nulls first", "ORDER BY priority DESC",
+			[]string{"t4", "t2", "t3", "t6", "t1", "t5"}),
+		Entry("order by several keys", "status = 'open' ORDER BY priority DESC, created LIMIT 20",
+			[]string{"t4", "t6", "t3", "t1", "t5"}),
+		Entry("order by date", "ORDER BY created DESC",
+			[]string{"t5", "t1", "t3", "t6", "t2", "t4"}),
+		Entry("order by string", "ORDER BY status, id DESC",
+			[]string{"t2", "t6", "t5", "t4", "t3", "t1"}),
+		Entry("order by expression", "ORDER BY created = 2024-01-30 DESC, id",
+			[]string{"t6", "t1", "t2", "t3", "t4", "t5"}),
+		Entry("limit", "ORDER BY created LIMIT 2",
+			[]string{"t4", "t2"}),
+		Entry("limit and offset", "ORDER BY created LIMIT 2 OFFSET 2",
+			[]string{"t6", "t3"}),
+		Entry("offset past the end", "ORDER BY created OFFSET 10",
+			[]string{}),
+		Entry("limit zero", "LIMIT 0",
+			[]string{}),
+	)
+
+	It("sorts registered types", func() {
+		registry := NewRegistry()
+		Register(registry, Comparator[tsl.Version]{
+			Compare: func(a, b tsl.Version) int { return a.Compare(b) },
+			Coerce: func(v interface{}) (tsl.Version, bool) {
+				s, ok := v.(string)
+				if !ok {
+					return tsl.Version{}, false
+				}
+				ver, err := tsl.ParseVersion(s)
+				return ver, err == nil
+			},
+		})
+
+		records := []map[string]interface{}{}
+		for _, t := range tickets {
+			ver, err := tsl.ParseVersion(t["version"].(string))
+			Expect(err).ToNot(HaveOccurred())
+			records = append(records, map[string]interface{}{"id": t["id"], "version": ver})
+		}
+
+		query, err := tsl.ParseQuery("ORDER BY version")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = Sort(context.Background(), records, query.OrderBy, get, WithRegistry(registry))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids(records)).To(Equal([]string{"t6", "t3", "t2", "t4", "t1", "t5"}))
+	})
+
+	It("does not modify the input", func() {
+		query, err := tsl.ParseQuery("ORDER BY id DESC")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = ApplyQuery(context.Background(), query, tickets, get)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids(tickets)).To(Equal([]string{"t1", "t2", "t3", "t4", "t5", "t6"}))
+	})
+
+	It("sorts NaN after other numbers", func() {
+		records := []map[string]interface{}{
+			{"id": "a", "value": math.NaN()},
+			{"id": "b", "value": 2},
+			{"id": "c", "value": 1.5},
+		}
+
+		query, err := tsl.ParseQuery("ORDER BY value")
+		Expect(err).ToNot(HaveOccurred())
+
+		results, err := ApplyQuery(context.Background(), query, records, get)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids(results)).To(Equal([]string{"c", "b", "a"}))
+	})
+
+	It("returns an error for values that can not be compared", func() {
+		records := []map[string]interface{}{
+			{"id": "a", "value": 1},
+			{"id": "b", "value": "one"},
+		}
+
+		query, err := tsl.ParseQuery("ORDER BY value")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = ApplyQuery(context.Background(), query, records, get)
+		Expect(errors.As(err, &tsl.TypeMismatchError{})).To(BeTrue())
+	})
+})
@@ -0,0 +1,41 @@
+package semantics
+
+import (
+	"fmt"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// toVersion converts version values and version strings to tsl.Version
+func toVersion(value interface{}) (tsl.Version, bool) {
+	switch v := value.(type) {
+	case tsl.Version:
+		return v, true
+	case string:
+		ver, err := tsl.ParseVersion(v)
+		return ver, err == nil
+	}
+	return tsl.Version{}, false
+}
+
+// isVersion checks if a value is a semantic version value
+func isVersion(value interface{}) bool {
+	_, ok := value.(tsl.Version)
+	return ok
+}
+
+// compareVersions compares two semantic versions, ok is false when neither
+// value is a tsl.Version, strings are parsed as versions
+func compareVersions(leftVal, rightVal interface{}) (cmp int, ok bool, err error) {
+	if !isVersion(leftVal) && !isVersion(rightVal) {
+		return 0, false, nil
+	}
+
+	left, leftOk := toVersion(leftVal)
+	right, rightOk := toVersion(rightVal)
+	if !leftOk || !rightOk {
+		return 0, true, tsl.TypeMismatchError{Expected: "version", Got: fmt.Sprintf("%T and %T", leftVal, rightVal)}
+	}
+
+	return left.Compare(right), true, nil
+}
@@ -20,6 +20,7 @@
 package semantics
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
@@ -70,19 +71,47 @@ type EvalFunc = func(string) (interface{}, bool)
 //	eval := evalFactory(record)
 //	compliance, err = semantics.Walk(tree, eval)
 func Walk(n *tsl.TSLNode, eval EvalFunc) (interface{}, error) {
+	w := &walker{
+		ctx: context.Background(),
+		eval: func(_ context.Context, k string) (interface{}, bool, error) {
+			v, ok := eval(k)
+			return v, ok, nil
+		},
+	}
+	return w.walk(n)
+}
+
+// walker holds the state of a single tree evaluation
+type walker struct {
+	ctx   context.Context
+	eval  EvalContextFunc
+	opts  options
+	steps int
+
+	// group holds the records of the group being evaluated, used by aggregate
+	// functions, nil outside grouped queries
+	group []EvalContextFunc
+}
+
+// walk evaluates a node, charging one step against the evaluation budget
+func (w *walker) walk(n *tsl.TSLNode) (interface{}, error) {
 	if n == nil {
 		return nil, nil
 	}
 
+	if err := w.step(); err != nil {
+		return nil, err
+	}
+
 	switch n.Type() {
 	case tsl.KindIdentifier:
-		return handleIdentifier(n, eval)
+		return w.handleIdentifier(n)
 	case tsl.KindBinaryExpr:
-		return handleBinaryExpression(n, eval)
+		return w.handleBinaryExpression(n)
 	case tsl.KindUnaryExpr:
-		return handleUnaryExpression(n, eval)
+		return w.handleUnaryExpression(n)
 	case tsl.KindArrayLiteral:
-		return handleArrayLiteral(n, eval)
+		return w.handleArrayLiteral(n)
 	case tsl.KindNullLiteral:
 		// null literal should be handled by the is expression
 		return nil, nil
@@ -92,36 +121,41 @@ func Walk(n *tsl.TSLNode, eval EvalFunc) (interface{}, error) {
 }
 
 // handleBinaryExpression handles binary expressions
-func handleBinaryExpression(n *tsl.TSLNode, eval EvalFunc) (interface{}, error) {
+func (w *walker) handleBinaryExpression(n *tsl.TSLNode) (interface{}, error) {
 	exprOp, ok := n.Value().(tsl.TSLExpressionOp)
 	if !ok {
 		return nil, tsl.TypeMismatchError{Expected: "TSLExpressionOp", Got: fmt.Sprintf("%T", n.Value())}
 	}
 
 	// lets walk the right side of the expression
-	rightVal, err := Walk(exprOp.Right, eval)
+	rightVal, err := w.walk(exprOp.Right)
 	if err != nil {
 		return nil, err
 	}
 
 	// lets walk the left side of the expression
-	leftVal, err := Walk(exprOp.Left, eval)
+	leftVal, err := w.walk(exprOp.Left)
 	if err != nil {
 		return nil, err
 	}
 
 	// Evaluate the binary operation
-	return evaluateBinaryExpression(exprOp.Operator, leftVal, rightVal)
+	return w.evaluateBinaryExpression(exprOp.Operator, leftVal, rightVal)
 }
 
 // evaluateBinaryExpression applies a binary operator to the left and right values
-func evaluateBinaryExpression(operator tsl.Operator, leftVal, rightVal interface{}) (interface{}, error) {
+func (w *walker) evaluateBinaryExpression(operator tsl.Operator, leftVal, rightVal interface{}) (interface{}, error) {
 	// Check if left value is an array and handle it by applying the operation to each element
 	if arr, ok := leftVal.([]interface{}); ok {
 		result := make([]interface{}, len(arr))
 
 		for i, val := range arr {
-			opResult, err := evaluateBinaryExpression(operator, val, rightVal)
+			// Each element operation is charged as a step
+			if err := w.step(); err != nil {
+				return nil, err
+			}
+
+			opResult, err := w.evaluateBinaryExpression(operator, val, rightVal)
 			if err != nil {
 				return nil, err
 			}
@@ -131,17 +165,25 @@ func evaluateBinaryExpression(operator tsl.Operator, leftVal, rightVal interface
 		return result, nil
 	}
 
+	// Pattern operators must respect the pattern and input size caps
+	switch operator {
+	case tsl.OpREQ, tsl.OpRNE, tsl.OpLike, tsl.OpILike:
+		if err := w.checkPatternLimits(leftVal, rightVal); err != nil {
+			return nil, err
+		}
+	}
+
 	switch operator {
 	case tsl.OpEQ:
-		return evaluateEquality(leftVal, rightVal)
+		return w.evaluateEquality(leftVal, rightVal)
 	case tsl.OpNE:
-		matched, err := evaluateEquality(leftVal, rightVal)
+		matched, err := w.evaluateEquality(leftVal, rightVal)
 		if err != nil {
 			return nil, err
 		}
 		return !matched, nil
 	case tsl.OpLT, tsl.OpLE, tsl.OpGT, tsl.OpGE:
-		return evaluateCompareExpressions(operator, leftVal, rightVal)
+		return w.evaluateCompareExpressions(operator, leftVal, rightVal)
 	case tsl.OpREQ:
 		return evaluateRegexMatch(leftVal, rightVal)
 	case tsl.OpRNE:
@@ -163,7 +205,7 @@ func evaluateBinaryExpression(operator tsl.Operator, leftVal, rightVal interface
 			return nil, tsl.TypeMismatchError{Expected: "array", Got: fmt.Sprintf("%T", rightVal)}
 		}
 
-		return isValueInArray(leftVal, rightArray)
+		return w.isValueInArray(leftVal, rightArray)
 	case tsl.OpBetween:
 		// Try to extract the array values from the right side of the expression
 		rightArray, ok := rightVal.([]interface{})
@@ -174,7 +216,9 @@ func evaluateBinaryExpression(operator tsl.Operator, leftVal, rightVal interface
 			return nil, tsl.TypeMismatchError{Expected: "min and max values", Got: fmt.Sprintf("%d values", len(rightArray))}
 		}
 
-		return isValueInRange(leftVal, rightArray[0], rightArray[1])
+		return w.isValueInRange(leftVal, rightArray[0], rightArray[1])
+	case tsl.OpWithin:
+		return evaluateWithin(leftVal, rightVal)
 	case tsl.OpIs: // is null
 		if rightVal == nil {
 			return leftVal == nil, nil
@@ -188,13 +232,28 @@ func evaluateBinaryExpression(operator tsl.Operator, leftVal, rightVal interface
 }
 
 // evaluateEquality performs a type‑aware equality check.
-func evaluateEquality(leftVal, rightVal interface{}) (bool, error) {
-	// Numeric comparison
-	if leftNumber, leftIsNumber := toFloat64(leftVal); leftIsNumber {
-		if rightNumber, rightIsNumber := toFloat64(rightVal); rightIsNumber {
-			return leftNumber == rightNumber, nil
+func (w *walker) evaluateEquality(leftVal, rightVal interface{}) (bool, error) {
+	// Registered type comparison
+	if leftVal != nil && rightVal != nil {
+		if c, l, r, ok, err := w.registeredOperands(leftVal, rightVal); ok {
+			if err != nil {
+				return false, err
+			}
+			return c.equal(l, r), nil
 		}
 	}
+	// IP address and CIDR comparison
+	if cmp, ok, err := compareIPs(leftVal, rightVal); ok {
+		return err == nil && cmp == 0, nil
+	}
+	// Semantic version comparison
+	if cmp, ok, err := compareVersions(leftVal, rightVal); ok {
+		return err == nil && cmp == 0, nil
+	}
+	// Numeric comparison
+	if cmp, ok := compareNumbers(leftVal, rightVal); ok {
+		return cmp == 0, nil
+	}
 	// Date/time comparison
 	if leftDateValue, leftIsDate := toDate(leftVal); leftIsDate {
 		if rightDateValue, rightIsDate := toDate(rightVal); rightIsDate {
@@ -212,35 +271,7 @@ func evaluateEquality(leftVal, rightVal interface{}) (bool, error) {
 }
 
 func evaluateMathExpression(operator tsl.Operator, leftVal, rightVal interface{}) (interface{}, error) {
-	leftNum, ok := toFloat64(leftVal)
-	if !ok {
-		return nil, tsl.TypeMismatchError{Expected: "number", Got: fmt.Sprintf("%T", leftVal)}
-	}
-	rightNum, ok := toFloat64(rightVal)
-	if !ok {
-		return nil, tsl.TypeMismatchError{Expected: "number", Got: fmt.Sprintf("%T", rightVal)}
-	}
-
-	switch operator {
-	case tsl.OpPlus:
-		return leftNum + rightNum, nil
-	case tsl.OpMinus:
-		return leftNum - rightNum, nil
-	case tsl.OpStar:
-		return leftNum * rightNum, nil
-	case tsl.OpSlash:
-		if rightNum == 0 {
-			return nil, tsl.DivisionByZeroError{Operation: "division"}
-		}
-		return leftNum / rightNum, nil
-	case tsl.OpPercent:
-		if rightNum == 0 {
-			return nil, tsl.DivisionByZeroError{Operation: "modulus"}
-		}
-		return float64(int64(leftNum) % int64(rightNum)), nil
-	default:
-		return nil, tsl.UnexpectedOperatorError{Operator: operator}
-	}
+	return evaluateArithmetic(operator, leftVal, rightVal)
 }
 
 func evaluateLogicalExpression(operator tsl.Operator, leftVal, rightVal interface{}) (interface{}, error) {
@@ -263,27 +294,48 @@ func evaluateLogicalExpression(operator tsl.Operator, leftVal, rightVal interfac
 	}
 }
 
-func evaluateCompareExpressions(operator tsl.Operator, leftVal, rightVal interface{}) (interface{}, error) {
+func (w *walker) evaluateCompareExpressions(operator tsl.Operator, leftVal, rightVal interface{}) (interface{}, error) {
 	if leftVal == nil || rightVal == nil {
 		return false, nil
 	}
 
-	leftNum, leftIsNum := toFloat64(leftVal)
-	rightNum, rightIsNum := toFloat64(rightVal)
+	// Registered type comparison
+	if c, l, r, ok, err := w.registeredOperands(leftVal, rightVal); ok {
+		if err != nil {
+			return nil, err
+		}
+		cmp, err := compareRegistered(c, l, r)
+		if err != nil {
+			return nil, err
+		}
+		return compareResult(operator, cmp), nil
+	}
+
+	// IP address and CIDR comparison
+	if cmp, ok, err := compareIPs(leftVal, rightVal); ok {
+		if err != nil {
+			return nil, err
+		}
+		return compareResult(operator, cmp), nil
+	}
+
+	// Semantic version comparison
+	if cmp, ok, err := compareVersions(leftVal, rightVal); ok {
+		if err != nil {
+			return nil, err
+		}
+		return compareResult(operator, cmp), nil
+	}
+
+	cmp, isNum := compareNumbers(leftVal, rightVal)
 	leftDate, leftIsDate := toDate(leftVal)
 	rightDate, rightIsDate := toDate(rightVal)
 
-	if leftIsNum && rightIsNum {
-		switch operator {
-		case tsl.OpLT:
-			return leftNum < rightNum, nil
-		case tsl.OpLE:
-			return leftNum <= rightNum, nil
-		case tsl.OpGT:
-			return leftNum > rightNum, nil
-		case tsl.OpGE:
-			return leftNum >= rightNum, nil
-		}
+	if isNum {
+		return compareResult(operator, cmp), nil
+	} else if isNaN(leftVal) || isNaN(rightVal) {
+		// NaN is neither less, equal nor greater than any number
+		return false, nil
 	} else if leftIsDate && rightIsDate {
 		switch operator {
 		case tsl.OpLT:
@@ -301,15 +353,34 @@ func evaluateCompareExpressions(operator tsl.Operator, leftVal, rightVal interfa
 	return nil, nil
 }
 
+// compareResult applies an ordering operator to a -1, 0, +1 comparison result
+func compareResult(operator tsl.Operator, cmp int) bool {
+	switch operator {
+	case tsl.OpLT:
+		return cmp < 0
+	case tsl.OpLE:
+		return cmp <= 0
+	case tsl.OpGT:
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
 // handleUnaryExpression handles unary expressions
-func handleUnaryExpression(n *tsl.TSLNode, eval EvalFunc) (interface{}, error) {
+func (w *walker) handleUnaryExpression(n *tsl.TSLNode) (interface{}, error) {
 	exprOp, ok := n.Value().(tsl.TSLExpressionOp)
 	if !ok {
 		return nil, tsl.TypeMismatchError{Expected: "TSLExpressionOp", Got: fmt.Sprintf("%T", n.Value())}
 	}
 
+	// Aggregate functions evaluate their operand over the group records
+	if tsl.IsAggregate(exprOp.Operator) {
+		return w.evaluateAggregate(exprOp.Operator, exprOp.Right)
+	}
+
 	// lets walk the right side of the expression
-	rightVal, err := Walk(exprOp.Right, eval)
+	rightVal, err := w.walk(exprOp.Right)
 	if err != nil {
 		return nil, err
 	}
@@ -362,17 +433,17 @@ func evaluateArrayUnaryExpression(operator tsl.Operator, arr []interface{}) (int
 
 	case tsl.OpLen:
 		// Return the length of the array
-		return float64(len(arr)), nil
+		return int64(len(arr)), nil
 
 	case tsl.OpSum:
 		// sum all numeric elements
-		var sum float64
+		var sum interface{} = int64(0)
 		for _, val := range arr {
-			num, ok := toFloat64(val)
-			if !ok {
-				return nil, tsl.TypeMismatchError{Expected: "number", Got: fmt.Sprintf("%T", val)}
+			var err error
+			sum, err = evaluateArithmetic(tsl.OpPlus, sum, val)
+			if err != nil {
+				return nil, err
 			}
-			sum += num
 		}
 		return sum, nil
 	}
@@ -399,24 +470,24 @@ func evaluateSingularUnaryExpression(operator tsl.Operator, rightVal interface{}
 		}
 		return !rightBool, nil
 	case tsl.OpUMinus:
-		rightNum, ok := toFloat64(rightVal)
+		rightNum, ok := negateNumber(rightVal)
 		if !ok {
 			return nil, tsl.TypeMismatchError{Expected: "number", Got: fmt.Sprintf("%T", rightVal)}
 		}
-		return -rightNum, nil
+		return rightNum, nil
 	default:
 		return nil, tsl.UnexpectedOperatorError{Operator: operator}
 	}
 }
 
-func handleArrayLiteral(n *tsl.TSLNode, eval EvalFunc) (interface{}, error) {
+func (w *walker) handleArrayLiteral(n *tsl.TSLNode) (interface{}, error) {
 	exprOp, ok := n.Value().(tsl.TSLArrayLiteral)
 	if !ok {
 		return nil, tsl.TypeMismatchError{Expected: "TSLArrayLiteral", Got: fmt.Sprintf("%T", n.Value())}
 	}
 	values := make([]interface{}, len(exprOp.Values))
 	for i, v := range exprOp.Values {
-		val, err := Walk(v, eval)
+		val, err := w.walk(v)
 		if err != nil {
 			return nil, err
 		}
@@ -16,6 +16,10 @@
 package semantics
 
 import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"net/netip"
 	"strings"
 	"testing"
 	"time"
@@ -31,6 +35,13 @@ func TestWalk(t *testing.T) {
 	RunSpecs(t, "Semantic walker")
 }
 
+// mustParse parses a TSL expression, failing the spec on errors
+func mustParse(input string) *tsl.TSLNode {
+	tree, err := tsl.ParseTSL(input)
+	Expect(err).ToNot(HaveOccurred())
+	return tree
+}
+
 var _ = Describe("Walk", func() {
 	// This is the record that we will use for all the tests:
 	date, _ := time.Parse(time.RFC3339, "2020-01-01T00:00:00Z")
@@ -47,6 +58,18 @@ var _ = Describe("Walk", func() {
 		"booleans":     []interface{}{true, false, true},
 		"dateStr":      "2020-01-01T00:00:00Z", // full ISO string
 		"shortDateStr": "2020-01-01",           // short date
+		"id":           int64(9007199254740993),
+		"big_id":       uint64(18446744073709551615),
+		"amount":       tsl.NewDecimal(big.NewRat(101, 10)),
+		"json_amount":  json.Number("19.99"),
+		"ip":           "10.1.2.3",
+		"ip6":          netip.MustParseAddr("2001:db8::1"),
+		"ips":          []interface{}{"10.0.0.1", "192.168.1.1"},
+		"k8s_version":  "v1.28.3",
+		"version":      tsl.Version{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "1"}},
+		"versions":     []interface{}{"1.2.0", "v1.10.0"},
+		"nan":          math.NaN(),
+		"float_id":     float64(9007199254740992),
 	}
 
 	// This is the evaluation function that we will use:
@@ -82,6 +105,11 @@ var _ = Describe("Walk", func() {
 		Entry("not equals string", "author != 'Jane'", true),
 		Entry("like with wildcard", "title like '%good%'", true),
 		Entry("ilike case insensitive", "title ilike '%GOOD%'", true),
+		Entry("like quotes regular expression characters", "title like 'A.good%'", false),
+		Entry("like escaped percent", `'50% off' like '50\\% %'`, true),
+		Entry("like escaped percent is not a wildcard", `'500 off' like '50\\%%'`, false),
+		Entry("like escaped underscore", `'a_b' ilike 'A\\_B'`, true),
+		Entry("like escaped backslash", `'a\\b' like 'a\\\\b'`, true),
 		Entry("regexp equals", "title ~= 'good.*'", true),
 		Entry("regexp not equals", "title ~! '.*bad.*'", true),
 
@@ -115,10 +143,35 @@ var _ = Describe("Walk", func() {
 		Entry("equals date", "date = '2020-01-01T00:00:00Z'", true),
 		Entry("greater than date", "date > '2019-12-31T00:00:00Z'", true),
 		Entry("between dates", "date between '2019-12-31T00:00:00Z' and '2020-01-02T00:00:00Z'", true),
+		Entry("between date literals", "date between 2019-12-31 and 2020-01-01", true),
+		Entry("outside date literals", "date between 2020-01-02 and 2020-02-01", false),
 		Entry("date before", "date < '2021-01-01T00:00:00Z'", true),
 		Entry("date after", "date > '2019-01-01T00:00:00Z'", true),
 
 		// Array operations
+		// NaN is not comparable to numbers
+		Entry("nan equals", "nan = 5", false),
+		Entry("nan not equals", "nan != 5", true),
+		Entry("nan in", "nan in [1, 2]", false),
+		Entry("nan between", "nan between 1 and 2", false),
+		Entry("nan not between", "nan not between 1 and 2", true),
+		Entry("nan greater or equal", "nan >= 5", false),
+		Entry("nan less or equal", "nan <= 5", false),
+		Entry("nan less or equal on the right", "5 <= nan", false),
+		Entry("not nan less than", "not (nan < 5)", true),
+		Entry("nan is not null", "nan is not null", true),
+
+		// Floats are compared exactly with integers and decimals
+		Entry("float equals nearby integer", "float_id = 9007199254740993", false),
+		Entry("float less than nearby integer", "float_id < 9007199254740993", true),
+		Entry("float equals integer", "float_id = 9007199254740992", true),
+		Entry("float in nearby integers", "float_id in [9007199254740991, 9007199254740993]", false),
+		Entry("float between nearby integers", "float_id between 9007199254740993 and 9007199254740995", false),
+		Entry("float equals nearby decimal", "float_id = 9007199254740992.5", false),
+		Entry("float equals its decimal", "price = 29.99", true),
+		Entry("float less than nearby decimal", "price < 29.990000000000001", true),
+		Entry("integer field equals nearby float", "id = float_id", false),
+
 		Entry("in array literal", "spec.rating in [3, 4, 5]", true),
 		Entry("not in array", "spec.pages in [20, 30, 40]", false),
 		Entry("in array identifier", "2 in numbers", true),
@@ -166,25 +219,25 @@ var _ = Describe("Walk", func() {
 		Entry("literal string array like", "['abc', 'def', 'ghi'] like '%e%'", []interface{}{false, true, false}),
 
 		// Literal array operations
-		Entry("literal array addition", "[1, 2, 3] + 4", []interface{}{5.0, 6.0, 7.0}),
-		Entry("literal array subtraction", "[5, 6, 7] - 2", []interface{}{3.0, 4.0, 5.0}),
-		Entry("literal array multiplication", "[2, 3, 4] * 3", []interface{}{6.0, 9.0, 12.0}),
-		Entry("literal array division", "[10, 20, 30] / 10", []interface{}{1.0, 2.0, 3.0}),
-		Entry("literal array modulus", "[10, 11, 12] % 3", []interface{}{1.0, 2.0, 0.0}),
+		Entry("literal array addition", "[1, 2, 3] + 4", []interface{}{int64(5), int64(6), int64(7)}),
+		Entry("literal array subtraction", "[5, 6, 7] - 2", []interface{}{int64(3), int64(4), int64(5)}),
+		Entry("literal array multiplication", "[2, 3, 4] * 3", []interface{}{int64(6), int64(9), int64(12)}),
+		Entry("literal array division", "[10, 20, 30] / 10", []interface{}{int64(1), int64(2), int64(3)}),
+		Entry("literal array modulus", "[10, 11, 12] % 3", []interface{}{int64(1), int64(2), int64(0)}),
 		Entry("literal array comparison", "[1, 5, 10] > 4", []interface{}{false, true, true}),
 		Entry("literal array equals", "[1, 2, 3] = 2", []interface{}{false, true, false}),
 		Entry("literal array not equals", "[1, 2, 3] != 2", []interface{}{true, false, true}),
 
 		// Nested arrays and complex operations
-		Entry("nested array operations", "([1, 2, 3] + 1) * 2", []interface{}{4.0, 6.0, 8.0}),
+		Entry("nested array operations", "([1, 2, 3] + 1) * 2", []interface{}{int64(4), int64(6), int64(8)}),
 
 		// Array operators ANY, ALL, LEN
 		Entry("any with array identifier", "any (numbers > 1)", true),
 		Entry("any with array identifier (false case)", "any (numbers > 5)", false),
 		Entry("all with array identifier (true case)", "all (numbers > 0)", true),
 		Entry("all with array identifier (false case)", "all (numbers > 1)", false),
-		Entry("len with array identifier", "len numbers", 3.0),
-		Entry("len with literal array", "len [1, 2, 3, 4, 5]", 5.0),
+		Entry("len with array identifier", "len numbers", int64(3)),
+		Entry("len with literal array", "len [1, 2, 3, 4, 5]", int64(5)),
 		Entry("any with boolean array", "any booleans", true),
 		Entry("all with boolean array", "all booleans", false),
 		Entry("len in comparison", "len numbers = 3", true),
@@ -193,7 +246,7 @@ var _ = Describe("Walk", func() {
 		Entry("any with string array identifier", "any (tags = 'fiction')", true),
 
 		// Sum operator tests
-		Entry("sum literal array", "sum [1, 2, 3]", 6.0),
+		Entry("sum literal array", "sum [1, 2, 3]", int64(6)),
 		Entry("sum identifier array", "sum numbers", 6.0),
 		Entry("sum on computed array", "sum (numbers * 2)", 12.0),
 		Entry("sum in expression", "sum numbers + 4", 10.0),
@@ -212,6 +265,59 @@ var _ = Describe("Walk", func() {
 		Entry("literal full date = identifier", "'2020-01-01T00:00:00Z' = dateStr", true),
 		Entry("literal short date = identifier", "'2020-01-01' = shortDateStr", true),
 		Entry("string date > earlier literal", "dateStr > '2019-12-31T00:00:00Z'", true),
+
+		// Exact integer and decimal arithmetic
+		Entry("decimal addition", "0.1 + 0.2 = 0.3", true),
+		Entry("decimal addition result", "0.1 + 0.2", tsl.NewDecimal(big.NewRat(3, 10))),
+		Entry("decimal multiplication", "1.1 * 1.1 = 1.21", true),
+		Entry("integer division to decimal", "7 / 2", tsl.NewDecimal(big.NewRat(7, 2))),
+		Entry("decimal result to integer", "0.5 + 0.5", int64(1)),
+		Entry("large integer literal", "id = 9007199254740993", true),
+		Entry("large integer literal neighbour", "id = 9007199254740992", false),
+		Entry("large integer in list", "id in [9007199254740992, 9007199254740993]", true),
+		Entry("large integer between", "id between 9007199254740993 and 9007199254740994", true),
+		Entry("integer overflow promotes", "9223372036854775807 + 1 > 9223372036854775807", true),
+		Entry("uint64 value", "big_id = 18446744073709551615", true),
+		Entry("decimal value", "amount = 10.10", true),
+		Entry("decimal value arithmetic", "amount * 3 = 30.3", true),
+		Entry("json number value", "json_amount - 0.01 = 19.98", true),
+		Entry("size suffix decimal", "1.5Ki", int64(1536)),
+
+		// IP addresses and CIDR
+		Entry("ip within cidr", "ip within 10.0.0.0/8", true),
+		Entry("ip in cidr", "ip in cidr 10.0.0.0/8", true),
+		Entry("ip not within cidr", "ip not within 192.168.0.0/16", true),
+		Entry("ip not in cidr", "ip not in cidr 10.0.0.0/8", false),
+		Entry("ip within cidr string", "ip within '10.1.0.0/16'", true),
+		Entry("ip within cidr list", "ip within [192.168.0.0/16, 10.0.0.0/8]", true),
+		Entry("ipv6 within cidr", "ip6 within 2001:db8::/32", true),
+		Entry("ipv6 outside cidr", "ip6 within 2001:db9::/32", false),
+		Entry("ip equals literal", "ip = 10.1.2.3", true),
+		Entry("ipv6 equals literal", "ip6 = 2001:db8::1", true),
+		Entry("ipv6 loopback literal", "ip6 = ::1", false),
+		Entry("ip greater than literal", "ip > 10.0.0.255", true),
+		Entry("ip in literal list", "ip in [10.1.2.3, 10.1.2.4]", true),
+		Entry("ip array within cidr", "ips within 10.0.0.0/8", []interface{}{true, false}),
+		Entry("any ip within cidr", "any (ips within 192.168.0.0/16)", true),
+
+		// Semantic versions
+		Entry("version greater or equal", "k8s_version >= v1.28.0", true),
+		Entry("version compares numerically", "k8s_version > v1.9.0", true),
+		Entry("version less than", "k8s_version < v1.28.3", false),
+		Entry("version equals", "k8s_version = v1.28.3", true),
+		Entry("version ignores build metadata", "k8s_version = v1.28.3+build.7", true),
+		Entry("version between", "version between v1.2.0 and v1.4.0", true),
+		Entry("version not between", "version not between v1.3.0 and v1.4.0", true),
+		Entry("version in", "k8s_version in [v1.27.0, v1.28.3]", true),
+		Entry("version not in", "k8s_version not in [v1.27.0, v1.28.0]", true),
+		Entry("pre-release before release", "version < v1.3.0", true),
+		Entry("pre-release numeric ordering", "version > v1.3.0-rc.0", true),
+		Entry("pre-release numeric before alphanumeric", "v1.0.0-1 < v1.0.0-alpha", true),
+		Entry("pre-release alphanumeric ordering", "v1.0.0-alpha.beta < v1.0.0-beta", true),
+		Entry("pre-release more identifiers", "v1.0.0-alpha < v1.0.0-alpha.1", true),
+		Entry("pre-release numeric identifiers", "v1.0.0-beta.2 < v1.0.0-beta.11", true),
+		Entry("version array comparison", "versions < v1.3.0", []interface{}{true, false}),
+		Entry("any version", "any (versions >= v1.10.0)", true),
 	)
 })
 
@@ -241,6 +347,8 @@ var _ = Describe("Walk error cases", func() {
 		Entry("Type mismatch number/string", "age = 'young'", tsl.KeyNotFoundError{}),
 		Entry("Invalid operator", "name invalid 'test'", tsl.UnexpectedOperatorError{}),
 		Entry("Invalid between array", "age between [1]", tsl.BetweenOperatorError{}),
+		Entry("Invalid version string", "'latest' < v1.0.0", tsl.TypeMismatchError{}),
+		Entry("Version between mixed types", "v1.2.0 between v1.0.0 and 5", tsl.TypeMismatchError{}),
 	)
 })
 
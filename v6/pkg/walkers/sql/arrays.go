@@ -0,0 +1,354 @@
+package sql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// ChildTable describes a table holding the elements of an array, one row per
+// element, e.g. the prices of the items of an order:
+//
+//	sql.ChildTable{Table: "order_items", Column: "price", ForeignKey: "order_id", Key: "orders.id"}
+type ChildTable struct {
+	// Table is the child table name
+	Table string
+
+	// Column is the child table column holding the element values
+	Column string
+
+	// ForeignKey is the child table column referencing the parent row
+	ForeignKey string
+
+	// Key is the parent column referenced by the foreign key, e.g. orders.id
+	Key string
+}
+
+// WithArrayColumns marks identifiers as array columns, used by the LEN, ANY,
+// ALL and SUM operators in dialects with arrays, e.g. PostgreSQL. The
+// identifiers are still mapped to columns (see WithColumns).
+func WithArrayColumns(names ...string) Option {
+	return func(o *options) {
+		if o.arrays == nil {
+			o.arrays = map[string]bool{}
+		}
+		for _, name := range names {
+			o.arrays[name] = true
+		}
+	}
+}
+
+// WithChildTables maps identifiers to child tables, the LEN, ANY, ALL and SUM
+// operators use EXISTS and aggregate subqueries over the rows of the parent
+// row, e.g. any (items > 100) is
+//
+//	EXISTS (SELECT 1 FROM "order_items" WHERE "order_items"."order_id" = "orders"."id" AND "order_items"."price" > ?)
+func WithChildTables(tables map[string]ChildTable) Option {
+	return func(o *options) {
+		if o.childTables == nil {
+			o.childTables = map[string]ChildTable{}
+		}
+		for name, table := range tables {
+			o.childTables[name] = table
+		}
+	}
+}
+
+// arraySource is the table of the elements of an array identifier
+type arraySource struct {
+	// name is the array identifier
+	name string
+
+	// from is the table expression of the elements, and where the condition
+	// selecting the elements of the current row, empty for all rows
+	from  string
+	where string
+
+	// value is the SQL of an element
+	value string
+
+	// json is set when the values are JSON text, cast by the comparisons
+	json bool
+
+	// column is the quoted column of an array column, empty otherwise
+	column string
+
+	// relation is set for the rows of a to-many relation, whose columns are
+	// used by the identifiers of the relation
+	relation bool
+}
+
+// subquery returns a subquery over the elements, cond is an optional
+// condition on the elements
+func (s arraySource) subquery(columns string, cond string) string {
+	where := s.where
+	switch {
+	case where != "" && cond != "":
+		where += " AND " + cond
+	case cond != "":
+		where = cond
+	}
+	if where != "" {
+		where = " WHERE " + where
+	}
+	return "SELECT " + columns + " FROM " + s.from + where
+}
+
+// arrayStep converts the LEN, ANY, ALL and SUM array operators
+func (w *walker) arrayStep(op tsl.TSLExpressionOp) (fragment, error) {
+	if op.Right.Type() == tsl.KindArrayLiteral {
+		return w.arrayLiteralStep(op)
+	}
+
+	src, err := w.arraySource(op.Operator, op.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk the operand with the array identifier naming one element
+	inner := *w
+	inner.element = &src
+
+	switch op.Operator {
+	case tsl.OpLen:
+		if src.column != "" && isIdentifier(op.Right, src.name) {
+			return expr("cardinality(" + src.column + ")"), nil
+		}
+		return expr("(" + src.subquery("COUNT(*)", "") + ")"), nil
+
+	case tsl.OpSum:
+		value, err := inner.operand(op.Right, nil, op.Operator)
+		if err != nil {
+			return nil, err
+		}
+		return expr("("+src.subquery("COALESCE(SUM(?), 0)", "")+")", value), nil
+	}
+
+	if cmp, value, ok := nativeComparison(src, op.Right); ok {
+		right, err := w.walk(value)
+		if err != nil {
+			return nil, err
+		}
+		if op.Operator == tsl.OpAny {
+			return expr("? "+cmp+" ANY("+src.column+")", right), nil
+		}
+		return expr("(cardinality("+src.column+") > 0 AND ? "+cmp+" ALL("+src.column+"))", right), nil
+	}
+
+	match, err := inner.walk(op.Right)
+	if err != nil {
+		return nil, err
+	}
+	if op.Operator == tsl.OpAny {
+		return expr("EXISTS ("+src.subquery("1", "?")+")", match), nil
+	}
+
+	// ALL is false for empty arrays, and for elements the condition is not
+	// true for, including NULL results
+	return expr("(EXISTS ("+src.subquery("1", "")+") AND NOT EXISTS ("+src.subquery("1", "CASE WHEN ? THEN 1 ELSE 0 END = 0")+"))", match), nil
+}
+
+// arrayLiteralStep converts the array operators of array literals
+func (w *walker) arrayLiteralStep(op tsl.TSLExpressionOp) (fragment, error) {
+	values, err := w.walkArrayValues(op.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Operator {
+	case tsl.OpLen:
+		return expr("?", int64(len(values))), nil
+	case tsl.OpSum:
+		if len(values) == 0 {
+			return expr("?", int64(0)), nil
+		}
+		return expr("("+strings.Repeat("? + ", len(values)-1)+"?)", sqlizersToInterface(values)...), nil
+	case tsl.OpAny:
+		return or(values), nil
+	default:
+		if len(values) == 0 {
+			return or{}, nil
+		}
+		return and(values), nil
+	}
+}
+
+// arraySource returns the elements of the array identifier of an operand,
+// array columns and child tables are preferred over JSON paths, the operand
+// must use one array
+func (w *walker) arraySource(operator tsl.Operator, n *tsl.TSLNode) (arraySource, error) {
+	var names, jsonNames []string
+	seen := map[string]bool{}
+	for _, name := range identifiers(n) {
+		_, child := w.opts.childTables[name]
+		if relation, ok := w.toManyPrefix(name); ok {
+			name = relation
+		} else if !w.opts.arrays[name] && !child {
+			if path, ok, _ := w.jsonPath(name); ok && !path.wildcard {
+				jsonNames = append(jsonNames, name)
+			}
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		names = jsonNames
+	}
+	if len(names) != 1 {
+		return arraySource{}, tsl.ArrayOperandError{Operator: operator, Arrays: names}
+	}
+	name := names[0]
+	d := w.opts.dialect
+
+	if relation, ok := w.opts.relations[name]; ok && relation.Cardinality == ToMany {
+		return w.relationSource(name)
+	}
+
+	if table, ok := w.opts.childTables[name]; ok {
+		return arraySource{
+			name:  name,
+			from:  d.QuoteIdentifier(table.Table),
+			where: d.QuoteIdentifier(table.Table+"."+table.ForeignKey) + " = " + d.QuoteIdentifier(table.Key),
+			value: d.QuoteIdentifier(table.Table + "." + table.Column),
+		}, nil
+	}
+
+	if w.opts.arrays[name] {
+		if !d.Arrays {
+			return arraySource{}, tsl.UnsupportedFeatureError{Feature: "array columns", Dialect: d.Name}
+		}
+		column, err := w.column(name)
+		if err != nil {
+			return arraySource{}, err
+		}
+		return arraySource{name: name, from: "unnest(" + column + ") AS elements(value)", value: "value", column: column}, nil
+	}
+
+	path, _, _ := w.jsonPath(name)
+	if d.JSON.Elements == "" {
+		return arraySource{}, tsl.UnsupportedFeatureError{Feature: "JSON array elements", Dialect: d.Name}
+	}
+	from := fmt.Sprintf(d.JSON.Elements, d.QuoteIdentifier(path.column), path.format(d.JSON))
+	return arraySource{name: name, from: from, value: "value", json: true}, nil
+}
+
+// nativeComparison returns the comparison of an array column element with a
+// value, as the operator of "value op ANY(column)", ok is false when the
+// operand is not such a comparison
+func nativeComparison(src arraySource, n *tsl.TSLNode) (cmp string, value *tsl.TSLNode, ok bool) {
+	if src.column == "" || n.Type() != tsl.KindBinaryExpr {
+		return "", nil, false
+	}
+	op := n.Value().(tsl.TSLExpressionOp)
+	operator, ok := flippedComparisons[op.Operator]
+	switch {
+	case !ok:
+		return "", nil, false
+	case isIdentifier(op.Left, src.name) && len(identifiers(op.Right)) == 0:
+		value = op.Right
+	case isIdentifier(op.Right, src.name) && len(identifiers(op.Left)) == 0:
+		value, operator = op.Left, op.Operator
+	default:
+		return "", nil, false
+	}
+
+	symbols := map[tsl.Operator]string{
+		tsl.OpEQ: "=", tsl.OpNE: "!=", tsl.OpLT: "<", tsl.OpLE: "<=", tsl.OpGT: ">", tsl.OpGE: ">=",
+	}
+	return symbols[operator], value, value.Type() != tsl.KindNullLiteral
+}
+
+// isIdentifier reports if a node is the identifier name
+func isIdentifier(n *tsl.TSLNode, name string) bool {
+	return n != nil && n.Type() == tsl.KindIdentifier && n.Value() == name
+}
+
+// identifiers returns the sorted distinct identifiers of a node
+func identifiers(n *tsl.TSLNode) []string {
+	seen := map[string]bool{}
+	var collect func(n *tsl.TSLNode)
+	collect = func(n *tsl.TSLNode) {
+		if n == nil {
+			return
+		}
+		switch n.Type() {
+		case tsl.KindIdentifier:
+			seen[n.Value().(string)] = true
+		case tsl.KindBinaryExpr, tsl.KindUnaryExpr:
+			op := n.Value().(tsl.TSLExpressionOp)
+			collect(op.Left)
+			collect(op.Right)
+		case tsl.KindArrayLiteral:
+			for _, value := range n.Value().(tsl.TSLArrayLiteral).Values {
+				collect(value)
+			}
+		}
+	}
+	collect(n)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// toManyPrefix returns the to-many relation of an identifier, that is the
+// relation prefix or starts with it
+func (w *walker) toManyPrefix(identifier string) (string, bool) {
+	if relation, ok := w.opts.relations[identifier]; ok && relation.Cardinality == ToMany {
+		return identifier, true
+	}
+	if _, mapped := w.opts.columns[identifier]; mapped {
+		return "", false
+	}
+	prefix, _, ok, _ := w.relationColumn(identifier)
+	return prefix, ok && w.opts.relations[prefix].Cardinality == ToMany
+}
+
+// elementsOf returns the JSON array elements or the related rows a binary
+// operator compares, nil when the operands use neither
+func (w *walker) elementsOf(op tsl.TSLExpressionOp) (*arraySource, error) {
+	if isLogical(op.Operator) {
+		return nil, nil
+	}
+
+	var src *arraySource
+	for _, n := range []*tsl.TSLNode{op.Left, op.Right} {
+		if n == nil || n.Type() != tsl.KindIdentifier {
+			continue
+		}
+		name := n.Value().(string)
+
+		var next arraySource
+		if prefix, ok := w.toManyPrefix(name); ok {
+			if w.element != nil && w.element.name == prefix {
+				continue
+			}
+			var err error
+			if next, err = w.relationSource(prefix); err != nil {
+				return nil, err
+			}
+		} else if path, ok, err := w.jsonPath(name); err == nil && ok && path.wildcard {
+			d := w.opts.dialect
+			if d.JSON.Elements == "" {
+				return nil, tsl.UnsupportedFeatureError{Feature: "JSON array elements", Dialect: d.Name}
+			}
+			next = arraySource{from: fmt.Sprintf(d.JSON.Elements, d.QuoteIdentifier(path.column), path.format(d.JSON))}
+		} else {
+			continue
+		}
+
+		if src != nil && (src.from != next.from || src.where != next.where) {
+			return nil, tsl.InvalidIdentifierError{Identifier: name}
+		}
+		src = &next
+	}
+	return src, nil
+}
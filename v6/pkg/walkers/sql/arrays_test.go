@@ -0,0 +1,169 @@
+package sql
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("Array operators", func() {
+	toSQL := func(d Dialect, input string) (string, []interface{}, error) {
+		tree, err := tsl.ParseTSL(input)
+		Expect(err).ToNot(HaveOccurred())
+
+		filter, err := WalkWithOptions(tree,
+			WithDialect(d),
+			WithAllowedColumns("id", "budget", "tags", "scores"),
+			WithArrayColumns("tags", "scores"),
+			WithChildTables(map[string]ChildTable{
+				"items": {Table: "order_items", Column: "price", ForeignKey: "order_id", Key: "orders.id"},
+			}),
+			WithJSONColumns(map[string]string{"doc": "doc"}),
+		)
+		if err != nil {
+			return "", nil, err
+		}
+		sql, args, err := d.StatementBuilder().Select("*").From("orders").Where(filter).ToSql()
+		Expect(err).ToNot(HaveOccurred())
+		if args == nil {
+			args = []interface{}{}
+		}
+		return sql, args, nil
+	}
+
+	entries := func(d Dialect) func(input string, expectedSQL string, expectedArgs ...interface{}) {
+		return func(input string, expectedSQL string, expectedArgs ...interface{}) {
+			sql, args, err := toSQL(d, input)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sql).To(Equal("SELECT * FROM orders WHERE " + expectedSQL))
+			Expect(args).To(Equal(expectedArgs))
+		}
+	}
+
+	DescribeTable("PostgreSQL array columns", entries(PostgreSQL),
+		Entry("len", "len tags > 2",
+			`cardinality("tags") > $1`, int64(2)),
+		Entry("len of two columns", "len tags = len scores",
+			`cardinality("tags") = cardinality("scores")`),
+		Entry("any", "any (tags = 'x')",
+			`$1 = ANY("tags")`, "x"),
+		Entry("any with the array on the right", "any ('x' = tags)",
+			`$1 = ANY("tags")`, "x"),
+		Entry("all", "all (scores > 50)",
+			`(cardinality("scores") > 0 AND $1 < ALL("scores"))`, int64(50)),
+		Entry("any with arithmetic", "any (scores * 2 > 10)",
+			`EXISTS (SELECT 1 FROM unnest("scores") AS elements(value) WHERE (value * $1) > $2)`, int64(2), int64(10)),
+		Entry("all with arithmetic", "all (scores * 2 > 10)",
+			`(EXISTS (SELECT 1 FROM unnest("scores") AS elements(value)) AND NOT EXISTS (SELECT 1 FROM unnest("scores") AS elements(value) WHERE CASE WHEN (value * $1) > $2 THEN 1 ELSE 0 END = 0))`, int64(2), int64(10)),
+		Entry("any with like", "any (tags like 'a%')",
+			`EXISTS (SELECT 1 FROM unnest("tags") AS elements(value) WHERE value LIKE $1)`, "a%"),
+		Entry("sum", "sum scores > 100",
+			`(SELECT COALESCE(SUM(value), 0) FROM unnest("scores") AS elements(value)) > $1`, int64(100)),
+	)
+
+	DescribeTable("PostgreSQL JSON arrays", entries(PostgreSQL),
+		Entry("len", "len doc.tags > 1",
+			`(SELECT COUNT(*) FROM jsonb_array_elements_text("doc" #> '{tags}')) > $1`, int64(1)),
+		Entry("any", "any (doc.tags = 'x')",
+			`EXISTS (SELECT 1 FROM jsonb_array_elements_text("doc" #> '{tags}') WHERE value = $1)`, "x"),
+		Entry("all", "all (doc.scores > 5)",
+			`(EXISTS (SELECT 1 FROM jsonb_array_elements_text("doc" #> '{scores}')) AND NOT EXISTS (SELECT 1 FROM jsonb_array_elements_text("doc" #> '{scores}') WHERE CASE WHEN (value)::numeric > $1 THEN 1 ELSE 0 END = 0))`, int64(5)),
+		Entry("sum", "sum doc.scores < 10",
+			`(SELECT COALESCE(SUM((value)::numeric), 0) FROM jsonb_array_elements_text("doc" #> '{scores}')) < $1`, int64(10)),
+	)
+
+	DescribeTable("MySQL JSON arrays", entries(MySQL),
+		Entry("len", "len doc.tags > 1",
+			"(SELECT COUNT(*) FROM JSON_TABLE(`doc`, '$.tags[*]' COLUMNS (value TEXT PATH '$')) AS elements) > ?", int64(1)),
+		Entry("any", "any (doc.tags = 'x')",
+			"EXISTS (SELECT 1 FROM JSON_TABLE(`doc`, '$.tags[*]' COLUMNS (value TEXT PATH '$')) AS elements WHERE value = ?)", "x"),
+		Entry("sum", "sum doc.scores < 10",
+			"(SELECT COALESCE(SUM(CAST(value AS DECIMAL(65, 30))), 0) FROM JSON_TABLE(`doc`, '$.scores[*]' COLUMNS (value TEXT PATH '$')) AS elements) < ?", int64(10)),
+	)
+
+	DescribeTable("SQLite JSON arrays", entries(SQLite),
+		Entry("len", "len doc.tags > 1",
+			`(SELECT COUNT(*) FROM json_each("doc", '$.tags')) > ?`, int64(1)),
+		Entry("all", "all (doc.scores > 5)",
+			`(EXISTS (SELECT 1 FROM json_each("doc", '$.scores')) AND NOT EXISTS (SELECT 1 FROM json_each("doc", '$.scores') WHERE CASE WHEN value > ? THEN 1 ELSE 0 END = 0))`, int64(5)),
+	)
+
+	DescribeTable("SQL Server JSON arrays", entries(SQLServer),
+		Entry("any", "any (doc.tags = 'x')",
+			`EXISTS (SELECT 1 FROM OPENJSON([doc], '$.tags') WHERE value = @p1)`, "x"),
+		Entry("sum", "sum doc.scores < 10",
+			`(SELECT COALESCE(SUM(CAST(value AS DECIMAL(38, 10))), 0) FROM OPENJSON([doc], '$.scores')) < @p1`, int64(10)),
+	)
+
+	DescribeTable("PostgreSQL child tables", entries(PostgreSQL),
+		Entry("len", "len items = 0",
+			`(SELECT COUNT(*) FROM "order_items" WHERE "order_items"."order_id" = "orders"."id") = $1`, int64(0)),
+		Entry("any", "any (items > 100)",
+			`EXISTS (SELECT 1 FROM "order_items" WHERE "order_items"."order_id" = "orders"."id" AND "order_items"."price" > $1)`, int64(100)),
+		Entry("all with a parent column", "all (items >= budget)",
+			`(EXISTS (SELECT 1 FROM "order_items" WHERE "order_items"."order_id" = "orders"."id") AND NOT EXISTS (SELECT 1 FROM "order_items" WHERE "order_items"."order_id" = "orders"."id" AND CASE WHEN "order_items"."price" >= "budget" THEN 1 ELSE 0 END = 0))`),
+		Entry("sum", "sum items > 10",
+			`(SELECT COALESCE(SUM("order_items"."price"), 0) FROM "order_items" WHERE "order_items"."order_id" = "orders"."id") > $1`, int64(10)),
+	)
+
+	DescribeTable("MySQL child tables", entries(MySQL),
+		Entry("any", "any (items > 100)",
+			"EXISTS (SELECT 1 FROM `order_items` WHERE `order_items`.`order_id` = `orders`.`id` AND `order_items`.`price` > ?)", int64(100)),
+		Entry("sum", "sum items > 10",
+			"(SELECT COALESCE(SUM(`order_items`.`price`), 0) FROM `order_items` WHERE `order_items`.`order_id` = `orders`.`id`) > ?", int64(10)),
+	)
+
+	DescribeTable("SQL Server child tables", entries(SQLServer),
+		Entry("len", "len items = 0",
+			`(SELECT COUNT(*) FROM [order_items] WHERE [order_items].[order_id] = [orders].[id]) = @p1`, int64(0)),
+	)
+
+	DescribeTable("array literals", entries(PostgreSQL),
+		Entry("len", "len [1, 2, 3] = 3",
+			`$1 = $2`, int64(3), int64(3)),
+		Entry("sum", "sum [id, 2] > 3",
+			`("id" + $1) > $2`, int64(2), int64(3)),
+		Entry("empty sum", "sum [] = 0",
+			`$1 = $2`, int64(0), int64(0)),
+		Entry("any", "any [id > 1, id < 0]",
+			`("id" > $1 OR "id" < $2)`, int64(1), int64(0)),
+		Entry("empty any", "any []",
+			`(1=0)`),
+		Entry("empty all", "all []",
+			`(1=0)`),
+	)
+
+	DescribeTable("rejects operands without exactly one array",
+		func(input string, expected error) {
+			_, _, err := toSQL(PostgreSQL, input)
+			Expect(err).To(Equal(expected))
+		},
+		Entry("two arrays", "any (tags = scores)",
+			tsl.ArrayOperandError{Operator: tsl.OpAny, Arrays: []string{"scores", "tags"}}),
+		Entry("no array", "any (id > 1)",
+			tsl.ArrayOperandError{Operator: tsl.OpAny}),
+		Entry("JSON wildcards", "len doc.tags[*] > 1",
+			tsl.ArrayOperandError{Operator: tsl.OpLen}),
+	)
+
+	DescribeTable("rejects arrays the dialect cannot read",
+		func(d Dialect, input string, expected error) {
+			_, _, err := toSQL(d, input)
+			Expect(err).To(Equal(expected))
+		},
+		Entry("SQLite array columns", SQLite, "len tags > 2",
+			tsl.UnsupportedFeatureError{Feature: "array columns", Dialect: "sqlite"}),
+		Entry("MySQL array columns", MySQL, "any (tags = 'x')",
+			tsl.UnsupportedFeatureError{Feature: "array columns", Dialect: "mysql"}),
+		Entry("SQL Server array columns", SQLServer, "sum scores > 1",
+			tsl.UnsupportedFeatureError{Feature: "array columns", Dialect: "sqlserver"}),
+	)
+
+	It("rejects JSON arrays without an elements function", func() {
+		d := PostgreSQL
+		d.JSON.Elements = ""
+		_, _, err := toSQL(d, "any (doc.tags = 'x')")
+		Expect(err).To(Equal(tsl.UnsupportedFeatureError{Feature: "JSON array elements", Dialect: "postgresql"}))
+	})
+})
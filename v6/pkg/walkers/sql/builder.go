@@ -0,0 +1,208 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// fragment is a piece of SQL with "?" placeholders and its arguments, it
+// implements squirrel.Sqlizer
+type fragment interface {
+	ToSql() (string, []interface{}, error)
+}
+
+// sqlExpr is an SQL fragment, arguments that are fragments are inlined
+type sqlExpr struct {
+	sql  string
+	args []interface{}
+}
+
+// expr returns an SQL fragment, e.g. expr("? > ?", column, value)
+func expr(sql string, args ...interface{}) fragment {
+	return sqlExpr{sql: sql, args: args}
+}
+
+// ToSql returns the SQL of the fragment, replacing the placeholders of
+// fragment arguments by their SQL
+func (e sqlExpr) ToSql() (string, []interface{}, error) {
+	var b strings.Builder
+	var args []interface{}
+
+	sql := e.sql
+	for _, arg := range e.args {
+		i := strings.Index(sql, "?")
+		if i < 0 {
+			return "", nil, fmt.Errorf("too many arguments for %q", e.sql)
+		}
+		b.WriteString(sql[:i])
+		sql = sql[i+1:]
+
+		f, ok := arg.(fragment)
+		if !ok {
+			b.WriteString("?")
+			args = append(args, arg)
+			continue
+		}
+		s, fargs, err := f.ToSql()
+		if err != nil {
+			return "", nil, err
+		}
+		b.WriteString(s)
+		args = append(args, fargs...)
+	}
+	b.WriteString(sql)
+	return b.String(), args, nil
+}
+
+// and is the conjunction of fragments, true when empty
+type and []fragment
+
+// ToSql returns the parenthesized conjunction
+func (a and) ToSql() (string, []interface{}, error) {
+	return joinFragments(a, " AND ", "(1=1)")
+}
+
+// or is the disjunction of fragments, false when empty
+type or []fragment
+
+// ToSql returns the parenthesized disjunction
+func (o or) ToSql() (string, []interface{}, error) {
+	return joinFragments(o, " OR ", "(1=0)")
+}
+
+// joinFragments returns the parenthesized fragments joined by a separator,
+// fragments without SQL are skipped
+func joinFragments(fragments []fragment, sep string, empty string) (string, []interface{}, error) {
+	if len(fragments) == 0 {
+		return empty, []interface{}{}, nil
+	}
+
+	var parts []string
+	var args []interface{}
+	for _, f := range fragments {
+		s, fargs, err := f.ToSql()
+		if err != nil {
+			return "", nil, err
+		}
+		if s != "" {
+			parts = append(parts, s)
+			args = append(args, fargs...)
+		}
+	}
+	if len(parts) == 0 {
+		return "", args, nil
+	}
+	return "(" + strings.Join(parts, sep) + ")", args, nil
+}
+
+// Filter is a TSL filter converted to SQL text, for database/sql, sqlx, pgx
+// and other libraries that take a query string and arguments.
+type Filter struct {
+	// Where is the SQL condition, using the placeholders of the dialect
+	Where string
+
+	// Args are the arguments of the placeholders
+	Args []interface{}
+
+	// Joins are the JOIN clauses of the to-one relations the filter uses
+	// (see WithRelations)
+	Joins []string
+}
+
+// WithArgOffset numbers placeholders after the arguments of the query the
+// filter is added to, e.g. the first placeholder is $3 in PostgreSQL with an
+// offset of 2. Placeholders that are not numbered, e.g. "?", are not changed.
+func WithArgOffset(offset int) Option {
+	return func(o *options) {
+		o.argOffset = offset
+	}
+}
+
+// NewFilter converts a TSL tree to an SQL condition and its arguments, using
+// the dialect placeholders and the column mapping (see WalkWithOptions).
+//
+//	filter, _ := sql.NewFilter(tree, sql.WithDialect(sql.PostgreSQL), sql.WithAllowedColumns("name"))
+//	rows, _ := db.QueryContext(ctx, "SELECT id FROM users WHERE "+filter.Where, filter.Args...)
+func NewFilter(n *tsl.TSLNode, opts ...Option) (Filter, error) {
+	w := newWalker(opts)
+	f, err := w.walk(n)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	sql, args, err := f.ToSql()
+	if err != nil {
+		return Filter{}, err
+	}
+	sql, err = w.opts.dialect.placeholders(sql, w.opts.argOffset)
+	if err != nil {
+		return Filter{}, err
+	}
+	return Filter{Where: sql, Args: args, Joins: w.joins.clauses}, nil
+}
+
+// ToSQL converts a TSL tree to an SQL condition and its arguments, using the
+// dialect placeholders, filters using to-one relations return a
+// tsl.JoinRequiredError, use NewFilter to get their JOIN clauses.
+//
+//	where, args, _ := sql.ToSQL(tree, sql.WithDialect(sql.PostgreSQL), sql.WithAllowedColumns("name"))
+//	// where: "name" = $1, args: [joe]
+func ToSQL(n *tsl.TSLNode, opts ...Option) (string, []interface{}, error) {
+	filter, err := NewFilter(n, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(filter.Joins) > 0 {
+		return "", nil, tsl.JoinRequiredError{Join: filter.Joins[0]}
+	}
+	return filter.Where, filter.Args, nil
+}
+
+// quoteClosers maps the opening characters of quoted strings and identifiers
+// to their closing characters
+var quoteClosers = map[byte]byte{'\'': '\'', '"': '"', '`': '`', '[': ']'}
+
+// placeholders replaces the "?" placeholders of a query by the placeholders
+// of the dialect, numbered placeholders start after offset arguments, a "?"
+// inside a quoted string or identifier, e.g. a mapped column "a?", is kept
+func (d Dialect) placeholders(sql string, offset int) (string, error) {
+	prefix := ""
+	switch d.Placeholder {
+	case nil, sq.Question:
+		return sql, nil
+	case sq.Dollar:
+		prefix = "$"
+	case sq.AtP:
+		prefix = "@p"
+	case sq.Colon:
+		prefix = ":"
+	default:
+		return d.Placeholder.ReplacePlaceholders(sql)
+	}
+
+	var b strings.Builder
+	n := offset
+	var closer byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case closer != 0:
+			// Doubled closing quotes close and reopen the quoted text
+			if c == closer {
+				closer = 0
+			}
+		case quoteClosers[c] != 0:
+			closer = quoteClosers[c]
+		case c == '?':
+			n++
+			b.WriteString(fmt.Sprintf("%s%d", prefix, n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,133 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// recordingQueryer records the queries it runs
+type recordingQueryer struct {
+	query string
+	args  []interface{}
+}
+
+func (q *recordingQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*dbsql.Rows, error) {
+	q.query, q.args = query, args
+	return nil, nil
+}
+
+var _ = Describe("SQL text", func() {
+	columns := WithAllowedColumns("name", "age", "active", "tags")
+
+	DescribeTable("returns the condition and arguments",
+		func(d Dialect, input string, expectedSQL string, expectedArgs ...interface{}) {
+			where, args, err := ToSQL(mustParse(input), WithDialect(d), columns)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(where).To(Equal(expectedSQL))
+			Expect(args).To(HaveLen(len(expectedArgs)))
+			if len(expectedArgs) > 0 {
+				Expect(args).To(Equal(expectedArgs))
+			}
+		},
+		Entry("postgresql", PostgreSQL, "name = 'joe' and age > 20",
+			`("name" = $1 AND "age" > $2)`, "joe", int64(20)),
+		Entry("mysql", MySQL, "name in ['a', 'b'] or active = true",
+			"(`name` IN (?,?) OR `active` = ?)", "a", "b", true),
+		Entry("sql server", SQLServer, "age between 1 and 2",
+			"[age] BETWEEN @p1 AND @p2", int64(1), int64(2)),
+		Entry("nested fragments", PostgreSQL, "not (name = 'a' or (age + 1) * 2 > 5)",
+			`NOT (("name" = $1 OR (("age" + $2) * $3) > $4))`, "a", int64(1), int64(2), int64(5)),
+		Entry("null", PostgreSQL, "name is null",
+			`"name" IS NULL`),
+	)
+
+	It("matches the squirrel adapter", func() {
+		for _, input := range []string{
+			"name = 'joe' and (age > 20 or active = false)",
+			"name ilike 'j%' and age not between 3 and 4",
+			"not (tags in ['a', 'b'] or name like 'x%')",
+			"(age * 2) % 3 = 1 or name is not null",
+		} {
+			for _, d := range []Dialect{PostgreSQL, MySQL, SQLite, SQLServer} {
+				where, args, err := ToSQL(mustParse(input), WithDialect(d), columns)
+				Expect(err).ToNot(HaveOccurred())
+
+				filter, err := WalkWithOptions(mustParse(input), WithDialect(d), columns)
+				Expect(err).ToNot(HaveOccurred())
+				sql, sqArgs, err := d.StatementBuilder().Select("*").From("t").Where(filter).ToSql()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sql).To(Equal("SELECT * FROM t WHERE "+where), input)
+				Expect(args).To(Equal(sqArgs), input)
+			}
+		}
+	})
+
+	It("numbers placeholders after an offset", func() {
+		where, _, err := ToSQL(mustParse("name = 'joe' and age > 20"), WithDialect(PostgreSQL), columns, WithArgOffset(2))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(where).To(Equal(`("name" = $3 AND "age" > $4)`))
+
+		where, _, err = ToSQL(mustParse("name = 'joe'"), WithDialect(MySQL), columns, WithArgOffset(2))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(where).To(Equal("`name` = ?"))
+	})
+
+	DescribeTable("keeps question marks of quoted text",
+		func(d Dialect, opts []Option, input string, expectedSQL string) {
+			where, _, err := ToSQL(mustParse(input), append([]Option{WithDialect(d)}, opts...)...)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(where).To(Equal(expectedSQL))
+		},
+		Entry("quoted identifier", PostgreSQL, []Option{WithColumns(map[string]string{"q": "why?", "age": "age"})},
+			"q = 'a' and age > 1", `("why?" = $1 AND "age" > $2)`),
+		Entry("closing quote in an identifier", PostgreSQL, []Option{WithColumns(map[string]string{"q": `a"?`})},
+			"q = 1", `"a""?" = $1`),
+		Entry("bracket identifier", SQLServer, []Option{WithColumns(map[string]string{"q": "why?"})},
+			"q = 1", "[why?] = @p1"),
+		Entry("backtick identifier", MySQL, []Option{WithColumns(map[string]string{"q": "why?"})},
+			"q = 1", "`why?` = ?"),
+	)
+
+	It("keeps question marks of string literals", func() {
+		where, err := PostgreSQL.placeholders("a = '?' AND b = 'it''s?' AND c = ?", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(where).To(Equal("a = '?' AND b = 'it''s?' AND c = $1"))
+	})
+
+	It("returns the joins of relations", func() {
+		relations := WithRelations(map[string]Relation{
+			"author": {Table: "authors", Key: "id", ParentKey: "books.author_id", Columns: []string{"country"}},
+		})
+		tree := mustParse("author.country = 'IT'")
+
+		filter, err := NewFilter(tree, WithDialect(PostgreSQL), relations)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filter).To(Equal(Filter{
+			Where: `"author"."country" = $1`,
+			Args:  []interface{}{"IT"},
+			Joins: []string{`LEFT JOIN "authors" AS "author" ON "author"."id" = "books"."author_id"`},
+		}))
+
+		_, _, err = ToSQL(tree, WithDialect(PostgreSQL), relations)
+		Expect(err).To(Equal(tsl.JoinRequiredError{Join: filter.Joins[0]}))
+	})
+
+	It("runs database/sql queries", func() {
+		q := &recordingQueryer{}
+		_, err := Select(context.Background(), q, "books.*", "books", mustParse("author.country = 'IT' and price < 10"),
+			WithDialect(PostgreSQL),
+			WithAllowedColumns("price"),
+			WithRelations(map[string]Relation{
+				"author": {Table: "authors", Key: "id", ParentKey: "books.author_id", Columns: []string{"country"}},
+			}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(q.query).To(Equal(`SELECT books.* FROM books LEFT JOIN "authors" AS "author" ON "author"."id" = "books"."author_id" WHERE ("author"."country" = $1 AND "price" < $2)`))
+		Expect(q.args).To(Equal([]interface{}{"IT", int64(10)}))
+	})
+})
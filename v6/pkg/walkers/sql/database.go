@@ -0,0 +1,47 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"strings"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// Queryer runs queries, it is implemented by the *sql.DB, *sql.Tx and
+// *sql.Conn types of database/sql.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*dbsql.Rows, error)
+}
+
+// Select queries the rows of a table matching a TSL filter, the columns and
+// the table are SQL text, e.g. "id, name" and "users", and the JOIN clauses of
+// the filter are added after the table.
+//
+//	rows, err := sql.Select(ctx, db, "users.id, users.name", "users", tree,
+//	  sql.WithDialect(sql.PostgreSQL),
+//	  sql.WithAllowedColumns("name", "age"),
+//	)
+func Select(ctx context.Context, q Queryer, columns string, table string, n *tsl.TSLNode, opts ...Option) (*dbsql.Rows, error) {
+	query, args, err := SelectQuery(columns, table, n, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return q.QueryContext(ctx, query, args...)
+}
+
+// SelectQuery returns the query Select runs, and its arguments.
+func SelectQuery(columns string, table string, n *tsl.TSLNode, opts ...Option) (string, []interface{}, error) {
+	filter, err := NewFilter(n, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT " + columns + " FROM " + table)
+	for _, join := range filter.Joins {
+		b.WriteString(" " + join)
+	}
+	b.WriteString(" WHERE " + filter.Where)
+	return b.String(), filter.Args, nil
+}
@@ -0,0 +1,319 @@
+package sql
+
+import (
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Dialect describes the SQL syntax of a database.
+//
+// The predefined dialects can be copied and changed, e.g. to match regular
+// expressions using a function registered in the database:
+//
+//	d := sql.SQLServer
+//	d.RegexFunction = "dbo.RegexMatch"
+//	filter, err := sql.WalkWithOptions(tree, sql.WithDialect(d))
+type Dialect struct {
+	// Name is the name of the dialect, used in error messages
+	Name string
+
+	// IdentifierQuote holds the opening and closing quotes of identifiers,
+	// e.g. "[" and "]", identifiers are not quoted when empty
+	IdentifierQuote [2]string
+
+	// Placeholder is the placeholder format of the query arguments, filters
+	// use "?" placeholders, replaced by the statement builder (see
+	// StatementBuilder)
+	Placeholder sq.PlaceholderFormat
+
+	// RegexOperator is the regular expression match operator, e.g. "~"
+	RegexOperator string
+
+	// RegexFunction is a function matching a value with a regular expression,
+	// used when RegexOperator is empty, e.g. "REGEXP_LIKE"
+	RegexFunction string
+
+	// ILike is set when the database has a case insensitive LIKE operator,
+	// otherwise ILIKE compares the LOWER() of the value and the pattern
+	ILike bool
+
+	// LikeEscape is set when LIKE needs an ESCAPE clause for the backslash to
+	// escape the wildcards, as it does by default in PostgreSQL and MySQL
+	LikeEscape bool
+
+	// Booleans is set when the database has boolean values, otherwise
+	// booleans are passed as 1 and 0
+	Booleans bool
+
+	// ModFunction is set when the modulus is computed using MOD(a, b)
+	// instead of the % operator
+	ModFunction bool
+
+	// Network is set when the database has IP address and CIDR operators,
+	// used by the WITHIN operator
+	Network bool
+
+	// Arrays is set when the database has array columns, with the
+	// cardinality() and unnest() functions and the ANY and ALL comparisons
+	Arrays bool
+
+	// TimestampLayout is the time.Format layout of timestamps passed as text
+	// by the TimeText mode, time.RFC3339Nano when empty
+	TimestampLayout string
+
+	// Decimal is the fmt template of decimal arguments, passed as exact text,
+	// for databases that compare text with computed numbers as text, e.g.
+	// "CAST(%s AS REAL)", arguments are not cast when empty
+	Decimal string
+
+	// JSON is the syntax of JSON values, used by identifiers routed to JSON
+	// columns (see WithJSONColumns)
+	JSON JSONSyntax
+}
+
+// JSONSyntax describes how a database reads values from JSON columns.
+//
+// The formats are fmt templates with %s verbs, Extract and Elements take the
+// quoted column and the path, the casts take the extracted value.
+type JSONSyntax struct {
+	// Extract reads the text of the value at a path, e.g. "%s #>> '%s'",
+	// JSON paths are not supported when empty
+	Extract string
+
+	// ArrayPath is set when paths are text arrays, e.g. {spec,pages},
+	// otherwise paths are SQL/JSON paths, e.g. $.spec.pages
+	ArrayPath bool
+
+	// Elements is a table of the elements of the array at a path, with the
+	// element values in a "value" column, used by [*] paths
+	Elements string
+
+	// Numeric, Boolean and Timestamp cast values compared with numbers,
+	// booleans and timestamps, values are not cast when empty
+	Numeric   string
+	Boolean   string
+	Timestamp string
+}
+
+// mysqlJSON reads JSON values using the ->> operator and JSON_TABLE of MySQL 8
+var mysqlJSON = JSONSyntax{
+	Extract:   "%s ->> '%s'",
+	Elements:  "JSON_TABLE(%s, '%s[*]' COLUMNS (value TEXT PATH '$')) AS elements",
+	Numeric:   "CAST(%s AS DECIMAL(65, 30))",
+	Boolean:   "(%s = 'true')",
+	Timestamp: "CAST(%s AS DATETIME(6))",
+}
+
+// Predefined dialects
+var (
+	// PostgreSQL uses $1 placeholders, ~ for regular expressions, and has
+	// ILIKE, booleans and inet operators
+	PostgreSQL = Dialect{
+		Name:            "postgresql",
+		IdentifierQuote: [2]string{`"`, `"`},
+		Placeholder:     sq.Dollar,
+		RegexOperator:   "~",
+		ILike:           true,
+		Booleans:        true,
+		Network:         true,
+		Arrays:          true,
+		TimestampLayout: "2006-01-02 15:04:05.999999999-07:00",
+		JSON: JSONSyntax{
+			Extract:   "%s #>> '%s'",
+			ArrayPath: true,
+			Elements:  "jsonb_array_elements_text(%s #> '%s')",
+			Numeric:   "(%s)::numeric",
+			Boolean:   "(%s)::boolean",
+			Timestamp: "(%s)::timestamptz",
+		},
+	}
+
+	// MySQL uses ? placeholders and REGEXP for regular expressions
+	MySQL = Dialect{
+		Name:            "mysql",
+		IdentifierQuote: [2]string{"`", "`"},
+		Placeholder:     sq.Question,
+		RegexOperator:   "REGEXP",
+		Booleans:        true,
+		TimestampLayout: "2006-01-02 15:04:05.999999-07:00",
+		JSON:            mysqlJSON,
+	}
+
+	// SQLite uses ? placeholders, and REGEXP for regular expressions, that
+	// calls the regexp() function the application registers.
+	//
+	// Decimals are cast to REAL: SQLite has no exact decimal type, it already
+	// converts decimal text compared with a numeric column to REAL, but it
+	// compares text with a computed number as text, e.g. "age" * 2 > '40.5'
+	// is always false. Integers remain exact.
+	SQLite = Dialect{
+		Name:            "sqlite",
+		IdentifierQuote: [2]string{`"`, `"`},
+		Placeholder:     sq.Question,
+		RegexOperator:   "REGEXP",
+		LikeEscape:      true,
+		TimestampLayout: "2006-01-02 15:04:05.999999999-07:00",
+		Decimal:         "CAST(%s AS REAL)",
+		JSON: JSONSyntax{
+			Extract:  "json_extract(%s, '%s')",
+			Elements: "json_each(%s, '%s')",
+		},
+	}
+
+	// SQLServer uses @p1 placeholders, and has no regular expressions and no
+	// booleans
+	SQLServer = Dialect{
+		Name:            "sqlserver",
+		IdentifierQuote: [2]string{"[", "]"},
+		Placeholder:     sq.AtP,
+		LikeEscape:      true,
+		TimestampLayout: "2006-01-02 15:04:05.9999999 -07:00",
+		JSON: JSONSyntax{
+			Extract:   "JSON_VALUE(%s, '%s')",
+			Elements:  "OPENJSON(%s, '%s')",
+			Numeric:   "CAST(%s AS DECIMAL(38, 10))",
+			Boolean:   "IIF(%s = 'true', 1, 0)",
+			Timestamp: "CAST(%s AS DATETIMEOFFSET)",
+		},
+	}
+
+	// ANSI is standard SQL, using ? placeholders and MOD(a, b)
+	ANSI = Dialect{
+		Name:            "ansi",
+		IdentifierQuote: [2]string{`"`, `"`},
+		Placeholder:     sq.Question,
+		Booleans:        true,
+		ModFunction:     true,
+		LikeEscape:      true,
+		TimestampLayout: "2006-01-02 15:04:05.999999999-07:00",
+		JSON: JSONSyntax{
+			Extract:   "JSON_VALUE(%s, '%s')",
+			Elements:  "JSON_TABLE(%s, '%s[*]' COLUMNS (value VARCHAR(4000) PATH '$')) AS elements",
+			Numeric:   "CAST(%s AS DECIMAL)",
+			Boolean:   "CAST(%s AS BOOLEAN)",
+			Timestamp: "CAST(%s AS TIMESTAMP WITH TIME ZONE)",
+		},
+	}
+
+	// defaultDialect is the dialect of WalkWithOptions and Walk, mixing the
+	// syntax of databases, e.g. REGEXP of MySQL and ILIKE of PostgreSQL.
+	// WalkWithOptions quotes identifiers with the standard double quotes, so
+	// column names may be reserved words, Walk does not quote them.
+	defaultDialect = Dialect{
+		Name:            "default",
+		IdentifierQuote: [2]string{`"`, `"`},
+		Placeholder:     sq.Question,
+		RegexOperator:   "REGEXP",
+		ILike:           true,
+		Network:         true,
+		TimestampLayout: "2006-01-02 15:04:05.999999999-07:00",
+		JSON:            mysqlJSON,
+	}
+)
+
+// StatementBuilder returns a squirrel statement builder using the placeholder
+// format of the dialect.
+//
+//	sql, args, _ := sql.PostgreSQL.StatementBuilder().
+//	  Select("name").
+//	  From("users").
+//	  Where(filter).
+//	  ToSql()
+func (d Dialect) StatementBuilder() sq.StatementBuilderType {
+	return sq.StatementBuilder.PlaceholderFormat(d.Placeholder)
+}
+
+// QuoteIdentifier quotes the dot separated parts of a column name, e.g.
+// users.name is "users"."name" in PostgreSQL. Closing quotes in the name are
+// doubled.
+func (d Dialect) QuoteIdentifier(name string) string {
+	if d.IdentifierQuote[0] == "" {
+		return name
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = d.quote(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// quote quotes a single identifier
+func (d Dialect) quote(name string) string {
+	if d.IdentifierQuote[0] == "" {
+		return name
+	}
+	closing := d.IdentifierQuote[1]
+	return d.IdentifierQuote[0] + strings.ReplaceAll(name, closing, closing+closing) + closing
+}
+
+// Option configures a WalkWithOptions conversion
+type Option func(*options)
+
+// options holds the conversion options
+type options struct {
+	dialect Dialect
+
+	// columns maps TSL identifiers to column names, identifiers that are not
+	// mapped are rejected
+	columns map[string]string
+
+	// plainIdentifiers is set by Walk, that passes identifiers without a
+	// mapping when they are plain SQL identifiers
+	plainIdentifiers bool
+
+	// timeMode sets how dates and timestamps are passed
+	timeMode TimeMode
+
+	// dateRanges compares columns with date literals by whole days
+	dateRanges bool
+
+	// jsonColumns maps identifier prefixes to JSON columns
+	jsonColumns map[string]string
+
+	// arrays holds the array column identifiers, and childTables maps
+	// identifiers to the child tables holding their elements
+	arrays      map[string]bool
+	childTables map[string]ChildTable
+
+	// relations maps identifier prefixes to related tables
+	relations map[string]Relation
+
+	// argOffset is the number of query arguments before the filter arguments
+	argOffset int
+}
+
+// WithDialect sets the SQL dialect of the conversion
+func WithDialect(d Dialect) Option {
+	return func(o *options) {
+		o.dialect = d
+	}
+}
+
+// WithColumns maps TSL identifiers to column names, e.g. "user" to
+// "customers.name". Identifiers that are not mapped, or allowed using
+// WithAllowedColumns, are rejected. Column names are quoted by the dialect,
+// dots separate the parts of qualified names.
+func WithColumns(columns map[string]string) Option {
+	return func(o *options) {
+		if o.columns == nil {
+			o.columns = map[string]string{}
+		}
+		for identifier, column := range columns {
+			o.columns[identifier] = column
+		}
+	}
+}
+
+// WithAllowedColumns allows TSL identifiers used as column names as is.
+func WithAllowedColumns(names ...string) Option {
+	return func(o *options) {
+		if o.columns == nil {
+			o.columns = map[string]string{}
+		}
+		for _, name := range names {
+			o.columns[name] = name
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package sql
+
+import (
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("WalkWithOptions", func() {
+	columns := []string{"name", "age", "email", "active", "id", "city", "ip", "version"}
+
+	// golden returns the SQL and arguments of a filter in a dialect
+	golden := func(d Dialect, input string) (string, []interface{}, error) {
+		tree, err := tsl.ParseTSL(input)
+		Expect(err).ToNot(HaveOccurred())
+
+		filter, err := WalkWithOptions(tree, WithDialect(d), WithAllowedColumns(columns...))
+		if err != nil {
+			return "", nil, err
+		}
+
+		sql, args, err := d.StatementBuilder().Select("*").From("users").Where(filter).ToSql()
+		Expect(err).ToNot(HaveOccurred())
+		if args == nil {
+			args = []interface{}{}
+		}
+		return sql, args, nil
+	}
+
+	entries := func(d Dialect) func(input string, expectedSQL string, expectedArgs ...interface{}) {
+		return func(input string, expectedSQL string, expectedArgs ...interface{}) {
+			sql, args, err := golden(d, input)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sql).To(Equal(expectedSQL))
+			Expect(args).To(Equal(expectedArgs))
+		}
+	}
+
+	DescribeTable("PostgreSQL", entries(PostgreSQL),
+		Entry("placeholders", "name = 'joe' and age > 20",
+			`SELECT * FROM users WHERE ("name" = $1 AND "age" > $2)`, "joe", int64(20)),
+		Entry("regular expression", "email ~= '.*@gmail.com'",
+			`SELECT * FROM users WHERE "email" ~ $1`, ".*@gmail.com"),
+		Entry("regular expression negation", "email ~! '.*@gmail.com'",
+			`SELECT * FROM users WHERE NOT ("email" ~ $1)`, ".*@gmail.com"),
+		Entry("ilike", "name ilike '%smith%'",
+			`SELECT * FROM users WHERE "name" ILIKE $1`, "%smith%"),
+		Entry("boolean", "active = true",
+			`SELECT * FROM users WHERE "active" = $1`, true),
+		Entry("modulus", "id % 2 = 0",
+			`SELECT * FROM users WHERE ("id" % $1) = $2`, int64(2), int64(0)),
+		Entry("in", "city in ['rome', 'paris']",
+			`SELECT * FROM users WHERE "city" IN ($1,$2)`, "rome", "paris"),
+		Entry("within", "ip within 10.0.0.0/8",
+			`SELECT * FROM users WHERE "ip" <<= $1`, "10.0.0.0/8"),
+	)
+
+	DescribeTable("MySQL", entries(MySQL),
+		Entry("placeholders", "name = 'joe' and age > 20",
+			"SELECT * FROM users WHERE (`name` = ? AND `age` > ?)", "joe", int64(20)),
+		Entry("regular expression", "email ~= '.*@gmail.com'",
+			"SELECT * FROM users WHERE `email` REGEXP ?", ".*@gmail.com"),
+		Entry("regular expression negation", "email ~! '.*@gmail.com'",
+			"SELECT * FROM users WHERE NOT (`email` REGEXP ?)", ".*@gmail.com"),
+		Entry("ilike", "name ilike '%smith%'",
+			"SELECT * FROM users WHERE LOWER(`name`) LIKE LOWER(?)", "%smith%"),
+		Entry("boolean", "active = true",
+			"SELECT * FROM users WHERE `active` = ?", true),
+		Entry("modulus", "id % 2 = 0",
+			"SELECT * FROM users WHERE (`id` % ?) = ?", int64(2), int64(0)),
+	)
+
+	DescribeTable("SQLite", entries(SQLite),
+		Entry("placeholders", "name = 'joe' and age > 20",
+			`SELECT * FROM users WHERE ("name" = ? AND "age" > ?)`, "joe", int64(20)),
+		Entry("regular expression", "email ~= '.*@gmail.com'",
+			`SELECT * FROM users WHERE "email" REGEXP ?`, ".*@gmail.com"),
+		Entry("like", `name like 'a\\_%'`,
+			`SELECT * FROM users WHERE "name" LIKE ? ESCAPE '\'`, `a\_%`),
+		Entry("ilike", "name ilike '%smith%'",
+			`SELECT * FROM users WHERE LOWER("name") LIKE LOWER(?) ESCAPE '\'`, "%smith%"),
+		Entry("boolean", "active = true",
+			`SELECT * FROM users WHERE "active" = ?`, 1),
+		Entry("modulus", "id % 2 = 0",
+			`SELECT * FROM users WHERE ("id" % ?) = ?`, int64(2), int64(0)),
+		Entry("decimal", "age * 2 > 40.5",
+			`SELECT * FROM users WHERE ("age" * ?) > CAST(? AS REAL)`, int64(2), tsl.NewDecimal(big.NewRat(81, 2))),
+		Entry("decimal column", "age < 9.99",
+			`SELECT * FROM users WHERE "age" < CAST(? AS REAL)`, tsl.NewDecimal(big.NewRat(999, 100))),
+	)
+
+	DescribeTable("SQL Server", entries(SQLServer),
+		Entry("placeholders", "name = 'joe' and age > 20",
+			"SELECT * FROM users WHERE ([name] = @p1 AND [age] > @p2)", "joe", int64(20)),
+		Entry("ilike", "name ilike '%smith%'",
+			"SELECT * FROM users WHERE LOWER([name]) LIKE LOWER(@p1) ESCAPE '\\'", "%smith%"),
+		Entry("boolean", "active = false",
+			"SELECT * FROM users WHERE [active] = @p1", 0),
+		Entry("between", "age between 20 and 30",
+			"SELECT * FROM users WHERE [age] BETWEEN @p1 AND @p2", int64(20), int64(30)),
+	)
+
+	DescribeTable("ANSI", entries(ANSI),
+		Entry("placeholders", "name = 'joe' and age > 20",
+			`SELECT * FROM users WHERE ("name" = ? AND "age" > ?)`, "joe", int64(20)),
+		Entry("ilike", "name ilike '%smith%'",
+			`SELECT * FROM users WHERE LOWER("name") LIKE LOWER(?) ESCAPE '\'`, "%smith%"),
+		Entry("boolean", "active = true",
+			`SELECT * FROM users WHERE "active" = ?`, true),
+		Entry("modulus", "id % 2 = 0",
+			`SELECT * FROM users WHERE MOD("id", ?) = ?`, int64(2), int64(0)),
+	)
+
+	DescribeTable("rejects unsupported operators",
+		func(d Dialect, input string, operator tsl.Operator) {
+			_, _, err := golden(d, input)
+			var unsupported tsl.UnsupportedOperatorError
+			Expect(errors.As(err, &unsupported)).To(BeTrue())
+			Expect(unsupported).To(Equal(tsl.UnsupportedOperatorError{Operator: operator, Dialect: d.Name}))
+		},
+		Entry("within in MySQL", MySQL, "ip within 10.0.0.0/8", tsl.OpWithin),
+		Entry("within in SQLite", SQLite, "ip within 10.0.0.0/8", tsl.OpWithin),
+		Entry("within in SQL Server", SQLServer, "ip within 10.0.0.0/8", tsl.OpWithin),
+		Entry("within in ANSI", ANSI, "ip not within 10.0.0.0/8", tsl.OpWithin),
+		Entry("regular expression in SQL Server", SQLServer, "email ~= 'x'", tsl.OpREQ),
+		Entry("regular expression negation in ANSI", ANSI, "email ~! 'x'", tsl.OpRNE),
+		Entry("version ordering in PostgreSQL", PostgreSQL, "version >= v1.28.0", tsl.OpGE),
+		Entry("version on the left in MySQL", MySQL, "v1.9.0 < version", tsl.OpLT),
+		Entry("version between in SQLite", SQLite, "version between v1.2.0 and v1.10.0", tsl.OpBetween),
+		Entry("version not between in ANSI", ANSI, "version not between v1.2.0 and v1.10.0", tsl.OpBetween),
+	)
+
+	It("uses a regular expression function", func() {
+		d := SQLServer
+		d.RegexFunction = "dbo.RegexMatch"
+		sql, args, err := golden(d, "email ~! '.*@gmail.com'")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sql).To(Equal("SELECT * FROM users WHERE NOT (dbo.RegexMatch([email], @p1) = 1)"))
+		Expect(args).To(Equal([]interface{}{".*@gmail.com"}))
+
+		d = ANSI
+		d.RegexFunction = "REGEXP_LIKE"
+		sql, _, err = golden(d, "email ~= '.*@gmail.com'")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sql).To(Equal(`SELECT * FROM users WHERE REGEXP_LIKE("email", ?)`))
+	})
+
+	It("converts queries", func() {
+		query, err := tsl.ParseQuery("name ilike 'j%' ORDER BY age DESC LIMIT 5")
+		Expect(err).ToNot(HaveOccurred())
+
+		builder, err := WalkQueryWithOptions(query, SQLite.StatementBuilder().Select("*").From("users"), WithDialect(SQLite), WithAllowedColumns("name", "age"))
+		Expect(err).ToNot(HaveOccurred())
+		sql, args, err := builder.ToSql()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sql).To(Equal(`SELECT * FROM users WHERE LOWER("name") LIKE LOWER(?) ESCAPE '\' ORDER BY "age" DESC LIMIT 5`))
+		Expect(args).To(Equal([]interface{}{"j%"}))
+	})
+})
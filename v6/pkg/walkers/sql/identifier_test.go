@@ -0,0 +1,118 @@
+package sql
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("Identifiers", func() {
+	toSQL := func(d Dialect, input string, opts ...Option) (string, error) {
+		filter, err := WalkWithOptions(mustParse(input), append([]Option{WithDialect(d)}, opts...)...)
+		if err != nil {
+			return "", err
+		}
+		sql, _, err := filter.ToSql()
+		Expect(err).ToNot(HaveOccurred())
+		return sql, nil
+	}
+
+	DescribeTable("maps and quotes identifiers",
+		func(d Dialect, input string, expected string) {
+			sql, err := toSQL(d, input,
+				WithColumns(map[string]string{"user": "customers.name", "odd": `we"ird]col`}),
+				WithAllowedColumns("age", "spec.pages", "labels[0]"),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sql).To(Equal(expected))
+		},
+		Entry("mapped column", PostgreSQL, "user = 'joe'", `"customers"."name" = ?`),
+		Entry("allowed column", MySQL, "age > 20", "`age` > ?"),
+		Entry("qualified column", SQLite, "spec.pages > 20", `"spec"."pages" > ?`),
+		Entry("brackets", SQLServer, "user = 'joe'", "[customers].[name] = ?"),
+		Entry("escapes double quotes", ANSI, "odd = 1", `"we""ird]col" = ?`),
+		Entry("escapes brackets", SQLServer, "odd = 1", `[we"ird]]col] = ?`),
+		Entry("allowed index", PostgreSQL, "labels[0] = 'x'", `"labels[0]" = ?`),
+	)
+
+	DescribeTable("rejects unmapped identifiers",
+		func(input string, identifier string) {
+			_, err := toSQL(PostgreSQL, input, WithAllowedColumns("name", "users.name"))
+			Expect(err).To(Equal(tsl.UnmappedIdentifierError{Identifier: identifier}))
+		},
+		Entry("column", "password = 'x'", "password"),
+		Entry("slash", "name/or/1 = 1", "name/or/1"),
+		Entry("dot", "users.password = 'x'", "users.password"),
+		Entry("brackets", "name[1) or (1 = 1; drop table users; --] = 1", "name[1) or (1 = 1; drop table users; --]"),
+		Entry("in expressions", "name = 'x' and (secret + 1 > 2)", "secret"),
+	)
+
+	It("requires a mapping", func() {
+		_, err := toSQL(PostgreSQL, "name = 'joe'")
+		Expect(err).To(Equal(tsl.UnmappedIdentifierError{Identifier: "name"}))
+
+		_, err = WalkWithOptions(mustParse("name = 'joe'"))
+		Expect(err).To(Equal(tsl.UnmappedIdentifierError{Identifier: "name"}))
+	})
+
+	DescribeTable("Walk rejects identifiers that are not plain SQL identifiers",
+		func(input string, identifier string) {
+			_, err := Walk(mustParse(input))
+			Expect(err).To(Equal(tsl.InvalidIdentifierError{Identifier: identifier}))
+		},
+		Entry("slash", "name/or/1 = 1", "name/or/1"),
+		Entry("empty part", "users..name = 1", "users..name"),
+		Entry("trailing dot", "users. = 1", "users."),
+		Entry("brackets", "name[1) or (1 = 1; drop table users; --] = 1", "name[1) or (1 = 1; drop table users; --]"),
+	)
+
+	It("Walk passes plain identifiers", func() {
+		filter, err := Walk(mustParse("users.name = 'joe' and _age2 > 1"))
+		Expect(err).ToNot(HaveOccurred())
+		sql, _, err := filter.ToSql()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sql).To(Equal("(users.name = ? AND _age2 > ?)"))
+	})
+
+	DescribeTable("quotes reserved words in the default dialect",
+		func(input string, expected string) {
+			filter, err := WalkWithOptions(mustParse(input), WithAllowedColumns("select", "order", "from", "group.user"))
+			Expect(err).ToNot(HaveOccurred())
+			sql, _, err := filter.ToSql()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sql).To(Equal(expected))
+		},
+		Entry("select", "select = 1", `"select" = ?`),
+		Entry("order and from", "order > 2 or from is null", `("order" > ? OR "from" IS NULL)`),
+		Entry("qualified", "group.user like 'a%'", `"group"."user" LIKE ?`),
+	)
+
+	It("quotes reserved words in queries of the default dialect", func() {
+		query, err := tsl.ParseQuery("SELECT user AS table WHERE from = 1 ORDER BY user")
+		Expect(err).ToNot(HaveOccurred())
+
+		builder, err := WalkQueryWithOptions(query, sq.Select().From("tickets"), WithAllowedColumns("user", "from"))
+		Expect(err).ToNot(HaveOccurred())
+		sql, _, err := builder.ToSql()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sql).To(Equal(`SELECT ("user") AS "table" FROM tickets WHERE "from" = ? ORDER BY "user"`))
+	})
+
+	It("quotes result column names", func() {
+		query, err := tsl.ParseQuery("SELECT user, age * 2 AS double ORDER BY double DESC")
+		Expect(err).ToNot(HaveOccurred())
+
+		builder, err := WalkQueryWithOptions(query, PostgreSQL.StatementBuilder().Select().From("customers"),
+			WithDialect(PostgreSQL),
+			WithColumns(map[string]string{"user": "name"}),
+			WithAllowedColumns("age"),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		sql, args, err := builder.ToSql()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sql).To(Equal(`SELECT ("name") AS "user", (("age" * $1)) AS "double" FROM customers ORDER BY "double" DESC`))
+		Expect(args).To(Equal([]interface{}{int64(2)}))
+	})
+})
@@ -0,0 +1,228 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// WithJSONColumns routes identifiers to values in JSON columns, the keys are
+// identifier prefixes and the values are column names, e.g. with
+// {"spec": "documents.spec"} the identifier spec.pages reads the pages key of
+// the documents.spec column, in PostgreSQL "documents"."spec" #>> '{pages}'.
+//
+// Path parts are object keys made of letters, digits and underscores, with
+// optional array indexes, e.g. spec.authors[0].name. A path ending with [*]
+// names the elements of an array, the comparison is true when it is true for
+// any of them, e.g. spec.tags[*] in ['go', 'sql'].
+//
+// Values are read as text, and cast by the dialect when compared with
+// numbers, booleans, timestamps or dates, or used in arithmetic.
+func WithJSONColumns(prefixes map[string]string) Option {
+	return func(o *options) {
+		if o.jsonColumns == nil {
+			o.jsonColumns = map[string]string{}
+		}
+		for prefix, column := range prefixes {
+			o.jsonColumns[prefix] = column
+		}
+	}
+}
+
+// jsonKey is an object key or an array index in a JSON path
+type jsonKey struct {
+	name  string
+	index bool
+}
+
+// jsonPath is the path of a value in a JSON column
+type jsonPath struct {
+	column   string
+	keys     []jsonKey
+	wildcard bool
+}
+
+// jsonPath returns the JSON path of an identifier, ok is false when the
+// identifier is not routed to a JSON column
+func (w *walker) jsonPath(identifier string) (path jsonPath, ok bool, err error) {
+	if _, mapped := w.opts.columns[identifier]; mapped {
+		return path, false, nil
+	}
+
+	// The longest prefix wins, e.g. meta.labels over meta
+	prefix := ""
+	for p := range w.opts.jsonColumns {
+		if strings.HasPrefix(identifier, p+".") && len(p) > len(prefix) {
+			prefix = p
+		}
+	}
+	if prefix == "" {
+		return path, false, nil
+	}
+	path.column = w.opts.jsonColumns[prefix]
+
+	invalid := tsl.InvalidIdentifierError{Identifier: identifier}
+	rest := identifier[len(prefix)+1:]
+	if strings.HasSuffix(rest, "[*]") {
+		path.wildcard = true
+		rest = strings.TrimSuffix(rest, "[*]")
+	}
+
+	for _, part := range strings.Split(rest, ".") {
+		name, indexes, _ := strings.Cut(part, "[")
+		if strings.Contains(name, ".") || !isPlainIdentifier(name) {
+			return path, true, invalid
+		}
+		path.keys = append(path.keys, jsonKey{name: name})
+
+		if indexes == "" {
+			continue
+		}
+		for _, index := range strings.Split(strings.TrimSuffix(indexes, "]"), "][") {
+			if !isDigits(index) || !strings.HasSuffix(indexes, "]") {
+				return path, true, invalid
+			}
+			path.keys = append(path.keys, jsonKey{name: index, index: true})
+		}
+	}
+	return path, true, nil
+}
+
+// isDigits reports if a string is a non empty run of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// format returns the path in the syntax of the dialect, e.g. {a,0,b} or
+// $.a[0].b
+func (p jsonPath) format(syntax JSONSyntax) string {
+	var b strings.Builder
+	if syntax.ArrayPath {
+		b.WriteString("{")
+		for i, key := range p.keys {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(key.name)
+		}
+		b.WriteString("}")
+		return b.String()
+	}
+
+	b.WriteString("$")
+	for _, key := range p.keys {
+		if key.index {
+			b.WriteString("[" + key.name + "]")
+		} else {
+			b.WriteString("." + key.name)
+		}
+	}
+	return b.String()
+}
+
+// identifier returns the SQL of an identifier, JSON values are cast using
+// the cast format, elements is set when the identifier may name the elements
+// of a JSON array or the rows of a to-many relation
+func (w *walker) identifier(name string, cast string, elements bool) (fragment, error) {
+	if w.element != nil && w.element.name == name && !w.element.relation {
+		if w.element.json && cast != "" {
+			return expr(fmt.Sprintf(cast, w.element.value)), nil
+		}
+		return expr(w.element.value), nil
+	}
+
+	if _, mapped := w.opts.columns[name]; !mapped {
+		if s, ok, err := w.relationIdentifier(name, elements); ok {
+			return s, err
+		}
+	}
+
+	path, ok, err := w.jsonPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		column, err := w.column(name)
+		if err != nil {
+			return nil, err
+		}
+		return expr(column), nil
+	}
+
+	syntax := w.opts.dialect.JSON
+	if syntax.Extract == "" {
+		return nil, tsl.UnsupportedFeatureError{Feature: "JSON paths", Dialect: w.opts.dialect.Name}
+	}
+
+	value := "value"
+	switch {
+	case !path.wildcard:
+		value = fmt.Sprintf(syntax.Extract, w.opts.dialect.QuoteIdentifier(path.column), path.format(syntax))
+	case !elements:
+		return nil, tsl.InvalidIdentifierError{Identifier: name}
+	}
+	if cast != "" {
+		value = fmt.Sprintf(cast, value)
+	}
+	return expr(value), nil
+}
+
+// operand returns the SQL of an operand of an operator, JSON values are cast
+// by the type of the other operand. Array elements and related rows are
+// used by binary operators, that are wrapped in EXISTS (see binaryStep).
+func (w *walker) operand(n, other *tsl.TSLNode, operator tsl.Operator) (fragment, error) {
+	if n.Type() != tsl.KindIdentifier {
+		return w.walk(n)
+	}
+	elements := other != nil && !isLogical(operator)
+	return w.identifier(n.Value().(string), w.jsonCast(other, operator), elements)
+}
+
+// jsonCast returns the cast format of JSON values used by an operator with
+// another operand
+func (w *walker) jsonCast(other *tsl.TSLNode, operator tsl.Operator) string {
+	syntax := w.opts.dialect.JSON
+	switch operator {
+	case tsl.OpPlus, tsl.OpMinus, tsl.OpStar, tsl.OpSlash, tsl.OpPercent,
+		tsl.OpUMinus, tsl.OpSum, tsl.OpAggSum, tsl.OpAvg:
+		return syntax.Numeric
+	}
+	if other == nil {
+		return ""
+	}
+
+	switch other.Type() {
+	case tsl.KindNumericLiteral:
+		return syntax.Numeric
+	case tsl.KindBooleanLiteral:
+		return syntax.Boolean
+	case tsl.KindDateLiteral, tsl.KindTimestampLiteral:
+		return syntax.Timestamp
+	case tsl.KindUnaryExpr:
+		if other.Value().(tsl.TSLExpressionOp).Operator == tsl.OpUMinus {
+			return syntax.Numeric
+		}
+	case tsl.KindArrayLiteral:
+		// Arrays cast by the type of their first non null value
+		for _, value := range other.Value().(tsl.TSLArrayLiteral).Values {
+			if value.Type() != tsl.KindNullLiteral {
+				return w.jsonCast(value, operator)
+			}
+		}
+	}
+	return ""
+}
+
+// isLogical reports if an operator combines boolean operands
+func isLogical(operator tsl.Operator) bool {
+	return operator == tsl.OpAnd || operator == tsl.OpOr || operator == tsl.OpNot
+}
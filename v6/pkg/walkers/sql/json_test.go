@@ -0,0 +1,122 @@
+package sql
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("JSON columns", func() {
+	toSQL := func(d Dialect, input string) (string, []interface{}, error) {
+		tree, err := tsl.ParseTSL(input)
+		Expect(err).ToNot(HaveOccurred())
+
+		filter, err := WalkWithOptions(tree,
+			WithDialect(d),
+			WithAllowedColumns("name", "spec.id"),
+			WithJSONColumns(map[string]string{"spec": "spec", "meta.labels": "docs.labels"}),
+		)
+		if err != nil {
+			return "", nil, err
+		}
+		sql, args, err := d.StatementBuilder().Select("*").From("docs").Where(filter).ToSql()
+		Expect(err).ToNot(HaveOccurred())
+		if args == nil {
+			args = []interface{}{}
+		}
+		return sql, args, nil
+	}
+
+	entries := func(d Dialect) func(input string, expectedSQL string, expectedArgs ...interface{}) {
+		return func(input string, expectedSQL string, expectedArgs ...interface{}) {
+			sql, args, err := toSQL(d, input)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sql).To(Equal("SELECT * FROM docs WHERE " + expectedSQL))
+			Expect(args).To(Equal(expectedArgs))
+		}
+	}
+
+	DescribeTable("PostgreSQL", entries(PostgreSQL),
+		Entry("text", "spec.title = 'go'",
+			`"spec" #>> '{title}' = $1`, "go"),
+		Entry("nested keys and indexes", "spec.authors[0].name = 'joe'",
+			`"spec" #>> '{authors,0,name}' = $1`, "joe"),
+		Entry("numbers", "spec.pages > 20",
+			`("spec" #>> '{pages}')::numeric > $1`, int64(20)),
+		Entry("numbers on the right", "20 < spec.pages",
+			`$1 < ("spec" #>> '{pages}')::numeric`, int64(20)),
+		Entry("negative numbers", "spec.delta > -2",
+			`("spec" #>> '{delta}')::numeric > -($1)`, int64(2)),
+		Entry("arithmetic", "spec.pages * 2 > 40",
+			`(("spec" #>> '{pages}')::numeric * $1) > $2`, int64(2), int64(40)),
+		Entry("booleans", "spec.draft = false",
+			`("spec" #>> '{draft}')::boolean = $1`, false),
+		Entry("timestamps", "spec.published > 2024-01-01T00:00:00Z",
+			`("spec" #>> '{published}')::timestamptz > $1`, "2024-01-01 00:00:00"),
+		Entry("in", "spec.pages in [10, 20]",
+			`("spec" #>> '{pages}')::numeric IN ($1,$2)`, int64(10), int64(20)),
+		Entry("between", "spec.pages between 10 and 20",
+			`("spec" #>> '{pages}')::numeric BETWEEN $1 AND $2`, int64(10), int64(20)),
+		Entry("null", "spec.title is null",
+			`"spec" #>> '{title}' IS NULL`),
+		Entry("longest prefix", "meta.labels.team = 'a'",
+			`"docs"."labels" #>> '{team}' = $1`, "a"),
+		Entry("mapped columns first", "spec.id = 1",
+			`"spec"."id" = $1`, int64(1)),
+		Entry("array membership", "spec.tags[*] in ['go', 'sql']",
+			`EXISTS (SELECT 1 FROM jsonb_array_elements_text("spec" #> '{tags}') WHERE value IN ($1,$2))`, "go", "sql"),
+		Entry("array element comparison", "spec.scores[*] > 90",
+			`EXISTS (SELECT 1 FROM jsonb_array_elements_text("spec" #> '{scores}') WHERE (value)::numeric > $1)`, int64(90)),
+		Entry("array membership in logical operators", "name = 'x' and not (spec.tags[*] in ['go'])",
+			`("name" = $1 AND NOT (EXISTS (SELECT 1 FROM jsonb_array_elements_text("spec" #> '{tags}') WHERE value IN ($2))))`, "x", "go"),
+	)
+
+	DescribeTable("MySQL", entries(MySQL),
+		Entry("text", "spec.authors[0].name = 'joe'",
+			"`spec` ->> '$.authors[0].name' = ?", "joe"),
+		Entry("numbers", "spec.pages > 20",
+			"CAST(`spec` ->> '$.pages' AS DECIMAL(65, 30)) > ?", int64(20)),
+		Entry("booleans", "spec.draft = true",
+			"(`spec` ->> '$.draft' = 'true') = ?", true),
+		Entry("array membership", "spec.tags[*] in ['go']",
+			"EXISTS (SELECT 1 FROM JSON_TABLE(`spec`, '$.tags[*]' COLUMNS (value TEXT PATH '$')) AS elements WHERE value IN (?))", "go"),
+	)
+
+	DescribeTable("SQLite", entries(SQLite),
+		Entry("numbers", "spec.pages > 20",
+			`json_extract("spec", '$.pages') > ?`, int64(20)),
+		Entry("booleans", "spec.draft = true",
+			`json_extract("spec", '$.draft') = ?`, 1),
+		Entry("array membership", "spec.tags[*] in ['go']",
+			`EXISTS (SELECT 1 FROM json_each("spec", '$.tags') WHERE value IN (?))`, "go"),
+	)
+
+	DescribeTable("SQL Server", entries(SQLServer),
+		Entry("numbers", "spec.pages > 20",
+			"CAST(JSON_VALUE([spec], '$.pages') AS DECIMAL(38, 10)) > @p1", int64(20)),
+		Entry("booleans", "spec.draft = true",
+			"IIF(JSON_VALUE([spec], '$.draft') = 'true', 1, 0) = @p1", 1),
+		Entry("array membership", "spec.tags[*] in ['go']",
+			"EXISTS (SELECT 1 FROM OPENJSON([spec], '$.tags') WHERE value IN (@p1))", "go"),
+	)
+
+	DescribeTable("rejects invalid paths",
+		func(input string, identifier string) {
+			_, _, err := toSQL(PostgreSQL, input)
+			Expect(err).To(Equal(tsl.InvalidIdentifierError{Identifier: identifier}))
+		},
+		Entry("quotes", "spec.a[x'] = 1", "spec.a[x']"),
+		Entry("slash", "spec.a/b = 1", "spec.a/b"),
+		Entry("empty key", "spec..a = 1", "spec..a"),
+		Entry("empty index", "spec.a[] = 1", "spec.a[]"),
+		Entry("inner wildcard", "spec.a[*].b = 1", "spec.a[*].b"),
+		Entry("wildcard outside comparisons", "spec.tags[*] and name = 'x'", "spec.tags[*]"),
+		Entry("two wildcards", "spec.a[*] = spec.b[*]", "spec.b[*]"),
+	)
+
+	It("requires JSON support in the dialect", func() {
+		_, _, err := toSQL(Dialect{Name: "plain"}, "spec.pages > 1")
+		Expect(err).To(Equal(tsl.UnsupportedFeatureError{Feature: "JSON paths", Dialect: "plain"}))
+	})
+})
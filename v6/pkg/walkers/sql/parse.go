@@ -0,0 +1,717 @@
+package sql
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// ParseWhere parses an SQL boolean expression, e.g. the condition of a WHERE
+// clause, into a TSL tree, for importing filters stored as SQL.
+//
+//	tree, err := sql.ParseWhere("name ILIKE 'jo%' AND age BETWEEN 18 AND 65")
+//	// the same tree as tsl.ParseTSL("name ilike 'jo%' and age between 18 and 65")
+//
+// The supported SQL is the SQL the walker writes:
+//
+//   - comparisons, =, <>, !=, <, <=, > and >=
+//   - [NOT] LIKE, [NOT] ILIKE, [NOT] IN (...), [NOT] BETWEEN ... AND ...,
+//     patterns may use ESCAPE '\', the escape character of TSL patterns
+//   - IS [NOT] NULL
+//   - regular expressions, ~, !~, REGEXP and RLIKE
+//   - arithmetic, +, -, *, /, % and MOD(a, b)
+//   - LOWER(a) LIKE LOWER(b), read as a ILIKE b
+//   - AND, OR, NOT and parentheses
+//   - numbers, 'strings', TRUE, FALSE, DATE '2024-01-31' and
+//     TIMESTAMP '2024-01-31 10:00:00+00:00' literals
+//   - column names, optionally quoted using double quotes, backticks or
+//     brackets, and qualified, e.g. "users"."name" is the identifier users.name
+//
+// Other SQL, e.g. function calls, subqueries, CASE, casts, placeholders and
+// comparisons with NULL, returns a *tsl.SyntaxError with the position of the
+// unsupported construct. An optional leading WHERE keyword is skipped.
+//
+// The walker writes SQL equivalent to the imported SQL, except that in the
+// default TimeLegacy mode timestamps lose their offset, e.g.
+// TIMESTAMP '2024-01-31 10:00:00+05:00' is passed as "2024-01-31 10:00:00",
+// use WithTimeMode(TimeValue) or WithTimeMode(TimeText) to keep it. Without
+// a dialect patterns are written without ESCAPE '\', relying on the default
+// backslash escape of MySQL and PostgreSQL, use WithDialect(SQLite) for SQLite.
+func ParseWhere(where string) (*tsl.TSLNode, error) {
+	p := &whereParser{input: where}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	if p.keyword("WHERE") {
+		p.next()
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t.pos, "unexpected %s", t)
+	}
+	if len(p.lowered) > 0 {
+		first := len(p.input)
+		for _, pos := range p.lowered {
+			if pos < first {
+				first = pos
+			}
+		}
+		return nil, p.errorf(first, "unsupported function call LOWER, only LOWER(a) LIKE LOWER(b) is supported")
+	}
+	return &tsl.TSLNode{Node: n}, nil
+}
+
+// tokenKind is the kind of an SQL token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenQuoted
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+// token is an SQL token, pos and end are rune offsets in the input
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+	end  int
+}
+
+// String returns the token as used in error messages
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of input"
+	case tokenString:
+		return "string '" + t.text + "'"
+	case tokenQuoted:
+		return "identifier " + t.text
+	}
+	return t.text
+}
+
+// symbols are the SQL symbols, longest first
+var symbols = []string{
+	"<=", ">=", "<>", "!=", "!~", "::", "||",
+	"=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ",", ".", "~", ";",
+	"?", "$", ":", "@", "&", "|", "^", "#",
+}
+
+// unsupportedKeywords are SQL keywords without a TSL equivalent
+var unsupportedKeywords = map[string]bool{
+	"SELECT": true, "EXISTS": true, "CASE": true, "WHEN": true, "THEN": true,
+	"ELSE": true, "END": true, "CAST": true, "ESCAPE": true, "ANY": true,
+	"ALL": true, "SOME": true, "INTERVAL": true, "COLLATE": true,
+	"SIMILAR": true, "DISTINCT": true, "SYMMETRIC": true, "UNKNOWN": true,
+	"GLOB": true, "MATCH": true, "OVERLAPS": true, "ARRAY": true,
+	"FROM": true, "WHERE": true, "GROUP": true, "ORDER": true, "LIMIT": true,
+	"UNION": true,
+}
+
+// operatorKeywords are SQL keywords used by the supported operators
+var operatorKeywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "LIKE": true, "ILIKE": true,
+	"IN": true, "BETWEEN": true, "IS": true, "REGEXP": true, "RLIKE": true,
+}
+
+// comparisonOperators are the SQL comparison symbols
+var comparisonOperators = map[string]tsl.Operator{
+	"=": tsl.OpEQ, "<>": tsl.OpNE, "!=": tsl.OpNE,
+	"<": tsl.OpLT, "<=": tsl.OpLE, ">": tsl.OpGT, ">=": tsl.OpGE,
+	"~": tsl.OpREQ, "!~": tsl.OpRNE,
+}
+
+// matchOperators are the SQL keywords of pattern matching operators
+var matchOperators = map[string]tsl.Operator{
+	"LIKE": tsl.OpLike, "ILIKE": tsl.OpILike, "REGEXP": tsl.OpREQ, "RLIKE": tsl.OpREQ,
+}
+
+// whereParser is a recursive descent parser of SQL boolean expressions
+type whereParser struct {
+	input  string
+	tokens []token
+	i      int
+
+	// lowered holds the positions of the LOWER calls not yet read as ILIKE
+	// operands
+	lowered map[*tsl.Node]int
+}
+
+// errorf returns a syntax error at a rune offset of the input
+func (p *whereParser) errorf(pos int, format string, args ...interface{}) error {
+	return &tsl.SyntaxError{Message: fmt.Sprintf(format, args...), Position: pos, Input: p.input}
+}
+
+// tokenize splits the input into tokens, skipping white space and comments
+func (p *whereParser) tokenize() error {
+	runes := []rune(p.input)
+	i := 0
+	for i < len(runes) {
+		c := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			for i += 2; i+1 < len(runes) && (runes[i] != '*' || runes[i+1] != '/'); i++ {
+			}
+			if i+1 >= len(runes) {
+				return p.errorf(start, "unterminated comment")
+			}
+			i += 2
+			continue
+
+		case c == '\'':
+			text, next, ok := quoted(runes, i, '\'')
+			if !ok {
+				return p.errorf(start, "unterminated string")
+			}
+			p.tokens = append(p.tokens, token{kind: tokenString, text: text, pos: start, end: next})
+			i = next
+			continue
+
+		case c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			text, next, ok := quoted(runes, i, closing)
+			if !ok || text == "" {
+				return p.errorf(start, "invalid quoted identifier")
+			}
+			p.tokens = append(p.tokens, token{kind: tokenQuoted, text: text, pos: start, end: next})
+			i = next
+			continue
+
+		case isDigit(c) || (c == '.' && i+1 < len(runes) && isDigit(runes[i+1])):
+			for i < len(runes) && (isDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && isDigit(runes[j]) {
+					for i = j; i < len(runes) && isDigit(runes[i]); i++ {
+					}
+				}
+			}
+			p.tokens = append(p.tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start, end: i})
+			continue
+
+		case c == '_' || unicode.IsLetter(c):
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokenWord, text: string(runes[start:i]), pos: start, end: i})
+			continue
+		}
+
+		matched := false
+		for _, s := range symbols {
+			if strings.HasPrefix(string(runes[i:]), s) {
+				i += len(s)
+				p.tokens = append(p.tokens, token{kind: tokenSymbol, text: s, pos: start, end: i})
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return p.errorf(start, "unexpected character %q", c)
+		}
+	}
+	p.tokens = append(p.tokens, token{kind: tokenEOF, pos: len(runes), end: len(runes)})
+	return nil
+}
+
+// quoted reads a quoted text starting at runes[i], a doubled closing quote
+// is an escaped quote
+func quoted(runes []rune, i int, closing rune) (text string, next int, ok bool) {
+	var b strings.Builder
+	for i++; i < len(runes); i++ {
+		if runes[i] != closing {
+			b.WriteRune(runes[i])
+			continue
+		}
+		if i+1 < len(runes) && runes[i+1] == closing {
+			b.WriteRune(closing)
+			i++
+			continue
+		}
+		return b.String(), i + 1, true
+	}
+	return "", i, false
+}
+
+// isDigit reports if a rune is an ASCII digit
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+// peek returns the current token
+func (p *whereParser) peek() token {
+	return p.tokens[p.i]
+}
+
+// next returns the current token and moves to the next one
+func (p *whereParser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+// keyword reports if the current token is one of the keywords
+func (p *whereParser) keyword(words ...string) bool {
+	t := p.peek()
+	if t.kind != tokenWord {
+		return false
+	}
+	for _, word := range words {
+		if strings.EqualFold(t.text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// symbol reports if the current token is the symbol
+func (p *whereParser) symbol(s string) bool {
+	t := p.peek()
+	return t.kind == tokenSymbol && t.text == s
+}
+
+// expect reads a symbol
+func (p *whereParser) expect(s string) error {
+	if !p.symbol(s) {
+		t := p.peek()
+		return p.errorf(t.pos, "expected %s, got %s", s, t)
+	}
+	p.next()
+	return nil
+}
+
+// binary returns a binary expression node
+func binary(operator tsl.Operator, left, right *tsl.Node, pos int) *tsl.Node {
+	return &tsl.Node{Kind: tsl.KindBinaryExpr, Operator: operator, Left: left, Right: right, Position: pos}
+}
+
+// unary returns a unary expression node
+func unary(operator tsl.Operator, right *tsl.Node, pos int) *tsl.Node {
+	return &tsl.Node{Kind: tsl.KindUnaryExpr, Operator: operator, Right: right, Position: pos}
+}
+
+// parseOr parses a disjunction
+func (p *whereParser) parseOr() (*tsl.Node, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.keyword("OR") {
+		pos := p.next().pos
+		var right *tsl.Node
+		if right, err = p.parseAnd(); err == nil {
+			left = binary(tsl.OpOr, left, right, pos)
+		}
+	}
+	return left, err
+}
+
+// parseAnd parses a conjunction
+func (p *whereParser) parseAnd() (*tsl.Node, error) {
+	left, err := p.parseNot()
+	for err == nil && p.keyword("AND") {
+		pos := p.next().pos
+		var right *tsl.Node
+		if right, err = p.parseNot(); err == nil {
+			left = binary(tsl.OpAnd, left, right, pos)
+		}
+	}
+	return left, err
+}
+
+// parseNot parses a negation, NOT binds looser than the predicates
+func (p *whereParser) parseNot() (*tsl.Node, error) {
+	if !p.keyword("NOT") {
+		return p.parsePredicate()
+	}
+	pos := p.next().pos
+	right, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return unary(tsl.OpNot, right, pos), nil
+}
+
+// parsePredicate parses a comparison, pattern match, IN, BETWEEN or IS NULL
+// predicate, negated predicates are NOT nodes, as in the TSL parser
+func (p *whereParser) parsePredicate() (*tsl.Node, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if operator, ok := comparisonOperators[t.text]; ok && t.kind == tokenSymbol {
+		p.next()
+		right, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return binary(operator, left, right, t.pos), nil
+	}
+
+	negated := false
+	if p.keyword("NOT") {
+		p.next()
+		negated = true
+		if !p.keyword("LIKE", "ILIKE", "REGEXP", "RLIKE", "IN", "BETWEEN") {
+			t := p.peek()
+			return nil, p.errorf(t.pos, "expected LIKE, ILIKE, REGEXP, IN or BETWEEN after NOT, got %s", t)
+		}
+	}
+
+	var n *tsl.Node
+	t = p.peek()
+	switch {
+	case p.keyword("LIKE", "ILIKE", "REGEXP", "RLIKE"):
+		p.next()
+		n, err = p.parseMatch(strings.ToUpper(t.text), left, t.pos)
+	case p.keyword("IN"):
+		p.next()
+		n, err = p.parseIn(left, t.pos)
+	case p.keyword("BETWEEN"):
+		p.next()
+		n, err = p.parseBetween(left, t.pos)
+	case p.keyword("IS"):
+		p.next()
+		n, err = p.parseIs(left, t.pos)
+	default:
+		return left, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if negated {
+		n = unary(tsl.OpNot, n, n.Position)
+	}
+	return n, nil
+}
+
+// parseMatch parses the pattern of a LIKE, ILIKE, REGEXP or RLIKE operator,
+// LOWER(a) LIKE LOWER(b) is a ILIKE b
+func (p *whereParser) parseMatch(keyword string, left *tsl.Node, pos int) (*tsl.Node, error) {
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.keyword("ESCAPE") {
+		p.next()
+		if t := p.peek(); t.kind != tokenString || t.text != `\` {
+			return nil, p.errorf(t.pos, `unsupported escape character, only '\' is supported`)
+		}
+		p.next()
+	}
+
+	operator := matchOperators[keyword]
+	_, lowerLeft := p.lowered[left]
+	_, lowerRight := p.lowered[right]
+	if operator == tsl.OpLike && lowerLeft && lowerRight {
+		delete(p.lowered, left)
+		delete(p.lowered, right)
+		operator = tsl.OpILike
+	}
+	return binary(operator, left, right, pos), nil
+}
+
+// parseIn parses the value list of an IN operator
+func (p *whereParser) parseIn(left *tsl.Node, pos int) (*tsl.Node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if p.keyword("SELECT") {
+		return nil, p.errorf(p.peek().pos, "unsupported subquery")
+	}
+
+	list := &tsl.Node{Kind: tsl.KindArrayLiteral, Position: p.peek().pos}
+	for !p.symbol(")") {
+		if len(list.Children) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		value, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		list.Children = append(list.Children, value)
+	}
+	p.next()
+	return binary(tsl.OpIn, left, list, pos), nil
+}
+
+// parseBetween parses the bounds of a BETWEEN operator
+func (p *whereParser) parseBetween(left *tsl.Node, pos int) (*tsl.Node, error) {
+	list := &tsl.Node{Kind: tsl.KindArrayLiteral, Position: p.peek().pos}
+	low, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keyword("AND") {
+		t := p.peek()
+		return nil, p.errorf(t.pos, "expected AND, got %s", t)
+	}
+	p.next()
+	high, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	list.Children = []*tsl.Node{low, high}
+	return binary(tsl.OpBetween, left, list, pos), nil
+}
+
+// parseIs parses IS NULL and IS NOT NULL
+func (p *whereParser) parseIs(left *tsl.Node, pos int) (*tsl.Node, error) {
+	negated := false
+	if p.keyword("NOT") {
+		p.next()
+		negated = true
+	}
+	t := p.peek()
+	if !p.keyword("NULL") {
+		return nil, p.errorf(t.pos, "unsupported IS predicate, only IS [NOT] NULL is supported")
+	}
+	p.next()
+
+	n := binary(tsl.OpIs, left, &tsl.Node{Kind: tsl.KindNullLiteral, Position: t.pos}, pos)
+	if negated {
+		n = unary(tsl.OpNot, n, pos)
+	}
+	return n, nil
+}
+
+// parseSum parses additions and subtractions
+func (p *whereParser) parseSum() (*tsl.Node, error) {
+	left, err := p.parseProduct()
+	for err == nil && (p.symbol("+") || p.symbol("-")) {
+		t := p.next()
+		operator := tsl.OpPlus
+		if t.text == "-" {
+			operator = tsl.OpMinus
+		}
+		var right *tsl.Node
+		if right, err = p.parseProduct(); err == nil {
+			left = binary(operator, left, right, t.pos)
+		}
+	}
+	return left, err
+}
+
+// parseProduct parses multiplications, divisions and modulo
+func (p *whereParser) parseProduct() (*tsl.Node, error) {
+	operators := map[string]tsl.Operator{"*": tsl.OpStar, "/": tsl.OpSlash, "%": tsl.OpPercent}
+	left, err := p.parseUnary()
+	for err == nil && (p.symbol("*") || p.symbol("/") || p.symbol("%")) {
+		t := p.next()
+		var right *tsl.Node
+		if right, err = p.parseUnary(); err == nil {
+			left = binary(operators[t.text], left, right, t.pos)
+		}
+	}
+	return left, err
+}
+
+// parseUnary parses signs
+func (p *whereParser) parseUnary() (*tsl.Node, error) {
+	switch {
+	case p.symbol("-"):
+		pos := p.next().pos
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unary(tsl.OpUMinus, right, pos), nil
+	case p.symbol("+"):
+		p.next()
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses literals, identifiers, function calls and
+// parenthesized expressions
+func (p *whereParser) parsePrimary() (*tsl.Node, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenNumber:
+		p.next()
+		return p.number(t)
+
+	case tokenString:
+		p.next()
+		return &tsl.Node{Kind: tsl.KindStringLiteral, Value: t.text, Position: t.pos}, nil
+
+	case tokenQuoted:
+		return p.parseIdentifier()
+
+	case tokenWord:
+		word := strings.ToUpper(t.text)
+		switch {
+		case word == "TRUE" || word == "FALSE":
+			p.next()
+			return &tsl.Node{Kind: tsl.KindBooleanLiteral, Value: word == "TRUE", Position: t.pos}, nil
+		case word == "NULL":
+			return nil, p.errorf(t.pos, "unsupported use of NULL, comparisons with NULL are never true, use IS NULL")
+		case (word == "DATE" || word == "TIMESTAMP") && p.tokens[p.i+1].kind == tokenString:
+			p.next()
+			return p.timeLiteral(word, p.next())
+		case unsupportedKeywords[word]:
+			return nil, p.errorf(t.pos, "unsupported SQL keyword %s", word)
+		case operatorKeywords[word]:
+			return nil, p.errorf(t.pos, "unexpected %s", word)
+		case p.tokens[p.i+1].kind == tokenSymbol && p.tokens[p.i+1].text == "(":
+			return p.parseCall()
+		}
+		return p.parseIdentifier()
+
+	case tokenSymbol:
+		switch t.text {
+		case "(":
+			p.next()
+			if p.keyword("SELECT") {
+				return nil, p.errorf(p.peek().pos, "unsupported subquery")
+			}
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if p.symbol(",") {
+				return nil, p.errorf(p.peek().pos, "unsupported row value")
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case "?", "$", ":", "@":
+			return nil, p.errorf(t.pos, "unsupported placeholder %s, filters must use literal values", t.text)
+		}
+	}
+	return nil, p.errorf(t.pos, "unexpected %s", t)
+}
+
+// parseIdentifier parses a column name, the parts of qualified names are
+// joined by dots
+func (p *whereParser) parseIdentifier() (*tsl.Node, error) {
+	first := p.next()
+	parts := []string{first.text}
+	end := first.end
+	for p.symbol(".") && p.peek().pos == end {
+		dot := p.next()
+		t := p.peek()
+		if (t.kind != tokenWord && t.kind != tokenQuoted) || t.pos != dot.end {
+			return nil, p.errorf(t.pos, "expected a column name, got %s", t)
+		}
+		p.next()
+		parts = append(parts, t.text)
+		end = t.end
+	}
+	if p.symbol("::") {
+		return nil, p.errorf(p.peek().pos, "unsupported cast")
+	}
+	return &tsl.Node{Kind: tsl.KindIdentifier, Value: strings.Join(parts, "."), Position: first.pos}, nil
+}
+
+// parseCall parses the function calls the walker writes, MOD(a, b) and
+// LOWER(a) as an operand of LIKE
+func (p *whereParser) parseCall() (*tsl.Node, error) {
+	name := p.next()
+	p.next()
+
+	var args []*tsl.Node
+	for !p.symbol(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+
+	switch word := strings.ToUpper(name.text); {
+	case word == "MOD" && len(args) == 2:
+		return binary(tsl.OpPercent, args[0], args[1], name.pos), nil
+	case word == "LOWER" && len(args) == 1:
+		if p.lowered == nil {
+			p.lowered = map[*tsl.Node]int{}
+		}
+		p.lowered[args[0]] = name.pos
+		return args[0], nil
+	}
+	return nil, p.errorf(name.pos, "unsupported function call %s", name.text)
+}
+
+// number returns a numeric literal, integers that fit in an int64 are int64
+// values and other numbers are tsl.Decimal values, as in the TSL parser
+func (p *whereParser) number(t token) (*tsl.Node, error) {
+	r, ok := new(big.Rat).SetString(t.text)
+	if !ok {
+		return nil, p.errorf(t.pos, "invalid number %s", t.text)
+	}
+	var value interface{} = tsl.NewDecimal(r)
+	if r.IsInt() && r.Num().IsInt64() {
+		value = r.Num().Int64()
+	}
+	return &tsl.Node{Kind: tsl.KindNumericLiteral, Value: value, Position: t.pos}, nil
+}
+
+// timestampLayouts are the accepted layouts of TIMESTAMP literals, values
+// without an offset are UTC
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// timeLiteral returns the node of a DATE or TIMESTAMP literal
+func (p *whereParser) timeLiteral(keyword string, t token) (*tsl.Node, error) {
+	if keyword == "DATE" {
+		if _, err := time.Parse("2006-01-02", t.text); err != nil {
+			return nil, p.errorf(t.pos, "invalid date %q", t.text)
+		}
+		return &tsl.Node{Kind: tsl.KindDateLiteral, Value: t.text, Position: t.pos}, nil
+	}
+
+	for _, layout := range timestampLayouts {
+		if value, err := time.Parse(layout, t.text); err == nil {
+			return &tsl.Node{Kind: tsl.KindTimestampLiteral, Value: value, Position: t.pos}, nil
+		}
+	}
+	return nil, p.errorf(t.pos, "invalid timestamp %q", t.text)
+}
@@ -0,0 +1,197 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// withoutPositions returns a copy of a tree with the node positions, and the
+// unused operators of literals and identifiers, cleared
+func withoutPositions(n *tsl.Node) *tsl.Node {
+	if n == nil {
+		return nil
+	}
+	n = n.Clone()
+	var clear func(n *tsl.Node)
+	clear = func(n *tsl.Node) {
+		if n == nil {
+			return
+		}
+		n.Position = 0
+		if n.Kind != tsl.KindBinaryExpr && n.Kind != tsl.KindUnaryExpr {
+			n.Operator = 0
+		}
+		clear(n.Left)
+		clear(n.Right)
+		for _, child := range n.Children {
+			clear(child)
+		}
+	}
+	clear(n)
+	return n
+}
+
+// inline replaces the "?" placeholders of a condition by SQL literals
+func inline(where string, args []interface{}) string {
+	for _, arg := range args {
+		var literal string
+		switch v := arg.(type) {
+		case string:
+			literal = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+		case bool:
+			literal = strings.ToUpper(fmt.Sprintf("%v", v))
+		default:
+			literal = fmt.Sprintf("%v", v)
+		}
+		where = strings.Replace(where, "?", literal, 1)
+	}
+	return where
+}
+
+var _ = Describe("ParseWhere", func() {
+	DescribeTable("imports SQL as the equivalent TSL tree",
+		func(where string, equivalent string) {
+			imported, err := ParseWhere(where)
+			Expect(err).ToNot(HaveOccurred())
+
+			expected, err := tsl.ParseTSL(equivalent)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(withoutPositions(imported.Node)).To(Equal(withoutPositions(expected.Node)))
+		},
+		Entry("comparison", "age >= 18", "age >= 18"),
+		Entry("not equal", "age <> 18", "age != 18"),
+		Entry("string with quote", "name = 'O''Brien'", `name = "O'Brien"`),
+		Entry("unicode string", "city = 'Zürich'", "city = 'Zürich'"),
+		Entry("decimal", "price < 9.99", "price < 9.99"),
+		Entry("negative number", "delta > -5", "delta > -5"),
+		Entry("booleans", "active = TRUE", "active = true"),
+		Entry("like", "name LIKE 'jo%'", "name like 'jo%'"),
+		Entry("not like", "name NOT LIKE 'jo%'", "name not like 'jo%'"),
+		Entry("like escape", `name LIKE 'a\_%' ESCAPE '\'`, `name like 'a\\_%'`),
+		Entry("not ilike escape", `code NOT ILIKE '50\%%' ESCAPE '\'`, `code not ilike '50\\%%'`),
+		Entry("ilike", "name ilike 'jo%'", "name ilike 'jo%'"),
+		Entry("lower like lower", "LOWER(name) LIKE LOWER('Jo%')", "name ilike 'Jo%'"),
+		Entry("in", "city IN ('Paris', 'Rome')", "city in ['Paris', 'Rome']"),
+		Entry("not in", "id NOT IN (1, 2, 3)", "id not in [1, 2, 3]"),
+		Entry("between", "age BETWEEN 18 AND 65", "age between 18 and 65"),
+		Entry("not between", "age NOT BETWEEN 18 AND 65", "age not between 18 and 65"),
+		Entry("is null", "email IS NULL", "email is null"),
+		Entry("is not null", "email IS NOT NULL", "email is not null"),
+		Entry("regular expression", "name ~ '^jo'", "name ~= '^jo'"),
+		Entry("regexp keyword", "name REGEXP '^jo'", "name ~= '^jo'"),
+		Entry("negated regular expression", "name !~ '^jo'", "name ~! '^jo'"),
+		Entry("arithmetic precedence", "a + b * c - d / 2 > 10", "a + b * c - d / 2 > 10"),
+		Entry("modulo", "MOD(id, 2) = 0", "id % 2 = 0"),
+		Entry("and binds tighter than or", "a = 1 OR b = 2 AND c = 3", "a = 1 or b = 2 and c = 3"),
+		Entry("parentheses", "(a = 1 OR b = 2) AND c = 3", "(a = 1 or b = 2) and c = 3"),
+		Entry("not binds looser than comparisons", "NOT a = 1", "not (a = 1)"),
+		Entry("quoted qualified identifier", `"users"."name" = 'joe'`, "users.name = 'joe'"),
+		Entry("backtick and bracket identifiers", "`age` > 1 AND [size] < 2", "age > 1 and size < 2"),
+		Entry("date", "created > DATE '2024-01-31'", "created > 2024-01-31"),
+		Entry("timestamp", "created > TIMESTAMP '2024-01-31 10:00:00'", "created > 2024-01-31T10:00:00Z"),
+		Entry("leading where and comments", "WHERE age > 1 -- adults\n/* only */ AND active", "age > 1 and active"),
+	)
+
+	DescribeTable("writes SQL that imports back to the same SQL",
+		func(where string, opts ...Option) {
+			walk := func(where string) (string, []interface{}) {
+				tree, err := ParseWhere(where)
+				Expect(err).ToNot(HaveOccurred())
+				filter, err := Walk(tree)
+				if len(opts) > 0 {
+					filter, err = WalkWithOptions(tree, opts...)
+				}
+				Expect(err).ToNot(HaveOccurred())
+				sql, args, err := filter.ToSql()
+				Expect(err).ToNot(HaveOccurred())
+				return sql, args
+			}
+
+			// The walker SQL imports back to a tree the walker writes as the
+			// same SQL
+			sql, args := walk(where)
+			again, againArgs := walk(inline(sql, args))
+			Expect(again).To(Equal(sql))
+			// Booleans of dialects without booleans import as numbers
+			Expect(fmt.Sprint(againArgs)).To(Equal(fmt.Sprint(args)))
+		},
+		Entry("comparisons", "age >= 18 AND name <> 'joe' OR price < 9.99"),
+		Entry("patterns", "name NOT LIKE 'jo%' AND name ILIKE 'x' AND name REGEXP 'a' AND name !~ 'b'"),
+		Entry("lists", "id NOT IN (1, 2) AND age BETWEEN 18 AND 65"),
+		Entry("nulls", "email IS NULL OR NOT phone IS NOT NULL"),
+		Entry("arithmetic", "-(a + b) * c % 3 > 1"),
+		Entry("postgresql", `"users"."name" ~ '^jo' AND active = TRUE AND name ILIKE 'a%'`,
+			WithDialect(PostgreSQL), WithAllowedColumns("users.name", "active", "name")),
+		Entry("mysql", "MOD(id, 2) = 0 AND name ILIKE 'a%' AND name RLIKE 'b'",
+			WithDialect(MySQL), WithAllowedColumns("id", "name")),
+		Entry("sqlite", "active = TRUE AND name ILIKE 'a%'",
+			WithDialect(SQLite), WithAllowedColumns("active", "name")),
+	)
+
+	DescribeTable("walks imported SQL to equivalent SQL",
+		func(where string, expectedSQL string, expectedArgs []interface{}, opts ...Option) {
+			tree, err := ParseWhere(where)
+			Expect(err).ToNot(HaveOccurred())
+			filter, err := Walk(tree)
+			if len(opts) > 0 {
+				filter, err = WalkWithOptions(tree, opts...)
+			}
+			Expect(err).ToNot(HaveOccurred())
+			sql, args, err := filter.ToSql()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sql).To(Equal(expectedSQL))
+			Expect(fmt.Sprint(args)).To(Equal(fmt.Sprint(expectedArgs)))
+		},
+		Entry("timestamp", "created > TIMESTAMP '2024-01-31 10:00:00'",
+			"created > ?", []interface{}{"2024-01-31 10:00:00"}),
+		Entry("timestamp with offset as a value", "created > TIMESTAMP '2024-01-31 10:00:00+05:00'",
+			`"created" > ?`, []interface{}{time.Date(2024, 1, 31, 10, 0, 0, 0, time.FixedZone("", 5*60*60))},
+			WithDialect(PostgreSQL), WithAllowedColumns("created"), WithTimeMode(TimeValue)),
+		Entry("timestamp with offset as text", "created > TIMESTAMP '2024-01-31 10:00:00+05:00'",
+			`"created" > ?`, []interface{}{"2024-01-31 10:00:00+05:00"},
+			WithAllowedColumns("created"), WithTimeMode(TimeText)),
+		Entry("escape in sqlite", `name LIKE 'a\%' ESCAPE '\' AND name NOT LIKE '\_%' ESCAPE '\'`,
+			`("name" LIKE ? ESCAPE '\' AND NOT ("name" LIKE ? ESCAPE '\'))`, []interface{}{`a\%`, `\_%`},
+			WithDialect(SQLite), WithAllowedColumns("name")),
+		Entry("escape in postgresql", `name ILIKE 'a\%' ESCAPE '\'`,
+			`"name" ILIKE ?`, []interface{}{`a\%`},
+			WithDialect(PostgreSQL), WithAllowedColumns("name")),
+	)
+
+	DescribeTable("rejects unsupported SQL with its position",
+		func(where string, position int, message string) {
+			_, err := ParseWhere(where)
+			Expect(err).To(HaveOccurred())
+
+			syntaxErr, ok := err.(*tsl.SyntaxError)
+			Expect(ok).To(BeTrue())
+			Expect(syntaxErr.Position).To(Equal(position))
+			Expect(syntaxErr.Message).To(ContainSubstring(message))
+			Expect(syntaxErr.Input).To(Equal(where))
+		},
+		Entry("function call", "age > 1 AND upper(name) = 'JOE'", 12, "unsupported function call upper"),
+		Entry("lower outside like", "LOWER(name) = 'joe'", 0, "LOWER"),
+		Entry("subquery", "id IN (SELECT id FROM admins)", 7, "subquery"),
+		Entry("exists", "EXISTS (SELECT 1)", 0, "EXISTS"),
+		Entry("case", "CASE WHEN a THEN 1 END = 1", 0, "CASE"),
+		Entry("cast", "age::int > 1", 3, "cast"),
+		Entry("placeholder", "age > ?", 6, "placeholder"),
+		Entry("numbered placeholder", "age > $1", 6, "placeholder"),
+		Entry("comparison with null", "email = NULL", 8, "IS NULL"),
+		Entry("is true", "active IS TRUE", 10, "IS [NOT] NULL"),
+		Entry("other escape character", "name LIKE 'a!%' ESCAPE '!'", 23, "escape character"),
+		Entry("concatenation", "a || b = 'ab'", 2, "||"),
+		Entry("row value", "(a, b) = (1, 2)", 2, "row value"),
+		Entry("trailing input", "a = 1 b", 6, "unexpected b"),
+		Entry("missing operand", "a = ", 4, "end of input"),
+		Entry("unterminated string", "name = 'joe", 7, "unterminated string"),
+		Entry("multiple statements", "a = 1; DROP TABLE users", 5, "unexpected ;"),
+		Entry("position in runes", "név = 'é' AND f(x)", 14, "function call f"),
+	)
+})
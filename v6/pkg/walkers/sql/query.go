@@ -0,0 +1,154 @@
+package sql
+
+import (
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// WalkQuery adds the SELECT, filter, GROUP BY, HAVING, ORDER BY, LIMIT and
+// OFFSET clauses of a TSL query to a squirrel select builder.
+//
+// When the query has a SELECT clause, its items replace the columns of the
+// builder, otherwise the builder columns are kept. GROUP BY keys can not use
+// literal values, since squirrel takes them as plain SQL text.
+//
+//	query, _ := tsl.ParseQuery("status = 'open' ORDER BY priority DESC LIMIT 20")
+//	builder, _ := sql.WalkQuery(query, sq.Select("*").From("tickets"))
+//	sql, args, _ := builder.ToSql()
+//
+// Identifiers are used as column names without quotes or a check, like in Walk.
+//
+// Deprecated: use WalkQueryWithOptions with WithAllowedColumns or WithColumns.
+func WalkQuery(q *tsl.Query, builder sq.SelectBuilder) (sq.SelectBuilder, error) {
+	return WalkQueryWithOptions(q, builder, plainIdentifiers())
+}
+
+// WalkQueryWithOptions adds the clauses of a TSL query to a squirrel select
+// builder, using the syntax of an SQL dialect and the column mapping (see
+// WalkWithOptions). ORDER BY keys may use the names of the SELECT items, and
+// the JOIN clauses of to-one relations are added to the builder (see
+// WithRelations).
+func WalkQueryWithOptions(q *tsl.Query, builder sq.SelectBuilder, opts ...Option) (sq.SelectBuilder, error) {
+	w := newWalker(opts)
+
+	// Result column names, that are not mapped to table columns
+	aliases := map[string]bool{}
+	for position, item := range q.Select {
+		if !item.Star && (item.Alias != "" || item.Expr.Type() != tsl.KindIdentifier) {
+			aliases[item.Name(position)] = true
+		}
+	}
+
+	if len(q.Select) > 0 {
+		builder = builder.RemoveColumns()
+		for position, item := range q.Select {
+			column, err := w.selectColumn(item, position)
+			if err != nil {
+				return builder, err
+			}
+			builder = builder.Column(column)
+		}
+	}
+
+	if q.Filter != nil {
+		filter, err := w.walk(q.Filter)
+		if err != nil {
+			return builder, err
+		}
+		builder = builder.Where(filter)
+	}
+
+	for _, key := range q.GroupBy {
+		expr, err := w.walk(key)
+		if err != nil {
+			return builder, err
+		}
+		sql, args, err := expr.ToSql()
+		if err != nil {
+			return builder, err
+		}
+		if len(args) > 0 {
+			return builder, tsl.TypeMismatchError{Expected: "GROUP BY key without literal values", Got: sql}
+		}
+		builder = builder.GroupBy(sql)
+	}
+
+	if q.Having != nil {
+		having, err := w.walk(q.Having)
+		if err != nil {
+			return builder, err
+		}
+		builder = builder.Having(having)
+	}
+
+	for _, key := range q.OrderBy {
+		sql, args, err := w.orderKey(key, aliases)
+		if err != nil {
+			return builder, err
+		}
+		if key.Desc {
+			sql += " DESC"
+		}
+		builder = builder.OrderByClause(sql, args...)
+	}
+
+	for _, join := range w.joins.clauses {
+		builder = builder.JoinClause(join)
+	}
+
+	if q.Limit != nil {
+		builder = builder.Limit(uint64(*q.Limit))
+	}
+	if q.Offset > 0 {
+		builder = builder.Offset(uint64(q.Offset))
+	}
+
+	return builder, nil
+}
+
+// selectColumn converts a SELECT item into a squirrel column, computed
+// columns are named like the in-memory projection output
+func (w *walker) selectColumn(item tsl.SelectItem, position int) (sq.Sqlizer, error) {
+	if item.Star {
+		return expr("*"), nil
+	}
+
+	expr, err := w.walk(item.Expr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Plain identifiers keep their column name, unless mapped to another
+	// column, a related table column or a JSON value
+	name := item.Name(position)
+	if item.Expr.Type() == tsl.KindIdentifier && item.Expr.Value() == name {
+		column, mapped := w.opts.columns[name]
+		_, _, related, _ := w.relationColumn(name)
+		_, json, _ := w.jsonPath(name)
+		if mapped && column == name || !mapped && !related && !json {
+			return expr, nil
+		}
+	}
+
+	alias, err := w.alias(name)
+	if err != nil {
+		return nil, err
+	}
+	return sq.Alias(expr, alias), nil
+}
+
+// orderKey returns the SQL of an ORDER BY key, keys naming a result column
+// use the column alias
+func (w *walker) orderKey(key tsl.SortKey, aliases map[string]bool) (string, []interface{}, error) {
+	if name, ok := key.Expr.Value().(string); ok && key.Expr.Type() == tsl.KindIdentifier && aliases[name] {
+		alias, err := w.alias(name)
+		return alias, nil, err
+	}
+
+	expr, err := w.walk(key.Expr)
+	if err != nil {
+		return "", nil, err
+	}
+	return expr.ToSql()
+}
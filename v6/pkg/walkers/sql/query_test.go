@@ -0,0 +1,127 @@
+// Copyright 2018 Yaacov Zamir <kobi.zamir@gmail.com>
+// and other contributors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("WalkQuery", func() {
+	DescribeTable("Generates the expected SQL and arguments",
+		func(input string, expectedSQL string, expectedArgs ...interface{}) {
+			query, err := tsl.ParseQuery(input)
+			Expect(err).ToNot(HaveOccurred())
+
+			builder, err := WalkQuery(query, sq.Select("id, status").From("tickets"))
+			Expect(err).ToNot(HaveOccurred())
+
+			actualSQL, actualArgs, err := builder.ToSql()
+			Expect(err).ToNot(HaveOccurred())
+
+			if actualArgs == nil {
+				actualArgs = []interface{}{}
+			}
+
+			Expect(actualSQL).To(Equal(expectedSQL))
+			Expect(actualArgs).To(Equal(expectedArgs))
+		},
+
+		Entry(
+			"Filter only",
+			"status = 'open'",
+			"SELECT id, status FROM tickets WHERE status = ?",
+			"open",
+		),
+
+		Entry(
+			"Order by, limit",
+			"status = 'open' ORDER BY priority DESC, created LIMIT 20",
+			"SELECT id, status FROM tickets WHERE status = ? ORDER BY priority DESC, created LIMIT 20",
+			"open",
+		),
+
+		Entry(
+			"Order by expression",
+			"ORDER BY priority * 2 ASC, id",
+			"SELECT id, status FROM tickets ORDER BY (priority * ?), id",
+			int64(2),
+		),
+
+		Entry(
+			"Filter and order by arguments keep their order",
+			"status = 'open' ORDER BY owner = 'joe' DESC",
+			"SELECT id, status FROM tickets WHERE status = ? ORDER BY owner = ? DESC",
+			"open", "joe",
+		),
+
+		Entry(
+			"Limit and offset",
+			"LIMIT 10 OFFSET 30",
+			"SELECT id, status FROM tickets LIMIT 10 OFFSET 30",
+		),
+
+		Entry(
+			"Select columns",
+			"SELECT title, pages * 2 AS double_pages WHERE author = 'Joe'",
+			"SELECT title, ((pages * ?)) AS double_pages FROM tickets WHERE author = ?",
+			int64(2), "Joe",
+		),
+
+		Entry(
+			"Select unnamed and renamed columns",
+			"SELECT *, price + tax, name AS title ORDER BY title",
+			"SELECT *, ((price + tax)) AS column2, (name) AS title FROM tickets ORDER BY title",
+		),
+
+		Entry(
+			"Select without filter",
+			"SELECT title LIMIT 5",
+			"SELECT title FROM tickets LIMIT 5",
+		),
+
+		Entry(
+			"Limit zero",
+			"LIMIT 0",
+			"SELECT id, status FROM tickets LIMIT 0",
+		),
+
+		Entry(
+			"Group by with aggregates",
+			"SELECT owner, COUNT(*) AS tickets, SUM(hours) AS hours WHERE status = 'open' GROUP BY owner HAVING AVG(hours) > 2 ORDER BY tickets DESC",
+			"SELECT owner, (COUNT(*)) AS tickets, (SUM(hours)) AS hours FROM tickets WHERE status = ? GROUP BY owner HAVING AVG(hours) > ? ORDER BY tickets DESC",
+			"open", int64(2),
+		),
+
+		Entry(
+			"Aggregates without group by",
+			"SELECT MIN(created) AS first, MAX(created) AS last, COUNT(owner) AS owned",
+			"SELECT (MIN(created)) AS first, (MAX(created)) AS last, (COUNT(owner)) AS owned FROM tickets",
+		),
+	)
+
+	It("rejects group by keys with literal values", func() {
+		query, err := tsl.ParseQuery("SELECT COUNT(*) GROUP BY priority > 2")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = WalkQuery(query, sq.Select("*").From("tickets"))
+		Expect(err).To(BeAssignableToTypeOf(tsl.TypeMismatchError{}))
+	})
+})
@@ -0,0 +1,170 @@
+package sql
+
+import (
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// Cardinality is the number of related rows of a relation
+type Cardinality int
+
+const (
+	// ToOne relations have at most one related row, e.g. the author of a book
+	ToOne Cardinality = iota
+
+	// ToMany relations have any number of related rows, e.g. the reviews of
+	// a book
+	ToMany
+)
+
+// Relation describes a table related to the filtered table, e.g. the authors
+// of books:
+//
+//	sql.Relation{Table: "authors", Key: "id", ParentKey: "books.author_id", Columns: []string{"name", "country"}}
+type Relation struct {
+	// Table is the related table name
+	Table string
+
+	// Cardinality is ToOne or ToMany
+	Cardinality Cardinality
+
+	// Key is the related table column matching ParentKey
+	Key string
+
+	// ParentKey is the column of the filtered table, e.g. books.author_id
+	ParentKey string
+
+	// Columns are the related table columns identifiers may use
+	Columns []string
+}
+
+// WithRelations maps identifier prefixes to related tables, e.g. with
+// {"author": authors} the identifier author.country is the country column of
+// the related author row.
+//
+// To-one relations are joined using LEFT JOIN clauses, returned by
+// WalkWithJoins, and added to the builder by WalkQueryWithOptions. The
+// related table is aliased by the prefix.
+//
+// Comparisons using to-many relations are true when true for any related
+// row, using a correlated EXISTS subquery, e.g. reviews.rating > 4 is
+//
+//	EXISTS (SELECT 1 FROM "reviews" WHERE "reviews"."book_id" = "books"."id" AND "reviews"."rating" > ?)
+//
+// Each comparison may match a different row, ANY matches one row, e.g.
+// any (reviews.rating > 4 and reviews.verified = true). LEN counts the rows,
+// e.g. len reviews > 10, and SUM adds the values of a column.
+func WithRelations(relations map[string]Relation) Option {
+	return func(o *options) {
+		if o.relations == nil {
+			o.relations = map[string]Relation{}
+		}
+		for prefix, relation := range relations {
+			o.relations[prefix] = relation
+		}
+	}
+}
+
+// WalkWithJoins travel the TSL tree like WalkWithOptions, and returns the
+// JOIN clauses of the to-one relations the filter uses, in the order of
+// their first use.
+//
+//	filter, joins, _ := sql.WalkWithJoins(tree, opts...)
+//	builder := sql.PostgreSQL.StatementBuilder().Select("books.*").From("books").Where(filter)
+//	for _, join := range joins {
+//	  builder = builder.JoinClause(join)
+//	}
+func WalkWithJoins(n *tsl.TSLNode, opts ...Option) (sq.Sqlizer, []string, error) {
+	w := newWalker(opts)
+	filter, err := w.walk(n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return filter, w.joins.clauses, nil
+}
+
+// joins holds the JOIN clauses of a conversion
+type joins struct {
+	names   map[string]bool
+	clauses []string
+}
+
+// relationColumn returns the related table column of an identifier, ok is
+// false when the identifier does not start with a relation prefix
+func (w *walker) relationColumn(identifier string) (prefix string, column string, ok bool, err error) {
+	for p := range w.opts.relations {
+		if strings.HasPrefix(identifier, p+".") && len(p) > len(prefix) {
+			prefix = p
+		}
+	}
+	if prefix == "" {
+		return "", "", false, nil
+	}
+
+	name := identifier[len(prefix)+1:]
+	allowed := false
+	for _, c := range w.opts.relations[prefix].Columns {
+		allowed = allowed || c == name
+	}
+	if !allowed {
+		return prefix, "", true, tsl.UnmappedIdentifierError{Identifier: identifier}
+	}
+
+	alias, err := w.alias(prefix)
+	if err != nil {
+		return prefix, "", true, err
+	}
+	return prefix, alias + "." + w.opts.dialect.quote(name), true, nil
+}
+
+// relationSource returns the related rows of a relation
+func (w *walker) relationSource(prefix string) (arraySource, error) {
+	relation := w.opts.relations[prefix]
+	alias, err := w.alias(prefix)
+	if err != nil {
+		return arraySource{}, err
+	}
+
+	from := w.opts.dialect.QuoteIdentifier(relation.Table)
+	if relation.Table != prefix {
+		from += " AS " + alias
+	}
+	where := alias + "." + w.opts.dialect.quote(relation.Key) + " = " + w.opts.dialect.QuoteIdentifier(relation.ParentKey)
+	return arraySource{name: prefix, from: from, where: where, relation: true}, nil
+}
+
+// join adds the JOIN clause of a to-one relation
+func (w *walker) join(prefix string) error {
+	if w.joins.names[prefix] {
+		return nil
+	}
+	src, err := w.relationSource(prefix)
+	if err != nil {
+		return err
+	}
+	w.joins.names[prefix] = true
+	w.joins.clauses = append(w.joins.clauses, "LEFT JOIN "+src.from+" ON "+src.where)
+	return nil
+}
+
+// relationIdentifier returns the column of an identifier of a related table,
+// to-many relations are used by comparisons, elements is set when the
+// comparison checks the related rows
+func (w *walker) relationIdentifier(identifier string, elements bool) (fragment, bool, error) {
+	prefix, column, ok, err := w.relationColumn(identifier)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+
+	if w.opts.relations[prefix].Cardinality == ToOne {
+		if err := w.join(prefix); err != nil {
+			return nil, true, err
+		}
+	} else if !elements && (w.element == nil || w.element.name != prefix) {
+		return nil, true, tsl.InvalidIdentifierError{Identifier: identifier}
+	}
+	return expr(column), true, nil
+}
@@ -0,0 +1,97 @@
+package sql
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("Relations", func() {
+	relations := WithRelations(map[string]Relation{
+		"author": {
+			Table:     "authors",
+			Key:       "id",
+			ParentKey: "books.author_id",
+			Columns:   []string{"name", "country"},
+		},
+		"reviews": {
+			Table:       "reviews",
+			Cardinality: ToMany,
+			Key:         "book_id",
+			ParentKey:   "books.id",
+			Columns:     []string{"rating", "verified"},
+		},
+	})
+
+	DescribeTable("converts related columns",
+		func(input string, expectedSQL string, expectedJoins []string, expectedArgs ...interface{}) {
+			filter, joins, err := WalkWithJoins(mustParse(input), WithDialect(PostgreSQL), WithAllowedColumns("title", "price"), relations)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(joins).To(Equal(expectedJoins))
+
+			builder := PostgreSQL.StatementBuilder().Select("books.*").From("books").Where(filter)
+			for _, join := range joins {
+				builder = builder.JoinClause(join)
+			}
+			sql, args, err := builder.ToSql()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sql).To(Equal(expectedSQL))
+			Expect(args).To(Equal(expectedArgs))
+		},
+		Entry("to-one", "author.country = 'IT'",
+			`SELECT books.* FROM books LEFT JOIN "authors" AS "author" ON "author"."id" = "books"."author_id" WHERE "author"."country" = $1`,
+			[]string{`LEFT JOIN "authors" AS "author" ON "author"."id" = "books"."author_id"`}, "IT"),
+		Entry("joins once", "author.country = 'IT' or author.name = 'Eco'",
+			`SELECT books.* FROM books LEFT JOIN "authors" AS "author" ON "author"."id" = "books"."author_id" WHERE ("author"."country" = $1 OR "author"."name" = $2)`,
+			[]string{`LEFT JOIN "authors" AS "author" ON "author"."id" = "books"."author_id"`}, "IT", "Eco"),
+		Entry("to-many", "reviews.rating > 4",
+			`SELECT books.* FROM books WHERE EXISTS (SELECT 1 FROM "reviews" WHERE "reviews"."book_id" = "books"."id" AND "reviews"."rating" > $1)`,
+			nil, int64(4)),
+		Entry("to-many in", "reviews.rating in [1, 2]",
+			`SELECT books.* FROM books WHERE EXISTS (SELECT 1 FROM "reviews" WHERE "reviews"."book_id" = "books"."id" AND "reviews"."rating" IN ($1,$2))`,
+			nil, int64(1), int64(2)),
+		Entry("any row", "any (reviews.rating > 4 and reviews.verified = true)",
+			`SELECT books.* FROM books WHERE EXISTS (SELECT 1 FROM "reviews" WHERE "reviews"."book_id" = "books"."id" AND ("reviews"."rating" > $1 AND "reviews"."verified" = $2))`,
+			nil, int64(4), true),
+		Entry("row count", "len reviews >= 10",
+			`SELECT books.* FROM books WHERE (SELECT COUNT(*) FROM "reviews" WHERE "reviews"."book_id" = "books"."id") >= $1`,
+			nil, int64(10)),
+		Entry("sum", "sum reviews.rating > 100",
+			`SELECT books.* FROM books WHERE (SELECT COALESCE(SUM("reviews"."rating"), 0) FROM "reviews" WHERE "reviews"."book_id" = "books"."id") > $1`,
+			nil, int64(100)),
+		Entry("mixed", "price < 20 and author.country = 'IT' and not (reviews.rating < 2)",
+			`SELECT books.* FROM books LEFT JOIN "authors" AS "author" ON "author"."id" = "books"."author_id" WHERE (("price" < $1 AND "author"."country" = $2) AND NOT (EXISTS (SELECT 1 FROM "reviews" WHERE "reviews"."book_id" = "books"."id" AND "reviews"."rating" < $3)))`,
+			[]string{`LEFT JOIN "authors" AS "author" ON "author"."id" = "books"."author_id"`}, int64(20), "IT", int64(2)),
+	)
+
+	DescribeTable("rejects",
+		func(input string, expected error) {
+			_, _, err := WalkWithJoins(mustParse(input), WithDialect(PostgreSQL), relations)
+			Expect(err).To(Equal(expected))
+		},
+		Entry("columns that are not listed", "author.password = 'x'", tsl.UnmappedIdentifierError{Identifier: "author.password"}),
+		Entry("to-many columns outside comparisons", "reviews.verified", tsl.InvalidIdentifierError{Identifier: "reviews.verified"}),
+	)
+
+	It("requires WalkWithJoins for to-one relations", func() {
+		_, err := WalkWithOptions(mustParse("author.country = 'IT'"), WithDialect(PostgreSQL), relations)
+		Expect(err).To(Equal(tsl.JoinRequiredError{Join: `LEFT JOIN "authors" AS "author" ON "author"."id" = "books"."author_id"`}))
+
+		_, err = WalkWithOptions(mustParse("reviews.rating > 4"), WithDialect(PostgreSQL), relations)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("adds the joins of queries", func() {
+		query, err := tsl.ParseQuery("SELECT title, author.name WHERE author.country = 'IT' ORDER BY author.name")
+		Expect(err).ToNot(HaveOccurred())
+
+		builder, err := WalkQueryWithOptions(query, PostgreSQL.StatementBuilder().Select("*").From("books"),
+			WithDialect(PostgreSQL), WithAllowedColumns("title"), relations)
+		Expect(err).ToNot(HaveOccurred())
+		sql, args, err := builder.ToSql()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sql).To(Equal(`SELECT "title", ("author"."name") AS "author.name" FROM books LEFT JOIN "authors" AS "author" ON "author"."id" = "books"."author_id" WHERE "author"."country" = $1 ORDER BY "author"."name"`))
+		Expect(args).To(Equal([]interface{}{"IT"}))
+	})
+})
@@ -0,0 +1,209 @@
+package sql
+
+import (
+	"time"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+// legacyLayout is the timestamp layout of TimeLegacy, the clock time without
+// an offset
+const legacyLayout = "2006-01-02 15:04:05"
+
+// TimeMode sets how date and timestamp literals are passed to the database
+type TimeMode int
+
+const (
+	// TimeLegacy passes the clock time of timestamps as text without the
+	// offset, e.g. 2024-01-01T10:00:00+05:00 is "2024-01-01 10:00:00", and
+	// dates as midnight, the database compares them in its own time zone
+	TimeLegacy TimeMode = iota
+
+	// TimeValue passes time.Time arguments keeping their offset, dates are
+	// midnight UTC, like in the semantics walker
+	TimeValue
+
+	// TimeUTC passes time.Time arguments converted to UTC, for columns
+	// holding UTC times without a time zone
+	TimeUTC
+
+	// TimeText passes text in the timestamp layout of the dialect, including
+	// the offset, e.g. "2024-01-01 10:00:00+05:00"
+	TimeText
+)
+
+// WithTimeMode sets how date and timestamp literals are passed, the default
+// is TimeLegacy.
+func WithTimeMode(mode TimeMode) Option {
+	return func(o *options) {
+		o.timeMode = mode
+	}
+}
+
+// WithDateRanges compares columns with date literals by whole UTC days, e.g.
+// created = 2024-01-01 is true for any time of that day:
+//
+//	created = 2024-01-01         created >= '2024-01-01' AND created < '2024-01-02'
+//	created > 2024-01-01         created >= '2024-01-02'
+//	created <= 2024-01-01        created < '2024-01-02'
+//	created between 2024-01-01 and 2024-01-31
+//	                             created >= '2024-01-01' AND created < '2024-02-01'
+//
+// Timestamps compare like the UTC date of the column would compare in the
+// semantics walker, where dates are midnight UTC.
+func WithDateRanges() Option {
+	return func(o *options) {
+		o.dateRanges = true
+	}
+}
+
+// timeArg returns the query argument of a time
+func (w *walker) timeArg(t time.Time) interface{} {
+	switch w.opts.timeMode {
+	case TimeValue:
+		return t
+	case TimeUTC:
+		return t.UTC()
+	case TimeText:
+		layout := w.opts.dialect.TimestampLayout
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		return t.Format(layout)
+	default:
+		return t.Format(legacyLayout)
+	}
+}
+
+// dateLiteral returns the midnight UTC of a date literal
+func dateLiteral(n *tsl.TSLNode) (time.Time, bool) {
+	if n == nil || n.Type() != tsl.KindDateLiteral {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006-01-02", n.Value().(string))
+	return t, err == nil
+}
+
+// flippedComparisons are the comparisons with swapped operands
+var flippedComparisons = map[tsl.Operator]tsl.Operator{
+	tsl.OpEQ: tsl.OpEQ, tsl.OpNE: tsl.OpNE,
+	tsl.OpLT: tsl.OpGT, tsl.OpLE: tsl.OpGE,
+	tsl.OpGT: tsl.OpLT, tsl.OpGE: tsl.OpLE,
+}
+
+// dateRangeStep returns the whole day comparison of a binary expression with
+// a date literal, ok is false when the expression does not compare a date
+func (w *walker) dateRangeStep(op tsl.TSLExpressionOp) (s fragment, ok bool, err error) {
+	switch op.Operator {
+	case tsl.OpIn:
+		return w.dateInStep(op)
+	case tsl.OpBetween:
+		return w.dateBetweenStep(op)
+	}
+
+	operator, ok := flippedComparisons[op.Operator]
+	if !ok {
+		return nil, false, nil
+	}
+
+	value, day := op.Left, op.Right
+	start, isDate := dateLiteral(day)
+	if !isDate {
+		value, day = op.Right, op.Left
+		if start, isDate = dateLiteral(day); !isDate {
+			return nil, false, nil
+		}
+	} else {
+		operator = op.Operator
+	}
+	if _, both := dateLiteral(value); both {
+		return nil, false, nil
+	}
+
+	l, err := w.operand(value, day, operator)
+	if err != nil {
+		return nil, true, err
+	}
+	first, next := w.timeArg(start), w.timeArg(start.AddDate(0, 0, 1))
+
+	switch operator {
+	case tsl.OpEQ:
+		return expr("(? >= ? AND ? < ?)", l, first, l, next), true, nil
+	case tsl.OpNE:
+		return expr("(? < ? OR ? >= ?)", l, first, l, next), true, nil
+	case tsl.OpLT:
+		return expr("? < ?", l, first), true, nil
+	case tsl.OpLE:
+		return expr("? < ?", l, next), true, nil
+	case tsl.OpGT:
+		return expr("? >= ?", l, next), true, nil
+	default:
+		return expr("? >= ?", l, first), true, nil
+	}
+}
+
+// dateInStep matches any of the days of the date literals in an IN list,
+// other values are compared using IN
+func (w *walker) dateInStep(op tsl.TSLExpressionOp) (fragment, bool, error) {
+	if op.Right == nil || op.Right.Type() != tsl.KindArrayLiteral {
+		return nil, false, nil
+	}
+
+	var days []time.Time
+	var others []*tsl.TSLNode
+	for _, node := range op.Right.Value().(tsl.TSLArrayLiteral).Values {
+		if day, ok := dateLiteral(node); ok {
+			days = append(days, day)
+		} else {
+			others = append(others, node)
+		}
+	}
+	if len(days) == 0 {
+		return nil, false, nil
+	}
+
+	l, err := w.operand(op.Left, op.Right, op.Operator)
+	if err != nil {
+		return nil, true, err
+	}
+
+	matches := or{}
+	for _, day := range days {
+		matches = append(matches, expr("(? >= ? AND ? < ?)", l, w.timeArg(day), l, w.timeArg(day.AddDate(0, 0, 1))))
+	}
+	if len(others) > 0 {
+		values := make([]interface{}, len(others))
+		for i, node := range others {
+			if values[i], err = w.walk(node); err != nil {
+				return nil, true, err
+			}
+		}
+		matches = append(matches, expr("? IN ("+placeholders(len(values))+")", append([]interface{}{l}, values...)...))
+	}
+	return matches, true, nil
+}
+
+// dateBetweenStep includes the whole day of a date literal upper bound
+func (w *walker) dateBetweenStep(op tsl.TSLExpressionOp) (fragment, bool, error) {
+	if op.Right == nil || op.Right.Type() != tsl.KindArrayLiteral {
+		return nil, false, nil
+	}
+	bounds := op.Right.Value().(tsl.TSLArrayLiteral).Values
+	if len(bounds) != 2 {
+		return nil, false, nil
+	}
+	last, ok := dateLiteral(bounds[1])
+	if !ok {
+		return nil, false, nil
+	}
+
+	l, err := w.operand(op.Left, op.Right, op.Operator)
+	if err != nil {
+		return nil, true, err
+	}
+	lower, err := w.walk(bounds[0])
+	if err != nil {
+		return nil, true, err
+	}
+	return expr("(? >= ? AND ? < ?)", l, lower, l, w.timeArg(last.AddDate(0, 0, 1))), true, nil
+}
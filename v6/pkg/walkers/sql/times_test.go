@@ -0,0 +1,92 @@
+package sql
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/yaacov/tree-search-language/v6/pkg/tsl"
+)
+
+var _ = Describe("Times", func() {
+	plus5 := time.FixedZone("", 5*60*60)
+
+	toSQL := func(d Dialect, input string, opts ...Option) (string, []interface{}) {
+		tree, err := tsl.ParseTSL(input)
+		Expect(err).ToNot(HaveOccurred())
+
+		opts = append([]Option{WithDialect(d), WithAllowedColumns("created", "name")}, opts...)
+		filter, err := WalkWithOptions(tree, opts...)
+		Expect(err).ToNot(HaveOccurred())
+		sql, args, err := d.StatementBuilder().Select("*").From("t").Where(filter).ToSql()
+		Expect(err).ToNot(HaveOccurred())
+		return sql, args
+	}
+
+	DescribeTable("passes timestamps",
+		func(d Dialect, mode TimeMode, input string, expected interface{}) {
+			_, args := toSQL(d, input, WithTimeMode(mode))
+			Expect(args).To(HaveLen(1))
+			if t, ok := expected.(time.Time); ok {
+				Expect(args[0]).To(BeAssignableToTypeOf(t))
+				Expect(args[0].(time.Time).Equal(t)).To(BeTrue())
+				Expect(args[0].(time.Time).Location().String()).To(Equal(t.Location().String()))
+				return
+			}
+			Expect(args[0]).To(Equal(expected))
+		},
+		Entry("legacy drops the offset", PostgreSQL, TimeLegacy,
+			"created > 2024-01-01T10:00:00+05:00", "2024-01-01 10:00:00"),
+		Entry("value keeps the offset", PostgreSQL, TimeValue,
+			"created > 2024-01-01T10:00:00+05:00", time.Date(2024, 1, 1, 10, 0, 0, 0, plus5)),
+		Entry("utc", PostgreSQL, TimeUTC,
+			"created > 2024-01-01T10:00:00+05:00", time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)),
+		Entry("postgresql text", PostgreSQL, TimeText,
+			"created > 2024-01-01T10:00:00.5+05:00", "2024-01-01 10:00:00.5+05:00"),
+		Entry("mysql text", MySQL, TimeText,
+			"created > 2024-01-01T10:00:00Z", "2024-01-01 10:00:00+00:00"),
+		Entry("sql server text", SQLServer, TimeText,
+			"created > 2024-01-01T10:00:00-03:00", "2024-01-01 10:00:00 -03:00"),
+		Entry("legacy dates", PostgreSQL, TimeLegacy,
+			"created > 2024-01-01", "2024-01-01 00:00:00"),
+		Entry("dates are midnight utc", PostgreSQL, TimeValue,
+			"created > 2024-01-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("date text", SQLite, TimeText,
+			"created > 2024-01-01", "2024-01-01 00:00:00+00:00"),
+	)
+
+	DescribeTable("compares whole days",
+		func(input string, expectedSQL string, expectedArgs ...interface{}) {
+			sql, args := toSQL(PostgreSQL, input, WithDateRanges(), WithTimeMode(TimeText))
+			Expect(sql).To(Equal("SELECT * FROM t WHERE " + expectedSQL))
+			Expect(args).To(Equal(expectedArgs))
+		},
+		Entry("equal", "created = 2024-01-31",
+			`("created" >= $1 AND "created" < $2)`, "2024-01-31 00:00:00+00:00", "2024-02-01 00:00:00+00:00"),
+		Entry("not equal", "created != 2024-01-31",
+			`("created" < $1 OR "created" >= $2)`, "2024-01-31 00:00:00+00:00", "2024-02-01 00:00:00+00:00"),
+		Entry("less", "created < 2024-01-31",
+			`"created" < $1`, "2024-01-31 00:00:00+00:00"),
+		Entry("less or equal", "created <= 2024-01-31",
+			`"created" < $1`, "2024-02-01 00:00:00+00:00"),
+		Entry("greater", "created > 2024-01-31",
+			`"created" >= $1`, "2024-02-01 00:00:00+00:00"),
+		Entry("greater or equal", "created >= 2024-01-31",
+			`"created" >= $1`, "2024-01-31 00:00:00+00:00"),
+		Entry("date on the left", "2024-01-31 < created",
+			`"created" >= $1`, "2024-02-01 00:00:00+00:00"),
+		Entry("between", "created between 2024-01-01 and 2024-01-31",
+			`("created" >= $1 AND "created" < $2)`, "2024-01-01 00:00:00+00:00", "2024-02-01 00:00:00+00:00"),
+		Entry("in", "created in [2024-01-01, 2024-03-01]",
+			`(("created" >= $1 AND "created" < $2) OR ("created" >= $3 AND "created" < $4))`,
+			"2024-01-01 00:00:00+00:00", "2024-01-02 00:00:00+00:00", "2024-03-01 00:00:00+00:00", "2024-03-02 00:00:00+00:00"),
+		Entry("not", "not (created = 2024-01-31)",
+			`NOT (("created" >= $1 AND "created" < $2))`, "2024-01-31 00:00:00+00:00", "2024-02-01 00:00:00+00:00"),
+		Entry("timestamps are exact", "created = 2024-01-31T10:00:00Z",
+			`"created" = $1`, "2024-01-31 10:00:00+00:00"),
+		Entry("other operators", "name like '2024%'",
+			`"name" LIKE $1`, "2024%"),
+	)
+
+})
@@ -13,11 +13,15 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package sql helps to create SQL squirrel filters using the TSL package.
+// Package sql helps to create SQL filters using the TSL package, as SQL text
+// and arguments (see ToSQL and NewFilter), or as squirrel filters (see Walk).
 package sql
 
 import (
+	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
 	sq "github.com/Masterminds/squirrel"
 
@@ -35,39 +39,111 @@ import (
 //	  ToSql()
 //
 // Squirrel: https://github.com/Masterminds/squirrel
-func Walk(n *tsl.TSLNode) (s sq.Sqlizer, err error) {
+//
+// Identifiers are used as column names when they are plain SQL identifiers,
+// e.g. name or users.name. Walk does not quote or check the identifiers
+// against a list of columns, a filter can use any column of the tables,
+// including columns its author should not see.
+//
+// Deprecated: use WalkWithOptions with WithAllowedColumns or WithColumns.
+func Walk(n *tsl.TSLNode) (sq.Sqlizer, error) {
+	return WalkWithOptions(n, plainIdentifiers())
+}
+
+// WalkWithOptions travel the TSL tree to create squirrel SQL select operators
+// using the syntax of an SQL dialect, Walk uses a mix of the PostgreSQL and
+// MySQL syntax.
+//
+// Identifiers must be mapped to columns using WithColumns or
+// WithAllowedColumns, and are quoted by the dialect, other identifiers return
+// a tsl.UnmappedIdentifierError.
+//
+// Operators the dialect can not express, e.g. WITHIN outside PostgreSQL,
+// return a tsl.UnsupportedOperatorError. Filters use "?" placeholders, use
+// the dialect statement builder to get the placeholders of the database.
+//
+//	filter, _ := sql.WalkWithOptions(tree,
+//	  sql.WithDialect(sql.PostgreSQL),
+//	  sql.WithAllowedColumns("name", "city"),
+//	)
+//	sql, args, _ := sql.PostgreSQL.StatementBuilder().
+//	  Select("name, city, state").
+//	  From("users").
+//	  Where(filter).
+//	  ToSql()
+func WalkWithOptions(n *tsl.TSLNode, opts ...Option) (sq.Sqlizer, error) {
+	filter, joins, err := WalkWithJoins(n, opts...)
+	if err == nil && len(joins) > 0 {
+		return nil, tsl.JoinRequiredError{Join: joins[0]}
+	}
+	return filter, err
+}
+
+// walker holds the options of a conversion
+type walker struct {
+	opts options
+
+	// element is the array whose identifier names one element, in the
+	// operands of array operators
+	element *arraySource
+
+	// joins holds the JOIN clauses of the to-one relations, shared by the
+	// walkers of array operands
+	joins *joins
+}
+
+// newWalker returns a walker using the conversion options
+func newWalker(opts []Option) *walker {
+	w := &walker{opts: options{dialect: defaultDialect}, joins: &joins{names: map[string]bool{}}}
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+	return w
+}
+
+// walk converts a node to a squirrel SQL operator
+func (w *walker) walk(n *tsl.TSLNode) (s fragment, err error) {
 	switch n.Type() {
 	case tsl.KindIdentifier:
-		s = sq.Expr(n.Value().(string))
+		return w.identifier(n.Value().(string), "", false)
 	case tsl.KindNumericLiteral:
-		s = sq.Expr("?", n.Value().(float64))
+		// Numbers are passed exactly, as int64 or as tsl.Decimal (a driver.Valuer)
+		s = expr("?", n.Value())
+		if _, ok := n.Value().(tsl.Decimal); ok && w.opts.dialect.Decimal != "" {
+			s = expr(fmt.Sprintf(w.opts.dialect.Decimal, "?"), n.Value())
+		}
 	case tsl.KindDateLiteral:
-		// Parse date string and format for SQL
-		dateStr := n.Value().(string)
-		if t, err := time.Parse("2006-01-02", dateStr); err == nil {
-			s = sq.Expr("?", t.Format("2006-01-02 15:04:05"))
+		// Dates are midnight UTC, passed like timestamps
+		if t, ok := dateLiteral(n); ok {
+			s = expr("?", w.timeArg(t))
 		} else {
-			s = sq.Expr("?", dateStr)
+			s = expr("?", n.Value().(string))
 		}
 	case tsl.KindTimestampLiteral:
-		// Format time value using SQL timestamp format
-		t := n.Value().(time.Time)
-		s = sq.Expr("?", t.Format("2006-01-02 15:04:05"))
+		// Timestamps are passed using the time mode (see WithTimeMode)
+		s = expr("?", w.timeArg(n.Value().(time.Time)))
 	case tsl.KindStringLiteral:
-		s = sq.Expr("?", n.Value().(string))
+		s = expr("?", n.Value().(string))
+	case tsl.KindIPLiteral, tsl.KindCIDRLiteral, tsl.KindVersionLiteral:
+		// IP addresses, CIDR prefixes and versions are passed in their text form,
+		// versions are only compared for equality (see orderedVersions)
+		s = expr("?", fmt.Sprintf("%v", n.Value()))
 	case tsl.KindBooleanLiteral:
-		if n.Value().(bool) {
-			s = sq.Expr("?", 1)
-		} else {
-			s = sq.Expr("?", 0)
+		switch {
+		case w.opts.dialect.Booleans:
+			s = expr("?", n.Value().(bool))
+		case n.Value().(bool):
+			s = expr("?", 1)
+		default:
+			s = expr("?", 0)
 		}
 	case tsl.KindBinaryExpr:
-		return binaryStep(n)
+		return w.binaryStep(n)
 	case tsl.KindUnaryExpr:
-		return unaryStep(n)
+		return w.unaryStep(n)
 	case tsl.KindNullLiteral:
 		// NULL literal is handled as a special case of IS NULL operator
-		s = sq.Expr("")
+		s = expr("")
 	default:
 		err = tsl.UnexpectedLiteralError{Literal: n.Type()}
 	}
@@ -75,18 +151,68 @@ func Walk(n *tsl.TSLNode) (s sq.Sqlizer, err error) {
 	return
 }
 
+// plainIdentifiers returns the option of Walk, that passes plain SQL
+// identifiers without a mapping, and without quotes
+func plainIdentifiers() Option {
+	return func(o *options) {
+		o.plainIdentifiers = true
+		o.dialect.IdentifierQuote = [2]string{}
+	}
+}
+
+// column returns the quoted column name of an identifier
+func (w *walker) column(identifier string) (string, error) {
+	if column, ok := w.opts.columns[identifier]; ok {
+		return w.opts.dialect.QuoteIdentifier(column), nil
+	}
+	if !w.opts.plainIdentifiers {
+		return "", tsl.UnmappedIdentifierError{Identifier: identifier}
+	}
+	if !isPlainIdentifier(identifier) {
+		return "", tsl.InvalidIdentifierError{Identifier: identifier}
+	}
+	return w.opts.dialect.QuoteIdentifier(identifier), nil
+}
+
+// alias returns the quoted name of a result column
+func (w *walker) alias(name string) (string, error) {
+	if w.opts.dialect.IdentifierQuote[0] != "" {
+		return w.opts.dialect.quote(name), nil
+	}
+	if strings.Contains(name, ".") || !isPlainIdentifier(name) {
+		return "", tsl.InvalidIdentifierError{Identifier: name}
+	}
+	return name, nil
+}
+
+// isPlainIdentifier reports if a name is made of dot separated SQL identifiers
+// of letters, digits and underscores, that do not start with a digit
+func isPlainIdentifier(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if part == "" {
+			return false
+		}
+		for i, c := range part {
+			if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // Helper function to walk array nodes and return values
-func walkArrayValues(n *tsl.TSLNode) ([]sq.Sqlizer, error) {
+func (w *walker) walkArrayValues(n *tsl.TSLNode) ([]fragment, error) {
 	if n.Type() != tsl.KindArrayLiteral {
 		return nil, tsl.UnexpectedTypeError{Type: n.Type()}
 	}
 
 	array := n.Value().(tsl.TSLArrayLiteral)
-	values := make([]sq.Sqlizer, len(array.Values))
+	values := make([]fragment, len(array.Values))
 	var err error
 
 	for i, node := range array.Values {
-		values[i], err = Walk(node)
+		values[i], err = w.walk(node)
 		if err != nil {
 			return nil, err
 		}
@@ -95,11 +221,61 @@ func walkArrayValues(n *tsl.TSLNode) ([]sq.Sqlizer, error) {
 	return values, nil
 }
 
-func binaryStep(n *tsl.TSLNode) (s sq.Sqlizer, err error) {
-	var l sq.Sqlizer
+// binaryStep converts a binary expression, comparisons of JSON array
+// elements or of related rows are true when true for any of them
+func (w *walker) binaryStep(n *tsl.TSLNode) (fragment, error) {
 	op := n.Value().(tsl.TSLExpressionOp)
 
-	l, err = Walk(op.Left)
+	if orderedVersions(op) {
+		return nil, tsl.UnsupportedOperatorError{Operator: op.Operator, Dialect: w.opts.dialect.Name}
+	}
+
+	elements, err := w.elementsOf(op)
+	if err != nil {
+		return nil, err
+	}
+
+	var s fragment
+	ok := false
+	if w.opts.dateRanges {
+		s, ok, err = w.dateRangeStep(op)
+	}
+	if !ok {
+		s, err = w.binaryExpr(op)
+	}
+	if err != nil || elements == nil {
+		return s, err
+	}
+	return expr("EXISTS ("+elements.subquery("1", "?")+")", s), nil
+}
+
+// orderedVersions reports whether an ordering comparison or BETWEEN uses a
+// version literal, databases compare versions as text, so v1.10.0 would sort
+// before v1.9.0
+func orderedVersions(op tsl.TSLExpressionOp) bool {
+	switch op.Operator {
+	case tsl.OpLT, tsl.OpLE, tsl.OpGT, tsl.OpGE, tsl.OpBetween:
+	default:
+		return false
+	}
+
+	nodes := []*tsl.TSLNode{op.Left, op.Right}
+	if op.Right.Type() == tsl.KindArrayLiteral {
+		nodes = append(nodes, op.Right.Value().(tsl.TSLArrayLiteral).Values...)
+	}
+	for _, n := range nodes {
+		if n.Type() == tsl.KindVersionLiteral {
+			return true
+		}
+	}
+	return false
+}
+
+// binaryExpr converts the operator of a binary expression
+func (w *walker) binaryExpr(op tsl.TSLExpressionOp) (s fragment, err error) {
+	var l fragment
+
+	l, err = w.operand(op.Left, op.Right, op.Operator)
 	if err != nil {
 		return
 	}
@@ -107,25 +283,25 @@ func binaryStep(n *tsl.TSLNode) (s sq.Sqlizer, err error) {
 	// Handle array operations specially
 	switch op.Operator {
 	case tsl.OpIn:
-		values, err := walkArrayValues(op.Right)
+		values, err := w.walkArrayValues(op.Right)
 		if err != nil {
 			return nil, err
 		}
-		return sq.Expr("? IN ("+placeholders(len(values))+")", append([]interface{}{l}, sqlizersToInterface(values)...)...), nil
+		return expr("? IN ("+placeholders(len(values))+")", append([]interface{}{l}, sqlizersToInterface(values)...)...), nil
 
 	case tsl.OpBetween:
-		values, err := walkArrayValues(op.Right)
+		values, err := w.walkArrayValues(op.Right)
 		if err != nil {
 			return nil, err
 		}
 		if len(values) != 2 {
 			return nil, tsl.BetweenOperatorError{Message: "BETWEEN requires exactly two values"}
 		}
-		return sq.Expr("? BETWEEN ? AND ?", l, values[0], values[1]), nil
+		return expr("? BETWEEN ? AND ?", l, values[0], values[1]), nil
 	}
 
 	// For non-array operations, handle normally
-	r, err := Walk(op.Right)
+	r, err := w.operand(op.Right, op.Left, op.Operator)
 	if err != nil {
 		return
 	}
@@ -133,61 +309,115 @@ func binaryStep(n *tsl.TSLNode) (s sq.Sqlizer, err error) {
 	switch op.Operator {
 	// Arithmetic operators
 	case tsl.OpPlus:
-		return sq.Expr("(? + ?)", l, r), nil
+		return expr("(? + ?)", l, r), nil
 	case tsl.OpMinus:
-		return sq.Expr("(? - ?)", l, r), nil
+		return expr("(? - ?)", l, r), nil
 	case tsl.OpStar:
-		return sq.Expr("(? * ?)", l, r), nil
+		return expr("(? * ?)", l, r), nil
 	case tsl.OpSlash:
-		return sq.Expr("(? / ?)", l, r), nil
+		return expr("(? / ?)", l, r), nil
 	case tsl.OpPercent:
-		return sq.Expr("(? % ?)", l, r), nil
+		if w.opts.dialect.ModFunction {
+			return expr("MOD(?, ?)", l, r), nil
+		}
+		return expr("(? % ?)", l, r), nil
 
 	// Comparison operators
 	case tsl.OpEQ:
-		return sq.Expr("? = ?", l, r), nil
+		return expr("? = ?", l, r), nil
 	case tsl.OpNE:
-		return sq.Expr("? != ?", l, r), nil
+		return expr("? != ?", l, r), nil
 	case tsl.OpLT:
-		return sq.Expr("? < ?", l, r), nil
+		return expr("? < ?", l, r), nil
 	case tsl.OpLE:
-		return sq.Expr("? <= ?", l, r), nil
+		return expr("? <= ?", l, r), nil
 	case tsl.OpGT:
-		return sq.Expr("? > ?", l, r), nil
+		return expr("? > ?", l, r), nil
 	case tsl.OpGE:
-		return sq.Expr("? >= ?", l, r), nil
+		return expr("? >= ?", l, r), nil
 	case tsl.OpREQ:
-		return sq.Expr("? REGEXP ?", l, r), nil // MySQL specific
+		return w.regexMatch(op.Operator, l, r)
 	case tsl.OpRNE:
-		return sq.Expr("NOT (? REGEXP ?)", l, r), nil // MySQL specific
+		match, err := w.regexMatch(op.Operator, l, r)
+		if err != nil {
+			return nil, err
+		}
+		return expr("NOT (?)", match), nil
 
 	// Logical operators
 	case tsl.OpAnd:
-		return sq.And{l, r}, nil
+		return and{l, r}, nil
 	case tsl.OpOr:
-		return sq.Or{l, r}, nil
+		return or{l, r}, nil
 
 	// String operators
 	case tsl.OpLike:
-		return sq.Expr("? LIKE ?", l, r), nil
+		return expr("? LIKE ?"+w.likeEscape(), l, r), nil
 	case tsl.OpILike:
-		return sq.Expr("? ILIKE ?", l, r), nil // PostgreSQL specific
+		if w.opts.dialect.ILike {
+			return expr("? ILIKE ?"+w.likeEscape(), l, r), nil
+		}
+		return expr("LOWER(?) LIKE LOWER(?)"+w.likeEscape(), l, r), nil
+
+	// Network operator
+	case tsl.OpWithin:
+		if !w.opts.dialect.Network {
+			return nil, tsl.UnsupportedOperatorError{Operator: op.Operator, Dialect: w.opts.dialect.Name}
+		}
+		return expr("? <<= ?", l, r), nil // inet containment
 
 	// Null operator
 	case tsl.OpIs:
-		return sq.Expr("? IS NULL", l), nil
+		return expr("? IS NULL", l), nil
 
 	default:
 		return nil, tsl.UnexpectedOperatorError{Operator: op.Operator}
 	}
 }
 
+// likeEscape returns the ESCAPE clause of LIKE patterns, a backslash escapes
+// the wildcards, like in the semantics walker
+func (w *walker) likeEscape() string {
+	if w.opts.dialect.LikeEscape {
+		return ` ESCAPE '\'`
+	}
+	return ""
+}
+
+// regexMatch returns the regular expression match of a value, using the
+// operator or the function of the dialect
+func (w *walker) regexMatch(operator tsl.Operator, l, r fragment) (fragment, error) {
+	d := w.opts.dialect
+	switch {
+	case d.RegexOperator != "":
+		return expr("? "+d.RegexOperator+" ?", l, r), nil
+	case d.RegexFunction != "" && d.Booleans:
+		return expr(d.RegexFunction+"(?, ?)", l, r), nil
+	case d.RegexFunction != "":
+		// Without booleans the function returns 1 on a match
+		return expr(d.RegexFunction+"(?, ?) = 1", l, r), nil
+	default:
+		return nil, tsl.UnsupportedOperatorError{Operator: operator, Dialect: d.Name}
+	}
+}
+
 // unaryStep handles minus and not operators first
-func unaryStep(n *tsl.TSLNode) (s sq.Sqlizer, err error) {
+func (w *walker) unaryStep(n *tsl.TSLNode) (s fragment, err error) {
 	op := n.Value().(tsl.TSLExpressionOp)
 
+	// COUNT(*) has no operand
+	if op.Operator == tsl.OpCount && op.Right == nil {
+		return expr("COUNT(*)"), nil
+	}
+
+	// Array operators use the elements of their operand
+	switch op.Operator {
+	case tsl.OpLen, tsl.OpAny, tsl.OpAll, tsl.OpSum:
+		return w.arrayStep(op)
+	}
+
 	// Get the child node's SQL representation
-	right, err := Walk(op.Right)
+	right, err := w.operand(op.Right, nil, op.Operator)
 	if err != nil {
 		return nil, err
 	}
@@ -195,9 +425,21 @@ func unaryStep(n *tsl.TSLNode) (s sq.Sqlizer, err error) {
 	// Handle minus and not operators first
 	switch op.Operator {
 	case tsl.OpUMinus:
-		return sq.Expr("-(?)", right), nil
+		return expr("-(?)", right), nil
 	case tsl.OpNot:
-		return sq.Expr("NOT (?)", right), nil
+		return expr("NOT (?)", right), nil
+
+	// Aggregate functions
+	case tsl.OpCount:
+		return expr("COUNT(?)", right), nil
+	case tsl.OpAggSum:
+		return expr("SUM(?)", right), nil
+	case tsl.OpAvg:
+		return expr("AVG(?)", right), nil
+	case tsl.OpMin:
+		return expr("MIN(?)", right), nil
+	case tsl.OpMax:
+		return expr("MAX(?)", right), nil
 	default:
 		return nil, tsl.UnexpectedLiteralError{Literal: op.Operator}
 	}
@@ -218,8 +460,8 @@ func placeholders(n int) string {
 	return string(ph)
 }
 
-// Helper to convert []sq.Sqlizer to []interface{}
-func sqlizersToInterface(sqlizers []sq.Sqlizer) []interface{} {
+// Helper to convert []fragment to []interface{}
+func sqlizersToInterface(sqlizers []fragment) []interface{} {
 	result := make([]interface{}, len(sqlizers))
 	for i, s := range sqlizers {
 		result[i] = s
@@ -16,6 +16,7 @@
 package sql
 
 import (
+	"math/big"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -31,6 +32,13 @@ func TestSQLWalker(t *testing.T) {
 	RunSpecs(t, "SQL walker")
 }
 
+// mustParse parses a TSL expression, failing the spec on errors
+func mustParse(input string) *tsl.TSLNode {
+	tree, err := tsl.ParseTSL(input)
+	Expect(err).ToNot(HaveOccurred())
+	return tree
+}
+
 var _ = Describe("Walk", func() {
 	DescribeTable("Generates the expected SQL and arguments",
 		func(input string, expectedSQL string, expectedArgs ...interface{}) {
@@ -84,21 +92,21 @@ var _ = Describe("Walk", func() {
 			"Addition",
 			"salary + bonus > 50000",
 			"SELECT name, city, state FROM users WHERE (salary + bonus) > ?",
-			50000.0,
+			int64(50000),
 		),
 
 		Entry(
 			"Multiplication",
 			"hours * rate = 1000",
 			"SELECT name, city, state FROM users WHERE (hours * rate) = ?",
-			1000.0,
+			int64(1000),
 		),
 
 		Entry(
 			"Complex arithmetic",
 			"(salary + bonus) * 0.3 > 20000",
 			"SELECT name, city, state FROM users WHERE ((salary + bonus) * ?) > ?",
-			0.3, 20000.0,
+			tsl.NewDecimal(big.NewRat(3, 10)), int64(20000),
 		),
 
 		Entry(
@@ -112,7 +120,7 @@ var _ = Describe("Walk", func() {
 			"BETWEEN operator",
 			"age BETWEEN 20 and 30",
 			"SELECT name, city, state FROM users WHERE age BETWEEN ? AND ?",
-			20.0, 30.0,
+			int64(20), int64(30),
 		),
 
 		Entry(
@@ -153,7 +161,63 @@ var _ = Describe("Walk", func() {
 			"Complex arithmetic",
 			"(salary * 12) + bonus BETWEEN 50000 and 100000",
 			"SELECT name, city, state FROM users WHERE ((salary * ?) + bonus) BETWEEN ? AND ?",
-			12.0, 50000.0, 100000.0,
+			int64(12), int64(50000), int64(100000),
+		),
+
+		Entry(
+			"IP within CIDR",
+			"ip within 10.0.0.0/8",
+			"SELECT name, city, state FROM users WHERE ip <<= ?",
+			"10.0.0.0/8",
+		),
+
+		Entry(
+			"IP in CIDR string",
+			"ip in cidr '2001:db8::/32'",
+			"SELECT name, city, state FROM users WHERE ip <<= ?",
+			"2001:db8::/32",
+		),
+
+		Entry(
+			"IP not within CIDR",
+			"ip not within 192.168.0.0/16",
+			"SELECT name, city, state FROM users WHERE NOT (ip <<= ?)",
+			"192.168.0.0/16",
+		),
+
+		Entry(
+			"IP equality",
+			"ip = 10.0.0.1",
+			"SELECT name, city, state FROM users WHERE ip = ?",
+			"10.0.0.1",
+		),
+
+		Entry(
+			"Version literal",
+			"version = v1.28.0",
+			"SELECT name, city, state FROM users WHERE version = ?",
+			"v1.28.0",
+		),
+
+		Entry(
+			"Version list",
+			"version in [v1.28.0, v1.29.0]",
+			"SELECT name, city, state FROM users WHERE version IN (?,?)",
+			"v1.28.0", "v1.29.0",
+		),
+
+		Entry(
+			"Large integer",
+			"id = 9007199254740993",
+			"SELECT name, city, state FROM users WHERE id = ?",
+			int64(9007199254740993),
+		),
+
+		Entry(
+			"Decimal",
+			"price = 0.1 + 0.2",
+			"SELECT name, city, state FROM users WHERE price = (? + ?)",
+			tsl.NewDecimal(big.NewRat(1, 10)), tsl.NewDecimal(big.NewRat(2, 10)),
 		),
 	)
 })